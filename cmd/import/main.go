@@ -0,0 +1,82 @@
+// cmd/import: import katalog lagu dari file CSV / JSON Lines.
+//
+//	go run ./cmd/import -file songs.csv
+//	go run ./cmd/import -file songs.jsonl -report report.json
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"back_music/internal/config"
+	"back_music/internal/database"
+	"back_music/internal/repository"
+	"back_music/internal/services"
+)
+
+func main() {
+	filePath := flag.String("file", "", "path ke file CSV atau JSONL")
+	format := flag.String("format", "", "csv | jsonl (default: dari ekstensi file)")
+	reportPath := flag.String("report", "", "simpan laporan per baris ke file JSON ini")
+	flag.Parse()
+
+	if *filePath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	importFormat, err := services.DetectImportFormat(*format, *filePath)
+	if err != nil {
+		log.Fatal("❌ ", err)
+	}
+
+	data, err := os.ReadFile(*filePath)
+	if err != nil {
+		log.Fatal("❌ Failed to read file: ", err)
+	}
+
+	if err := config.LoadConfig(); err != nil {
+		log.Println("⚠️ Config load warning:", err)
+	}
+	if err := database.ConnectDB(); err != nil {
+		log.Fatal("❌ Database connection failed: ", err)
+	}
+
+	importService := services.NewCatalogImportService(
+		repository.NewSongRepository(),
+		repository.NewArtistRepository(),
+		services.NewJobRunner(repository.NewJobRepository()),
+		repository.NewAuditLogRepository(),
+	)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	report, err := importService.Import(ctx, data, importFormat, func(processed, total int) {
+		log.Printf("📥 %d/%d rows processed", processed, total)
+	})
+	if report != nil {
+		for _, row := range report.Rows {
+			if row.Status == services.ImportRowInvalid {
+				log.Printf("⚠️ Row %d (%s): %v", row.Row, row.SpotifyID, row.Errors)
+			}
+		}
+		log.Printf("✅ Import finished: %d total, %d created, %d updated, %d skipped, %d invalid",
+			report.Total, report.Created, report.Updated, report.Skipped, report.Invalid)
+
+		if *reportPath != "" {
+			output, _ := json.MarshalIndent(report, "", "  ")
+			if err := os.WriteFile(*reportPath, output, 0o644); err != nil {
+				log.Println("⚠️ Failed to write report:", err)
+			}
+		}
+	}
+	if err != nil {
+		log.Fatal("❌ Import failed: ", err)
+	}
+}
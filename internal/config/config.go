@@ -4,6 +4,8 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -13,6 +15,45 @@ type Config struct {
     SpotifyClientSecret string
     RedirectURI         string
     
+    // Base URL Spotify bisa diarahkan ke stub lokal (mis. httptest) saat testing
+    SpotifyAPIBaseURL      string
+    SpotifyAccountsBaseURL string
+    
+    // Link akun Spotify user (PKCE): URL frontend tujuan redirect setelah callback (opsional)
+    SpotifyLinkRedirectURL string
+    
+    // Kunci enkripsi token akun pihak ketiga milik user (refresh token Spotify, token
+    // ListenBrainz). Jika kosong, kunci diturunkan dari JWT_SECRET.
+    TokenEncryptionKey string
+    
+    // Scrobbling ke server ListenBrainz-compatible. User boleh memakai URL sendiri jika
+    // host-nya ada di LISTENBRAINZ_ALLOWED_HOSTS (default hanya host LISTENBRAINZ_API_URL,
+    // "*" = semua host publik).
+    ListenBrainzAPIURL       string
+    ListenBrainzAllowedHosts []string
+    
+    // Penyimpanan file audio yang diupload admin (sumber audio "local"):
+    // STORAGE_BACKEND=local memakai AudioUploadDir, s3 memakai bucket S3-compatible.
+    StorageBackend    string
+    AudioUploadDir    string
+    S3Endpoint        string
+    S3Region          string
+    S3Bucket          string
+    S3AccessKeyID     string
+    S3SecretAccessKey string
+    S3PathStyle       bool
+    
+    // URL stream audio ditandatangani HMAC supaya <audio> bisa memutar tanpa JWT.
+    // Kunci kosong = diturunkan dari JWT_SECRET.
+    StreamSigningKey string
+    StreamURLTTL     time.Duration
+    
+    // Kuota harian YouTube Data API (unit). Pencarian background berhenti saat sisa kuota
+    // tinggal YouTubeInteractiveReserve supaya request user tetap terlayani.
+    YouTubeDailyQuota         int
+    YouTubeInteractiveReserve int
+    YouTubeResolveInterval    time.Duration // 0 = resolver background nonaktif
+    
     DBHost     string
     DBPort     string
     DBUser     string
@@ -26,10 +67,32 @@ type Config struct {
     SimilarityThreshold  float64
     ContentWeight       float64
     CollaborativeWeight float64
+    
+    // Normalisasi fitur audio untuk content-based similarity
+    FeatureNormalization string             // "zscore" atau "quantile"
+    FeatureWeights       map[string]float64 // override bobot per fitur, contoh: mode=0.5,tempo=1.2
+    FeatureStatsTTL      time.Duration
+    
+    // Discover Weekly
+    DiscoverPlaylistSize int
+    DiscoverActiveDays   int
+    
+    // Seeding katalog dari Spotify (lihat seed_profiles.json)
+    SeedProfiles       map[string]SeedProfile
+    DefaultSeedProfile string
 }
 
 var GlobalConfig *Config
 
+// DefaultJWTSecret dipakai jika JWT_SECRET kosong. Nilainya publik, jadi kunci lain tidak
+// boleh diturunkan darinya.
+const DefaultJWTSecret = "default-jwt-secret-change-in-production"
+
+// HasJWTSecret bernilai false jika JWT_SECRET tidak di-set (masih memakai DefaultJWTSecret).
+func (c *Config) HasJWTSecret() bool {
+    return c.JWTSecret != "" && c.JWTSecret != DefaultJWTSecret
+}
+
 func LoadConfig() error {
     if err := godotenv.Load(); err != nil {
         log.Println("No .env file found, using environment variables")
@@ -45,6 +108,43 @@ func LoadConfig() error {
     contentWeight, _ := strconv.ParseFloat(getEnv("CONTENT_WEIGHT", "0.7"), 64)
     collaborativeWeight, _ := strconv.ParseFloat(getEnv("COLLABORATIVE_WEIGHT", "0.3"), 64)
     
+    discoverPlaylistSize, err := strconv.Atoi(getEnv("DISCOVER_PLAYLIST_SIZE", "30"))
+    if err != nil || discoverPlaylistSize <= 0 {
+        discoverPlaylistSize = 30
+    }
+    discoverActiveDays, err := strconv.Atoi(getEnv("DISCOVER_ACTIVE_DAYS", "28"))
+    if err != nil || discoverActiveDays <= 0 {
+        discoverActiveDays = 28
+    }
+    
+    featureStatsTTL, err := time.ParseDuration(getEnv("FEATURE_STATS_TTL", "24h"))
+    if err != nil {
+        featureStatsTTL = 24 * time.Hour
+    }
+    
+    youtubeDailyQuota, err := strconv.Atoi(getEnv("YOUTUBE_DAILY_QUOTA", "10000"))
+    if err != nil || youtubeDailyQuota < 0 {
+        youtubeDailyQuota = 10000
+    }
+    youtubeInteractiveReserve, err := strconv.Atoi(getEnv("YOUTUBE_INTERACTIVE_RESERVE", "2000"))
+    if err != nil || youtubeInteractiveReserve < 0 {
+        youtubeInteractiveReserve = 2000
+    }
+    youtubeResolveInterval, err := time.ParseDuration(getEnv("YOUTUBE_RESOLVE_INTERVAL", "1h"))
+    if err != nil || youtubeResolveInterval < 0 {
+        youtubeResolveInterval = time.Hour
+    }
+    
+    streamURLTTL, err := time.ParseDuration(getEnv("STREAM_URL_TTL", "1h"))
+    if err != nil || streamURLTTL < time.Minute {
+        streamURLTTL = time.Hour
+    }
+    
+    seedProfiles, defaultSeedProfile := loadSeedProfiles(
+        getEnv("SEED_PROFILES_FILE", "seed_profiles.json"),
+        getEnv("SEED_PROFILE", "indonesia"),
+    )
+    
     // Set DB defaults based on environment
     var dbHost, dbPort, dbUser, dbPassword, dbName, dbSSLMode string
     if env == "production" {
@@ -68,7 +168,32 @@ func LoadConfig() error {
     GlobalConfig = &Config{
         SpotifyClientID:     getEnv("SPOTIFY_CLIENT_ID", ""),
         SpotifyClientSecret: getEnv("SPOTIFY_CLIENT_SECRET", ""),
-        RedirectURI:         getEnv("REDIRECT_URI", "http://localhost:8080/callback"),
+        RedirectURI:         getEnv("REDIRECT_URI", "http://localhost:8080/api/spotify/callback"),
+        
+        SpotifyAPIBaseURL:      getEnv("SPOTIFY_API_BASE_URL", "https://api.spotify.com/v1"),
+        SpotifyAccountsBaseURL: getEnv("SPOTIFY_ACCOUNTS_BASE_URL", "https://accounts.spotify.com"),
+        SpotifyLinkRedirectURL: getEnv("SPOTIFY_LINK_REDIRECT_URL", ""),
+        
+        TokenEncryptionKey: getEnv("TOKEN_ENCRYPTION_KEY", ""),
+        
+        ListenBrainzAPIURL:       getEnv("LISTENBRAINZ_API_URL", "https://api.listenbrainz.org"),
+        ListenBrainzAllowedHosts: parseList(getEnv("LISTENBRAINZ_ALLOWED_HOSTS", "")),
+        
+        StorageBackend:    strings.ToLower(getEnv("STORAGE_BACKEND", "local")),
+        AudioUploadDir:    getEnv("AUDIO_UPLOAD_DIR", "uploads/audio"),
+        S3Endpoint:        getEnv("S3_ENDPOINT", ""),
+        S3Region:          getEnv("S3_REGION", "us-east-1"),
+        S3Bucket:          getEnv("S3_BUCKET", ""),
+        S3AccessKeyID:     getEnv("S3_ACCESS_KEY_ID", ""),
+        S3SecretAccessKey: getEnv("S3_SECRET_ACCESS_KEY", ""),
+        S3PathStyle:       getEnv("S3_PATH_STYLE", "true") == "true",
+        
+        StreamSigningKey: getEnv("STREAM_SIGNING_KEY", ""),
+        StreamURLTTL:     streamURLTTL,
+        
+        YouTubeDailyQuota:         youtubeDailyQuota,
+        YouTubeInteractiveReserve: youtubeInteractiveReserve,
+        YouTubeResolveInterval:    youtubeResolveInterval,
         
         DBHost:     dbHost,
         DBPort:     dbPort,
@@ -78,11 +203,21 @@ func LoadConfig() error {
         DBSSLMode:  dbSSLMode,
         
         ServerPort: getEnv("SERVER_PORT", "8080"),
-        JWTSecret:  getEnv("JWT_SECRET", "default-jwt-secret-change-in-production"),
+        JWTSecret:  getEnv("JWT_SECRET", DefaultJWTSecret),
         
         SimilarityThreshold:  similarityThreshold,
         ContentWeight:       contentWeight,
         CollaborativeWeight: collaborativeWeight,
+        
+        FeatureNormalization: strings.ToLower(getEnv("FEATURE_NORMALIZATION", "zscore")),
+        FeatureWeights:       parseFeatureWeights(getEnv("FEATURE_WEIGHTS", "")),
+        FeatureStatsTTL:      featureStatsTTL,
+        
+        DiscoverPlaylistSize: discoverPlaylistSize,
+        DiscoverActiveDays:   discoverActiveDays,
+        
+        SeedProfiles:       seedProfiles,
+        DefaultSeedProfile: defaultSeedProfile,
     }
     
     if GlobalConfig.SpotifyClientID == "" || GlobalConfig.SpotifyClientSecret == "" {
@@ -97,4 +232,39 @@ func getEnv(key, defaultValue string) string {
         return value
     }
     return defaultValue
-}
\ No newline at end of file
+}
+
+// parseList membaca daftar dipisah koma (lowercase, entry kosong dibuang).
+func parseList(raw string) []string {
+    var items []string
+    for _, item := range strings.Split(raw, ",") {
+        if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+            items = append(items, item)
+        }
+    }
+    return items
+}
+
+// parseFeatureWeights membaca format "name=weight,name=weight".
+// Entry yang tidak valid diabaikan dengan warning.
+func parseFeatureWeights(raw string) map[string]float64 {
+    weights := make(map[string]float64)
+    for _, pair := range strings.Split(raw, ",") {
+        pair = strings.TrimSpace(pair)
+        if pair == "" {
+            continue
+        }
+        name, value, ok := strings.Cut(pair, "=")
+        if !ok {
+            log.Printf("⚠️ Invalid FEATURE_WEIGHTS entry: %q", pair)
+            continue
+        }
+        weight, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+        if err != nil || weight < 0 {
+            log.Printf("⚠️ Invalid FEATURE_WEIGHTS value for %q: %q", name, value)
+            continue
+        }
+        weights[strings.ToLower(strings.TrimSpace(name))] = weight
+    }
+    return weights
+}
@@ -0,0 +1,198 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// SeedQuery adalah satu query pencarian Spotify dengan kuota hasil per market.
+type SeedQuery struct {
+	Query string `json:"query"`
+	Limit int    `json:"limit,omitempty"` // 0 = pakai QueryLimit profile
+}
+
+// SeedProfile mendefinisikan sumber seeding katalog untuk satu region/genre.
+type SeedProfile struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Markets     []string `json:"markets"`         // kode negara ISO, mis. "ID"
+	Genre       string   `json:"genre,omitempty"` // label genre untuk lagu hasil seed
+	QueryLimit  int      `json:"query_limit,omitempty"`
+
+	Queries         []SeedQuery `json:"queries"`
+	TrendingQueries []SeedQuery `json:"trending_queries,omitempty"` // dipakai endpoint lagu populer
+
+	// Lagu lolos jika artist cocok AllowArtists atau judul mengandung TitleKeywords.
+	// Jika keduanya kosong, semua lagu lolos. DenyArtists selalu dibuang.
+	AllowArtists  []string `json:"allow_artists,omitempty"`
+	DenyArtists   []string `json:"deny_artists,omitempty"`
+	TitleKeywords []string `json:"title_keywords,omitempty"`
+	MinPopularity int      `json:"min_popularity,omitempty"`
+}
+
+const (
+	defaultSeedQueryLimit = 10
+	maxSeedQueryLimit     = 50 // batas Spotify search API
+)
+
+// QueryLimitFor mengembalikan kuota hasil untuk query (dibatasi 1..50).
+func (p *SeedProfile) QueryLimitFor(query SeedQuery) int {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = p.QueryLimit
+	}
+	if limit <= 0 {
+		limit = defaultSeedQueryLimit
+	}
+	if limit > maxSeedQueryLimit {
+		limit = maxSeedQueryLimit
+	}
+	return limit
+}
+
+// PrimaryMarket adalah market pertama profile (dipakai untuk pencarian biasa).
+func (p *SeedProfile) PrimaryMarket() string {
+	if len(p.Markets) == 0 {
+		return ""
+	}
+	return p.Markets[0]
+}
+
+func (p *SeedProfile) normalize() error {
+	p.Name = strings.ToLower(strings.TrimSpace(p.Name))
+	if p.Name == "" {
+		return errors.New("profile name is required")
+	}
+	if len(p.Queries) == 0 {
+		return fmt.Errorf("profile %q has no queries", p.Name)
+	}
+	for i, market := range p.Markets {
+		market = strings.ToUpper(strings.TrimSpace(market))
+		if len(market) != 2 {
+			return fmt.Errorf("profile %q: invalid market %q", p.Name, p.Markets[i])
+		}
+		p.Markets[i] = market
+	}
+	for _, queries := range [][]SeedQuery{p.Queries, p.TrendingQueries} {
+		for i := range queries {
+			queries[i].Query = strings.TrimSpace(queries[i].Query)
+			if queries[i].Query == "" {
+				return fmt.Errorf("profile %q has an empty query", p.Name)
+			}
+		}
+	}
+	lower := func(values []string) {
+		for i := range values {
+			values[i] = strings.ToLower(strings.TrimSpace(values[i]))
+		}
+	}
+	lower(p.AllowArtists)
+	lower(p.DenyArtists)
+	lower(p.TitleKeywords)
+	return nil
+}
+
+// LoadSeedProfiles membaca file JSON {"profiles": [...]}.
+// File tidak ada -> profile bawaan; file rusak -> error.
+func LoadSeedProfiles(path string) (map[string]SeedProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return DefaultSeedProfiles(), nil
+		}
+		return nil, err
+	}
+
+	var file struct {
+		Profiles []SeedProfile `json:"profiles"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid seed profiles file %s: %w", path, err)
+	}
+	if len(file.Profiles) == 0 {
+		return nil, fmt.Errorf("seed profiles file %s has no profiles", path)
+	}
+
+	profiles := make(map[string]SeedProfile, len(file.Profiles))
+	for _, profile := range file.Profiles {
+		if err := profile.normalize(); err != nil {
+			return nil, err
+		}
+		if _, exists := profiles[profile.Name]; exists {
+			return nil, fmt.Errorf("duplicate seed profile %q", profile.Name)
+		}
+		profiles[profile.Name] = profile
+	}
+	return profiles, nil
+}
+
+// DefaultSeedProfiles adalah profile Indonesia yang dulu di-hardcode di SpotifyService.
+func DefaultSeedProfiles() map[string]SeedProfile {
+	queries := func(values ...string) []SeedQuery {
+		result := make([]SeedQuery, len(values))
+		for i, value := range values {
+			result[i] = SeedQuery{Query: value}
+		}
+		return result
+	}
+
+	indonesia := SeedProfile{
+		Name:        "indonesia",
+		Description: "Pop Indonesia",
+		Markets:     []string{"ID"},
+		Genre:       "indonesian",
+		QueryLimit:  10,
+		Queries: queries(
+			"tulus", "raisa", "nadin amizah", "sheila on 7", "noah band", "ungu band",
+			"rossa", "judika", "armada band", "virgoun", "tiara andini", "lyodra",
+			"ziva magnolya", "yovie & nuno", "kerispatih", "d'masiv", "vierratale", "geisha band",
+			"lagu indonesia terbaru", "indonesian pop", "pop indonesia 2024",
+			"lagu indonesia viral", "chart indonesia",
+			`"tulus"`, `"raisa"`, `"nadin"`, `"sheila on 7"`,
+		),
+		TrendingQueries: []SeedQuery{
+			{Query: "lagu indonesia terbaru 2024", Limit: 20},
+			{Query: "indonesian viral hits", Limit: 20},
+			{Query: "spotify chart indonesia", Limit: 20},
+			{Query: "top hits indonesia", Limit: 20},
+			{Query: "trending indonesia", Limit: 20},
+		},
+		AllowArtists: []string{
+			"tulus", "raisa", "nadin", "sheila", "noah", "ungu", "rossa", "judika",
+			"armada", "virgoun", "tiara", "lyodra", "ziva", "yovie", "kerispatih",
+			"d'masiv", "vierra", "geisha", "last child", "fiersa", "ghea", "hari",
+			"gita", "iqbaal", "jidat", "kunto", "lale", "mansur", "naura", "ongen",
+		},
+		TitleKeywords: []string{
+			"indonesia", "jakarta", "bandung", "surabaya", "jogja", "yogyakarta",
+			"bali", "papua", "kalimantan", "sumatra", "jawa", "nusantara",
+			"merah", "putih", "sang", "saka", "garuda", "pancasila",
+		},
+	}
+	return map[string]SeedProfile{indonesia.Name: indonesia}
+}
+
+// loadSeedProfiles dipanggil LoadConfig; file yang rusak tidak menghentikan server.
+func loadSeedProfiles(path, defaultName string) (map[string]SeedProfile, string) {
+	profiles, err := LoadSeedProfiles(path)
+	if err != nil {
+		log.Printf("⚠️ Failed to load seed profiles, using built-in defaults: %v", err)
+		profiles = DefaultSeedProfiles()
+	}
+
+	defaultName = strings.ToLower(strings.TrimSpace(defaultName))
+	if _, ok := profiles[defaultName]; !ok {
+		log.Printf("⚠️ Seed profile %q not found, falling back", defaultName)
+		defaultName = ""
+		for name := range profiles {
+			if defaultName == "" || name < defaultName {
+				defaultName = name
+			}
+		}
+	}
+	return profiles, defaultName
+}
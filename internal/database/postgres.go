@@ -89,8 +89,27 @@ func AutoMigrate() error {
 	models := []interface{}{
 		&models.User{},
 		&models.Song{},
+		&models.Album{},
 		&models.UserLike{},
 		&models.UserPlay{},
+		&models.FeatureStat{},
+		&models.Playlist{},
+		&models.PlaylistItem{},
+		&models.PlayEvent{},
+		&models.ChartEntry{},
+		&models.Artist{},
+		&models.SongArtist{},
+		&models.ArtistSimilarity{},
+		&models.AuditLog{},
+		&models.Job{},
+		&models.DuplicateCluster{},
+		&models.SpotifyAccount{},
+		&models.SpotifyAuthState{},
+		&models.ScrobbleAccount{},
+		&models.ScrobbleQueueItem{},
+		&models.SongSource{},
+		&models.YoutubeQuotaUsage{},
+		&models.YoutubeMiss{},
 	}
 
 	for _, model := range models {
@@ -107,6 +126,7 @@ func AutoMigrate() error {
 	DB.Exec("CREATE INDEX IF NOT EXISTS idx_songs_popularity ON songs(popularity DESC)")
 	DB.Exec("CREATE INDEX IF NOT EXISTS idx_songs_artist ON songs(artist)")
 	DB.Exec("CREATE INDEX IF NOT EXISTS idx_songs_spotify_id ON songs(spotify_id)")
+	DB.Exec("CREATE INDEX IF NOT EXISTS idx_songs_album_track ON songs(album_id, disc_number, track_number)")
 	
 	// UserLike indexes for faster user preference queries
 	DB.Exec("CREATE INDEX IF NOT EXISTS idx_user_likes_user_id ON user_likes(user_id)")
@@ -118,7 +138,111 @@ func AutoMigrate() error {
 	DB.Exec("CREATE INDEX IF NOT EXISTS idx_user_plays_song_id ON user_plays(song_id)")
 	DB.Exec("CREATE INDEX IF NOT EXISTS idx_user_plays_play_count ON user_plays(user_id, play_count DESC)")
 	DB.Exec("CREATE INDEX IF NOT EXISTS idx_user_plays_last_played ON user_plays(user_id, last_played DESC)")
+
+	// PlayEvent indexes untuk agregasi chart per window waktu
+	DB.Exec("CREATE INDEX IF NOT EXISTS idx_play_events_played_at_song ON play_events(played_at, song_id)")
+
+	// Playlist indexes
+	DB.Exec("CREATE INDEX IF NOT EXISTS idx_playlist_items_position ON playlist_items(playlist_id, position)")
+
+	// Nama artist tanpa Spotify ID harus unik supaya FindOrCreateArtist bisa ON CONFLICT
+	if err := migrateArtistNames(); err != nil {
+		return fmt.Errorf("failed to migrate artist names: %w", err)
+	}
+
+	// Full-text search lagu
+	if err := migrateSongSearch(); err != nil {
+		log.Printf("⚠️ Failed to set up song full-text search: %v", err)
+	}
 	
 	log.Println("✅ Database migration & indexes completed")
 	return nil
 }
+
+
+// migrateArtistNames menggabungkan artist tanpa Spotify ID yang bernama sama (sisa
+// find-then-insert yang balapan) ke artist tertua, lalu membuat partial unique index
+// pada normalized_name. Cache similar artists milik artist yang digabung dibuang saja.
+func migrateArtistNames() error {
+	statements := []string{
+		`CREATE TEMP TABLE artist_merges ON COMMIT DROP AS
+			SELECT id AS loser_id, keeper_id FROM (
+				SELECT id, first_value(id) OVER (PARTITION BY normalized_name ORDER BY created_at, id) AS keeper_id
+				FROM artists WHERE spotify_id IS NULL
+			) ranked WHERE id <> keeper_id`,
+		`INSERT INTO song_artists (song_id, artist_id, position)
+			SELECT sa.song_id, m.keeper_id, sa.position
+			FROM song_artists sa JOIN artist_merges m ON m.loser_id = sa.artist_id
+			ON CONFLICT DO NOTHING`,
+		"DELETE FROM song_artists WHERE artist_id IN (SELECT loser_id FROM artist_merges)",
+		`DELETE FROM artist_similarities
+			WHERE artist_id IN (SELECT loser_id FROM artist_merges)
+			OR similar_artist_id IN (SELECT loser_id FROM artist_merges)`,
+		`UPDATE chart_entries SET artist_id = m.keeper_id
+			FROM artist_merges m WHERE chart_entries.artist_id = m.loser_id`,
+		"DELETE FROM artists WHERE id IN (SELECT loser_id FROM artist_merges)",
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_artists_normalized_name_unlinked
+			ON artists(normalized_name) WHERE spotify_id IS NULL`,
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		for _, stmt := range statements {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// songSearchVectorSQL menghitung tsvector berbobot: title (A), artist (B), album (C), genre (D).
+// Teks di-unaccent dulu supaya "beyonce" cocok dengan "Beyoncé".
+// Dipakai oleh trigger dan backfill agar keduanya selalu sama.
+const songSearchVectorSQL = `
+	setweight(to_tsvector('simple', immutable_unaccent(coalesce(%[1]stitle, ''))), 'A') ||
+	setweight(to_tsvector('simple', immutable_unaccent(coalesce(%[1]sartist, ''))), 'B') ||
+	setweight(to_tsvector('simple', immutable_unaccent(coalesce(%[1]salbum, ''))), 'C') ||
+	setweight(to_tsvector('simple', immutable_unaccent(coalesce(%[1]sgenre, ''))), 'D')`
+
+// migrateSongSearch menambah kolom songs.search_vector yang dijaga trigger, plus GIN index
+// untuk full-text dan trigram (fallback typo). Kolom ini sengaja tidak ada di models.Song
+// supaya Save() tidak pernah menimpanya.
+func migrateSongSearch() error {
+	statements := []string{
+		"CREATE EXTENSION IF NOT EXISTS pg_trgm",
+		"CREATE EXTENSION IF NOT EXISTS unaccent",
+		// unaccent() bawaan STABLE sehingga tidak bisa dipakai di index expression
+		`CREATE OR REPLACE FUNCTION immutable_unaccent(text) RETURNS text AS $$
+			SELECT public.unaccent('public.unaccent', $1)
+		$$ LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT`,
+		// song_search_text adalah teks title + artist ter-normalisasi untuk pencarian trigram
+		`CREATE OR REPLACE FUNCTION song_search_text(title text, artist text) RETURNS text AS $$
+			SELECT lower(immutable_unaccent(coalesce(title, '') || ' ' || coalesce(artist, '')))
+		$$ LANGUAGE sql IMMUTABLE PARALLEL SAFE`,
+		"ALTER TABLE songs ADD COLUMN IF NOT EXISTS search_vector tsvector",
+		`CREATE OR REPLACE FUNCTION songs_search_vector_update() RETURNS trigger AS $$
+		BEGIN
+			NEW.search_vector := ` + fmt.Sprintf(songSearchVectorSQL, "NEW.") + `;
+			RETURN NEW;
+		END
+		$$ LANGUAGE plpgsql`,
+		"DROP TRIGGER IF EXISTS trg_songs_search_vector ON songs",
+		`CREATE TRIGGER trg_songs_search_vector
+		BEFORE INSERT OR UPDATE OF title, artist, album, genre ON songs
+		FOR EACH ROW EXECUTE FUNCTION songs_search_vector_update()`,
+		// Hitung ulang baris lama (atau yang dihitung dengan definisi sebelumnya)
+		"UPDATE songs SET search_vector = " + fmt.Sprintf(songSearchVectorSQL, "") +
+			" WHERE search_vector IS DISTINCT FROM " + fmt.Sprintf(songSearchVectorSQL, ""),
+		"CREATE INDEX IF NOT EXISTS idx_songs_search_vector ON songs USING GIN(search_vector)",
+		"CREATE INDEX IF NOT EXISTS idx_songs_search_trgm ON songs USING GIN(song_search_text(title, artist) gin_trgm_ops)",
+		"CREATE INDEX IF NOT EXISTS idx_songs_title_trgm ON songs USING GIN(lower(immutable_unaccent(title)) gin_trgm_ops)",
+		"CREATE INDEX IF NOT EXISTS idx_artists_name_trgm ON artists USING GIN(immutable_unaccent(normalized_name) gin_trgm_ops)",
+	}
+
+	for _, stmt := range statements {
+		if err := DB.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,667 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"back_music/internal/models"
+	"back_music/internal/repository"
+	"back_music/internal/services"
+)
+
+// maxImportFileSize membatasi ukuran file import yang diupload.
+const maxImportFileSize = 20 << 20
+
+type AdminHandler struct {
+	catalogService   services.CatalogService
+	importService    services.CatalogImportService
+	duplicateService services.DuplicateService
+	spotifyService   services.SpotifyService
+	jobRunner        services.JobRunner
+	audioSources     services.AudioSourceService
+	youtubeService   services.YouTubeService
+	uploadService    services.UploadService
+}
+
+func NewAdminHandler(
+	catalogService services.CatalogService,
+	importService services.CatalogImportService,
+	duplicateService services.DuplicateService,
+	spotifyService services.SpotifyService,
+	jobRunner services.JobRunner,
+	audioSources services.AudioSourceService,
+	youtubeService services.YouTubeService,
+	uploadService services.UploadService,
+) *AdminHandler {
+	return &AdminHandler{
+		catalogService:   catalogService,
+		importService:    importService,
+		duplicateService: duplicateService,
+		spotifyService:   spotifyService,
+		jobRunner:        jobRunner,
+		audioSources:     audioSources,
+		youtubeService:   youtubeService,
+		uploadService:    uploadService,
+	}
+}
+
+type createSongRequest struct {
+	SpotifyID string `json:"spotify_id"`
+	services.SongPatch
+}
+
+type mergeSongsRequest struct {
+	TargetID string `json:"target_id" binding:"required"`
+}
+
+type mergeDuplicateRequest struct {
+	SurvivorID string `json:"survivor_id"`
+}
+
+type bulkUpdateRequest struct {
+	Filter services.SongBulkFilter `json:"filter"`
+	Set    services.SongBulkSet    `json:"set"`
+}
+
+func (h *AdminHandler) CreateSong(c *gin.Context) {
+	var req createSongRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid request body",
+		})
+		return
+	}
+
+	song, err := h.catalogService.CreateSong(c.GetUint("user_id"), req.SpotifyID, req.SongPatch)
+	if err != nil {
+		h.respondCatalogError(c, err, "Failed to create song")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "Song created",
+		"data":    song,
+	})
+}
+
+func (h *AdminHandler) UpdateSong(c *gin.Context) {
+	songID, ok := parseSongIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var patch services.SongPatch
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid request body",
+		})
+		return
+	}
+
+	song, err := h.catalogService.UpdateSong(c.GetUint("user_id"), songID, patch)
+	if err != nil {
+		h.respondCatalogError(c, err, "Failed to update song")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Song updated",
+		"data":    song,
+	})
+}
+
+func (h *AdminHandler) DeleteSong(c *gin.Context) {
+	songID, ok := parseSongIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.catalogService.DeleteSong(c.GetUint("user_id"), songID); err != nil {
+		h.respondCatalogError(c, err, "Failed to delete song")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Song deleted",
+	})
+}
+
+// MergeSongs menggabungkan lagu :id (duplikat) ke target_id; lagu :id dihapus.
+func (h *AdminHandler) MergeSongs(c *gin.Context) {
+	sourceID, ok := parseSongIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req mergeSongsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "target_id is required",
+		})
+		return
+	}
+	if _, err := uuid.Parse(req.TargetID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid target_id format",
+		})
+		return
+	}
+
+	if err := h.catalogService.MergeSongs(c.GetUint("user_id"), sourceID, req.TargetID); err != nil {
+		h.respondCatalogError(c, err, "Failed to merge songs")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Songs merged",
+		"data": gin.H{
+			"merged_id": sourceID,
+			"target_id": req.TargetID,
+		},
+	})
+}
+
+func (h *AdminHandler) BulkUpdateSongs(c *gin.Context) {
+	var req bulkUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid request body",
+		})
+		return
+	}
+
+	affected, err := h.catalogService.BulkUpdate(c.GetUint("user_id"), req.Filter, req.Set)
+	if err != nil {
+		h.respondCatalogError(c, err, "Failed to bulk update songs")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Songs updated",
+		"data": gin.H{
+			"affected": affected,
+		},
+	})
+}
+
+func (h *AdminHandler) GetSongStats(c *gin.Context) {
+	songID, ok := parseSongIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	stats, err := h.catalogService.GetSongStats(songID)
+	if err != nil {
+		h.respondCatalogError(c, err, "Failed to fetch song stats")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Song stats fetched",
+		"data":    stats,
+	})
+}
+
+func (h *AdminHandler) GetAuditLogs(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	logs, err := h.catalogService.ListAuditLogs(c.Query("entity_type"), c.Query("entity_id"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to fetch audit logs",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Audit logs fetched",
+		"data":    logs,
+	})
+}
+
+// ImportCatalog menerima file CSV/JSONL (field "file") dan menjalankannya sebagai job background.
+func (h *AdminHandler) ImportCatalog(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "File is required",
+		})
+		return
+	}
+	if fileHeader.Size > maxImportFileSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"status":  "error",
+			"message": "File is too large (max 20MB)",
+		})
+		return
+	}
+
+	format, err := services.DetectImportFormat(c.PostForm("format"), fileHeader.Filename)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Failed to read file",
+		})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Failed to read file",
+		})
+		return
+	}
+
+	job, err := h.importService.StartImportJob(c.GetUint("user_id"), data, format, fileHeader.Filename)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to start import",
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"status":  "success",
+		"message": "Import started in background",
+		"data":    job,
+	})
+}
+
+// GetJob mengembalikan status job (progress, error log) beserta hasilnya jika sudah selesai.
+func (h *AdminHandler) GetJob(c *gin.Context) {
+	jobID, ok := parseJobIDParam(c)
+	if !ok {
+		return
+	}
+
+	job, err := h.jobRunner.Get(jobID)
+	if err != nil {
+		h.respondJobError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Job fetched",
+		"data":    job,
+	})
+}
+
+func parseJobIDParam(c *gin.Context) (string, bool) {
+	jobID := c.Param("id")
+	if _, err := uuid.Parse(jobID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid job ID format",
+		})
+		return "", false
+	}
+	return jobID, true
+}
+
+func (h *AdminHandler) respondJobError(c *gin.Context, err error) {
+	if errors.Is(err, repository.ErrJobNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "Job not found",
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"status":  "error",
+		"message": "Failed to fetch job",
+	})
+}
+
+// GetSpotifyTokenStats menampilkan metrik refresh token Spotify (jumlah refresh, kegagalan beruntun).
+func (h *AdminHandler) GetSpotifyTokenStats(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Spotify token stats fetched",
+		"data":    h.spotifyService.TokenStats(),
+	})
+}
+
+// GetYouTubeQuota menampilkan pemakaian kuota YouTube Data API hari ini dan 14 hari terakhir.
+func (h *AdminHandler) GetYouTubeQuota(c *gin.Context) {
+	status, err := h.youtubeService.QuotaStatus()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to fetch YouTube quota",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "YouTube quota fetched",
+		"data":    status,
+	})
+}
+
+// GetSeedProfiles menampilkan profile seeding yang dimuat dari config.
+func (h *AdminHandler) GetSeedProfiles(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Seed profiles fetched",
+		"data":    h.spotifyService.ListSeedProfiles(),
+	})
+}
+
+// SeedSongs menjalankan seeding lagu dari Spotify (?profile=, default dari config) sebagai job background.
+func (h *AdminHandler) SeedSongs(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 {
+		limit = 100
+	}
+	if limit > 200 {
+		limit = 200 // Safety limit
+	}
+
+	profile, err := h.spotifyService.GetSeedProfile(c.Query("profile"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	params := map[string]interface{}{"limit": limit, "profile": profile.Name}
+	job, err := h.jobRunner.Start(c.GetUint("user_id"), models.JobTypeSpotifySeed, params,
+		func(ctx context.Context, progress services.JobProgress) (interface{}, error) {
+			return h.spotifyService.SeedSongsFromSpotify(ctx, profile.Name, limit, progress)
+		})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to start seeding",
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"status":  "success",
+		"message": "Seeding started in background",
+		"data":    job,
+	})
+}
+
+func (h *AdminHandler) ListJobs(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	jobs, total, err := h.jobRunner.List(c.Query("type"), c.Query("status"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to fetch jobs",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Jobs fetched",
+		"data": gin.H{
+			"jobs":   jobs,
+			"total":  total,
+			"limit":  limit,
+			"offset": offset,
+		},
+	})
+}
+
+// CancelJob membatalkan job yang masih queued/running. Job berhenti di item berikutnya.
+func (h *AdminHandler) CancelJob(c *gin.Context) {
+	jobID, ok := parseJobIDParam(c)
+	if !ok {
+		return
+	}
+
+	job, err := h.jobRunner.Cancel(jobID)
+	if err != nil {
+		if errors.Is(err, services.ErrJobNotCancelable) {
+			c.JSON(http.StatusConflict, gin.H{
+				"status":  "error",
+				"message": "Job already finished",
+				"data":    job,
+			})
+			return
+		}
+		h.respondJobError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"status":  "success",
+		"message": "Job cancellation requested",
+		"data":    job,
+	})
+}
+
+// ListDuplicates mengembalikan antrian review cluster duplikat (default: pending).
+func (h *AdminHandler) ListDuplicates(c *gin.Context) {
+	status := c.DefaultQuery("status", models.DuplicateStatusPending)
+	switch status {
+	case models.DuplicateStatusPending, models.DuplicateStatusMerged, models.DuplicateStatusDismissed,
+		models.DuplicateStatusResolved:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid status (pending, merged, dismissed, resolved)",
+		})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	clusters, total, err := h.duplicateService.ListClusters(status, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to fetch duplicate clusters",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Duplicate clusters fetched",
+		"data": gin.H{
+			"clusters": clusters,
+			"total":    total,
+			"limit":    limit,
+			"offset":   offset,
+		},
+	})
+}
+
+// ScanDuplicates menjalankan deteksi duplikat (ISRC + artist/judul/durasi) secara sinkron.
+func (h *AdminHandler) ScanDuplicates(c *gin.Context) {
+	result, err := h.duplicateService.Scan()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to scan duplicates",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Duplicate scan finished",
+		"data":    result,
+	})
+}
+
+// MergeDuplicate menggabungkan seluruh lagu di cluster ke survivor_id
+// (kosong = kandidat yang disarankan).
+func (h *AdminHandler) MergeDuplicate(c *gin.Context) {
+	clusterID, ok := parseClusterIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req mergeDuplicateRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "Invalid request body",
+			})
+			return
+		}
+	}
+	if req.SurvivorID != "" {
+		if _, err := uuid.Parse(req.SurvivorID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "Invalid survivor_id format",
+			})
+			return
+		}
+	}
+
+	cluster, err := h.duplicateService.MergeCluster(c.GetUint("user_id"), clusterID, req.SurvivorID)
+	if err != nil {
+		h.respondDuplicateError(c, err, "Failed to merge duplicates")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Duplicates merged",
+		"data":    cluster,
+	})
+}
+
+func (h *AdminHandler) DismissDuplicate(c *gin.Context) {
+	clusterID, ok := parseClusterIDParam(c)
+	if !ok {
+		return
+	}
+
+	cluster, err := h.duplicateService.DismissCluster(c.GetUint("user_id"), clusterID)
+	if err != nil {
+		h.respondDuplicateError(c, err, "Failed to dismiss duplicates")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Duplicate cluster dismissed",
+		"data":    cluster,
+	})
+}
+
+func parseClusterIDParam(c *gin.Context) (string, bool) {
+	clusterID := c.Param("id")
+	if _, err := uuid.Parse(clusterID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid cluster ID format",
+		})
+		return "", false
+	}
+	return clusterID, true
+}
+
+func (h *AdminHandler) respondDuplicateError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, repository.ErrDuplicateClusterNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "Duplicate cluster not found",
+		})
+	case errors.Is(err, services.ErrSurvivorNotInCluster):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	default:
+		h.respondCatalogError(c, err, message)
+	}
+}
+
+func parseSongIDParam(c *gin.Context, name string) (string, bool) {
+	songID := c.Param(name)
+	if _, err := uuid.Parse(songID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid song ID format",
+		})
+		return "", false
+	}
+	return songID, true
+}
+
+func (h *AdminHandler) respondCatalogError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, repository.ErrSongNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "Song not found",
+		})
+	case errors.Is(err, services.ErrInvalidCatalogInput), errors.Is(err, repository.ErrMergeSameSong):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": message,
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"back_music/internal/repository"
+	"back_music/internal/services"
+)
+
+type addSourceRequest struct {
+	URL         string `json:"url" binding:"required"`
+	DurationMs  int    `json:"duration_ms"`
+	BitrateKbps int    `json:"bitrate_kbps"`
+}
+
+// ListSongSources menampilkan semua sumber audio lagu, termasuk yang broken.
+func (h *AdminHandler) ListSongSources(c *gin.Context) {
+	songID, ok := parseSongIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	sources, err := h.audioSources.ListSources(songID)
+	if err != nil {
+		h.respondSourceError(c, err, "Failed to fetch audio sources")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Audio sources fetched",
+		"data":    sources,
+	})
+}
+
+// AddSongSource mendaftarkan URL audio eksternal untuk lagu.
+func (h *AdminHandler) AddSongSource(c *gin.Context) {
+	songID, ok := parseSongIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req addSourceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	source, err := h.audioSources.AddExternalSource(songID, req.URL, req.DurationMs, req.BitrateKbps)
+	if err != nil {
+		h.respondSourceError(c, err, "Failed to add audio source")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "Audio source added",
+		"data":    source,
+	})
+}
+
+// UploadSongAudio menyimpan file audio (multipart field `file`, mp3/flac/ogg) ke storage
+// dan menautkannya ke lagu. File yang sama persis untuk lagu yang sama tidak disimpan ulang.
+func (h *AdminHandler) UploadSongAudio(c *gin.Context) {
+	songID, ok := parseSongIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "File is required",
+		})
+		return
+	}
+	if fileHeader.Size > services.MaxAudioUploadSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"status":  "error",
+			"message": "File is too large (max 100MB)",
+		})
+		return
+	}
+
+	durationMs, _ := strconv.Atoi(c.PostForm("duration_ms"))
+	bitrateKbps, _ := strconv.Atoi(c.PostForm("bitrate_kbps"))
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Failed to read file",
+		})
+		return
+	}
+	defer file.Close()
+
+	result, err := h.uploadService.UploadSongAudio(c.Request.Context(), songID, fileHeader.Filename, file, durationMs, bitrateKbps)
+	if err != nil {
+		h.respondSourceError(c, err, "Failed to save audio file")
+		return
+	}
+
+	if result.Deduplicated {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "success",
+			"message": "Audio file already uploaded",
+			"data":    result,
+		})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "Audio file uploaded",
+		"data":    result,
+	})
+}
+
+// VerifySongSources memeriksa ulang semua sumber lagu secara sinkron.
+func (h *AdminHandler) VerifySongSources(c *gin.Context) {
+	songID, ok := parseSongIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	sources, err := h.audioSources.VerifySources(c.Request.Context(), songID)
+	if err != nil {
+		h.respondSourceError(c, err, "Failed to verify audio sources")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Audio sources verified",
+		"data":    sources,
+	})
+}
+
+func (h *AdminHandler) DeleteSongSource(c *gin.Context) {
+	songID, ok := parseSongIDParam(c, "id")
+	if !ok {
+		return
+	}
+	sourceID, err := strconv.ParseUint(c.Param("source_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid source ID",
+		})
+		return
+	}
+
+	if err := h.audioSources.RemoveSource(songID, uint(sourceID)); err != nil {
+		h.respondSourceError(c, err, "Failed to delete audio source")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Audio source deleted",
+	})
+}
+
+func (h *AdminHandler) respondSourceError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, repository.ErrSongSourceNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "Audio source not found",
+		})
+	case errors.Is(err, services.ErrAudioTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"status":  "error",
+			"message": "File is too large (max 100MB)",
+		})
+	case errors.Is(err, services.ErrInvalidSourceURL), errors.Is(err, services.ErrUnsupportedAudioFormat):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	default:
+		h.respondCatalogError(c, err, message)
+	}
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"back_music/internal/repository"
+)
+
+type AlbumHandler struct {
+	albumRepo repository.AlbumRepository
+	songRepo  repository.SongRepository
+}
+
+func NewAlbumHandler(albumRepo repository.AlbumRepository, songRepo repository.SongRepository) *AlbumHandler {
+	return &AlbumHandler{
+		albumRepo: albumRepo,
+		songRepo:  songRepo,
+	}
+}
+
+// GetAlbumByID mengembalikan album beserta tracklist berurutan (dengan status like jika login).
+func (h *AlbumHandler) GetAlbumByID(c *gin.Context) {
+	albumID := c.Param("id")
+	if _, err := uuid.Parse(albumID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid album ID format",
+		})
+		return
+	}
+
+	album, err := h.albumRepo.GetAlbumByID(albumID)
+	if err != nil {
+		if errors.Is(err, repository.ErrAlbumNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"status":  "error",
+				"message": "Album not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to fetch album",
+		})
+		return
+	}
+
+	if userID := c.GetUint("user_id"); userID > 0 && len(album.Tracks) > 0 {
+		songIDs := make([]string, len(album.Tracks))
+		for i, track := range album.Tracks {
+			songIDs[i] = track.ID
+		}
+		likedMap, _ := h.songRepo.GetLikedSongIDs(userID, songIDs)
+		for i := range album.Tracks {
+			album.Tracks[i].IsLiked = likedMap[album.Tracks[i].ID]
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Album fetched successfully",
+		"data":    album,
+	})
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"back_music/internal/repository"
+	"back_music/internal/services"
+)
+
+type ArtistHandler struct {
+	artistService services.ArtistService
+}
+
+func NewArtistHandler(artistService services.ArtistService) *ArtistHandler {
+	return &ArtistHandler{artistService: artistService}
+}
+
+func (h *ArtistHandler) SearchArtists(c *gin.Context) {
+	query := c.Query("q")
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Search query is required",
+		})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 || limit > 50 {
+		limit = 20
+	}
+
+	artists, err := h.artistService.SearchArtists(query, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to search artists",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Search completed",
+		"data":    artists,
+	})
+}
+
+func (h *ArtistHandler) GetArtistByID(c *gin.Context) {
+	artistID := c.Param("id")
+	if _, err := uuid.Parse(artistID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid artist ID format",
+		})
+		return
+	}
+
+	detail, err := h.artistService.GetArtistDetail(artistID, c.GetUint("user_id"))
+	if err != nil {
+		h.respondArtistError(c, err, "Failed to fetch artist")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Artist fetched successfully",
+		"data":    detail,
+	})
+}
+
+func (h *ArtistHandler) GetSimilarArtists(c *gin.Context) {
+	artistID := c.Param("id")
+	if _, err := uuid.Parse(artistID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid artist ID format",
+		})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit <= 0 || limit > 20 {
+		limit = 10
+	}
+
+	similar, err := h.artistService.GetSimilarArtists(artistID, limit)
+	if err != nil {
+		h.respondArtistError(c, err, "Failed to fetch similar artists")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Similar artists fetched",
+		"data": gin.H{
+			"artist_id": artistID,
+			"similar":   similar,
+			"count":     len(similar),
+		},
+	})
+}
+
+func (h *ArtistHandler) respondArtistError(c *gin.Context, err error, message string) {
+	if errors.Is(err, repository.ErrArtistNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "Artist not found",
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"status":  "error",
+		"message": message,
+	})
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"back_music/internal/models"
+	"back_music/internal/repository"
+	"back_music/internal/services"
+)
+
+type ChartHandler struct {
+	chartService services.ChartService
+	songRepo     repository.SongRepository
+}
+
+func NewChartHandler(chartService services.ChartService, songRepo repository.SongRepository) *ChartHandler {
+	return &ChartHandler{
+		chartService: chartService,
+		songRepo:     songRepo,
+	}
+}
+
+var validChartTypes = map[string]bool{
+	models.ChartTopSongs:   true,
+	models.ChartTopArtists: true,
+	models.ChartGenre:      true,
+	models.ChartRising:     true,
+}
+
+// GetChart: GET /api/charts?type=top_songs&period=weekly&genre=pop&date=2026-01-31&limit=50
+func (h *ChartHandler) GetChart(c *gin.Context) {
+	chartType := c.DefaultQuery("type", models.ChartTopSongs)
+	period := c.DefaultQuery("period", models.ChartPeriodWeekly)
+	genre := c.Query("genre")
+
+	if !validChartTypes[chartType] {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid chart type (top_songs, top_artists, genre, rising)",
+		})
+		return
+	}
+	if period != models.ChartPeriodDaily && period != models.ChartPeriodWeekly {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid period (daily, weekly)",
+		})
+		return
+	}
+	if chartType == models.ChartGenre && genre == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Genre is required for genre charts",
+		})
+		return
+	}
+	if chartType != models.ChartGenre {
+		genre = ""
+	}
+
+	var snapshotDate time.Time
+	if dateStr := c.Query("date"); dateStr != "" {
+		parsed, err := time.Parse("2006-01-02", dateStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "Invalid date format, use YYYY-MM-DD",
+			})
+			return
+		}
+		snapshotDate = parsed
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 50 {
+		limit = 50
+	}
+
+	entries, snapshotDate, err := h.chartService.GetChart(chartType, period, genre, snapshotDate, limit)
+	if err != nil {
+		if errors.Is(err, repository.ErrChartNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"status":  "error",
+				"message": "Chart not available",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to fetch chart",
+		})
+		return
+	}
+
+	if userID := c.GetUint("user_id"); userID > 0 {
+		songIDs := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			if entry.Song != nil {
+				songIDs = append(songIDs, entry.Song.ID)
+			}
+		}
+		likedMap, _ := h.songRepo.GetLikedSongIDs(userID, songIDs)
+		for i := range entries {
+			if entries[i].Song != nil {
+				entries[i].Song.IsLiked = likedMap[entries[i].Song.ID]
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Chart fetched",
+		"data": gin.H{
+			"type":          chartType,
+			"period":        period,
+			"genre":         genre,
+			"snapshot_date": snapshotDate.Format("2006-01-02"),
+			"entries":       entries,
+			"total":         len(entries),
+		},
+	})
+}
+
+// GetChartGenres: GET /api/charts/genres?period=weekly&date=2026-01-31
+func (h *ChartHandler) GetChartGenres(c *gin.Context) {
+	period := c.DefaultQuery("period", models.ChartPeriodWeekly)
+
+	snapshotDate := time.Now().AddDate(0, 0, -1)
+	if dateStr := c.Query("date"); dateStr != "" {
+		parsed, err := time.Parse("2006-01-02", dateStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "Invalid date format, use YYYY-MM-DD",
+			})
+			return
+		}
+		snapshotDate = parsed
+	}
+
+	genres, err := h.chartService.GetChartGenres(period, snapshotDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to fetch chart genres",
+		})
+		return
+	}
+	if genres == nil {
+		genres = []string{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Chart genres fetched",
+		"data": gin.H{
+			"period":        period,
+			"snapshot_date": snapshotDate.Format("2006-01-02"),
+			"genres":        genres,
+		},
+	})
+}
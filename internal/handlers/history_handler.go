@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"back_music/internal/repository"
+	"back_music/internal/services"
+)
+
+// maxHistoryFileSize: zip export Spotify bertahun-tahun bisa puluhan MB.
+const maxHistoryFileSize = 50 << 20
+
+type HistoryHandler struct {
+	historyService services.ListeningHistoryService
+}
+
+func NewHistoryHandler(historyService services.ListeningHistoryService) *HistoryHandler {
+	return &HistoryHandler{historyService: historyService}
+}
+
+// ImportHistory menerima Spotify Extended Streaming History (.json / .zip) atau
+// export scrobble Last.fm (.csv) dan mem-backfill riwayat dengar di background.
+func (h *HistoryHandler) ImportHistory(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "File is required",
+		})
+		return
+	}
+	if fileHeader.Size > maxHistoryFileSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"status":  "error",
+			"message": "File is too large (max 50MB)",
+		})
+		return
+	}
+
+	format, err := services.DetectHistoryFormat(c.PostForm("format"), fileHeader.Filename)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Failed to read file",
+		})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Failed to read file",
+		})
+		return
+	}
+
+	job, err := h.historyService.StartImport(c.GetUint("user_id"), data, format, fileHeader.Filename)
+	if err != nil {
+		// Error parse file dilaporkan apa adanya supaya user tahu file mana yang salah
+		if errors.Is(err, services.ErrInvalidHistoryFile) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to start history import",
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"status":  "success",
+		"message": "History import started in background",
+		"data":    job,
+	})
+}
+
+func (h *HistoryHandler) GetImport(c *gin.Context) {
+	jobID := c.Param("id")
+	if _, err := uuid.Parse(jobID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid job ID format",
+		})
+		return
+	}
+
+	job, err := h.historyService.GetImportJob(c.GetUint("user_id"), jobID)
+	if err != nil {
+		if errors.Is(err, repository.ErrJobNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"status":  "error",
+				"message": "Import job not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to fetch import job",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Import job fetched",
+		"data":    job,
+	})
+}
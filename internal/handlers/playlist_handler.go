@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"back_music/internal/models"
+	"back_music/internal/repository"
+	"back_music/internal/services"
+)
+
+type PlaylistHandler struct {
+	playlistRepo    repository.PlaylistRepository
+	songRepo        repository.SongRepository
+	discoverService services.DiscoverService
+}
+
+func NewPlaylistHandler(
+	playlistRepo repository.PlaylistRepository,
+	songRepo repository.SongRepository,
+	discoverService services.DiscoverService,
+) *PlaylistHandler {
+	return &PlaylistHandler{
+		playlistRepo:    playlistRepo,
+		songRepo:        songRepo,
+		discoverService: discoverService,
+	}
+}
+
+// GetDiscoverPlaylist mengembalikan Discover Weekly minggu ini.
+// Kalau scheduler belum membuatnya (misal user baru aktif), playlist dibuat saat itu juga.
+func (h *PlaylistHandler) GetDiscoverPlaylist(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	playlist, err := h.playlistRepo.GetLatestPlaylistByKind(userID, models.PlaylistKindDiscoverWeekly)
+	currentWeek := services.DiscoverSnapshotDate(time.Now())
+
+	if errors.Is(err, repository.ErrPlaylistNotFound) ||
+		(err == nil && playlist.SnapshotDate != nil && playlist.SnapshotDate.Before(currentWeek)) {
+		fresh, genErr := h.discoverService.GenerateForUser(userID, currentWeek)
+		if genErr == nil {
+			playlist, err = fresh, nil
+		} else if playlist == nil {
+			log.Printf("[GetDiscoverPlaylist] generate failed for user %d: %v", userID, genErr)
+			c.JSON(http.StatusNotFound, gin.H{
+				"status":  "error",
+				"message": "Discover playlist not available yet",
+			})
+			return
+		}
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to fetch discover playlist",
+		})
+		return
+	}
+
+	h.setLikeStatus(playlist, userID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Discover playlist fetched",
+		"data":    playlist,
+	})
+}
+
+func (h *PlaylistHandler) GetDiscoverHistory(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "12"))
+	if err != nil || limit <= 0 {
+		limit = 12
+	}
+	if limit > 52 {
+		limit = 52
+	}
+
+	playlists, err := h.playlistRepo.ListPlaylistsByKind(userID, models.PlaylistKindDiscoverWeekly, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to fetch discover history",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Discover history fetched",
+		"data": gin.H{
+			"playlists": playlists,
+			"total":     len(playlists),
+		},
+	})
+}
+
+func (h *PlaylistHandler) GetPlaylistByID(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	playlistID := c.Param("id")
+
+	if _, err := uuid.Parse(playlistID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid playlist ID format",
+		})
+		return
+	}
+
+	playlist, err := h.playlistRepo.GetPlaylistByID(playlistID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPlaylistNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"status":  "error",
+				"message": "Playlist not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to fetch playlist",
+		})
+		return
+	}
+
+	// Playlist bersifat pribadi
+	if playlist.UserID != userID {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "Playlist not found",
+		})
+		return
+	}
+
+	h.setLikeStatus(playlist, userID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Playlist fetched",
+		"data":    playlist,
+	})
+}
+
+func (h *PlaylistHandler) setLikeStatus(playlist *models.Playlist, userID uint) {
+	songIDs := make([]string, len(playlist.Items))
+	for i, item := range playlist.Items {
+		songIDs[i] = item.SongID
+	}
+
+	likedMap, _ := h.songRepo.GetLikedSongIDs(userID, songIDs)
+	for i := range playlist.Items {
+		playlist.Items[i].Song.IsLiked = likedMap[playlist.Items[i].SongID]
+	}
+}
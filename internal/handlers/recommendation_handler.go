@@ -131,6 +131,7 @@ func (h *RecommendationHandler) GetContentBasedRecommendations(c *gin.Context) {
     for i := range recommendations {
         recommendations[i].Rank = i + 1
         recommendations[i].Score = math.Round(recommendations[i].Score*100) / 100
+        h.ensureReasons(&recommendations[i])
     }
     
     c.JSON(http.StatusOK, gin.H{
@@ -191,6 +192,7 @@ func (h *RecommendationHandler) GetCollaborativeRecommendations(c *gin.Context)
         if recommendations[i].Explanation == "" {
             recommendations[i].Explanation = h.generateCollaborativeExplanation(&recommendations[i])
         }
+        h.ensureReasons(&recommendations[i])
     }
     
     c.JSON(http.StatusOK, gin.H{
@@ -272,6 +274,7 @@ func (h *RecommendationHandler) GetHybridRecommendations(c *gin.Context) {
         if recommendations[i].Explanation == "" {
             recommendations[i].Explanation = h.generateHybridExplanation(&recommendations[i])
         }
+        h.ensureReasons(&recommendations[i])
     }
     
     c.JSON(http.StatusOK, gin.H{
@@ -329,6 +332,7 @@ func (h *RecommendationHandler) GetSmartHybridRecommendations(c *gin.Context) {
         if recommendations[i].Explanation == "" {
             recommendations[i].Explanation = h.generateSmartHybridExplanation(&recommendations[i])
         }
+        h.ensureReasons(&recommendations[i])
     }
     
     c.JSON(http.StatusOK, gin.H{
@@ -452,4 +456,27 @@ func (h *RecommendationHandler) generateSmartHybridExplanation(rec *models.Recom
     }
     
     return strings.Join(explanations, " • ")
-}
\ No newline at end of file
+}
+
+// ensureReasons mengisi reason terstruktur untuk rekomendasi yang belum punya,
+// selaras dengan teks dari generate*Explanation di atas.
+func (h *RecommendationHandler) ensureReasons(rec *models.RecommendationScore) {
+    if len(rec.Reasons) > 0 {
+        return
+    }
+    
+    reasons := []models.RecommendationReason{}
+    if rec.ScoreType == "collaborative" {
+        reasons = append(reasons, models.RecommendationReason{
+            Type:         models.ReasonLikedBySimilarUsers,
+            Contribution: rec.Score,
+        })
+    }
+    if rec.Song.Popularity > 60 {
+        reasons = append(reasons, models.RecommendationReason{
+            Type:         models.ReasonPopular,
+            Contribution: math.Round(float64(rec.Song.Popularity)/100*0.15*1000) / 1000,
+        })
+    }
+    rec.Reasons = reasons
+}
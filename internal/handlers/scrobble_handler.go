@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"back_music/internal/listenbrainz"
+	"back_music/internal/repository"
+	"back_music/internal/services"
+)
+
+type ScrobbleHandler struct {
+	scrobbleService services.ScrobbleService
+	songRepo        repository.SongRepository
+}
+
+func NewScrobbleHandler(scrobbleService services.ScrobbleService, songRepo repository.SongRepository) *ScrobbleHandler {
+	return &ScrobbleHandler{
+		scrobbleService: scrobbleService,
+		songRepo:        songRepo,
+	}
+}
+
+type connectScrobbleRequest struct {
+	APIURL string `json:"api_url"`
+	Token  string `json:"token" binding:"required"`
+}
+
+// Connect menyimpan token ListenBrainz user. api_url opsional (default server ListenBrainz).
+func (h *ScrobbleHandler) Connect(c *gin.Context) {
+	var req connectScrobbleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "token is required",
+		})
+		return
+	}
+
+	account, err := h.scrobbleService.Connect(c.Request.Context(), c.GetUint("user_id"), req.APIURL, req.Token)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidScrobbleURL):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": err.Error(),
+			})
+		case errors.Is(err, listenbrainz.ErrInvalidToken):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "Token was rejected by the scrobble server",
+			})
+		case errors.Is(err, services.ErrScrobbleUnavailable):
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"status":  "error",
+				"message": err.Error(),
+			})
+		default:
+			log.Println("⚠️ Scrobble connect failed:", err)
+			c.JSON(http.StatusBadGateway, gin.H{
+				"status":  "error",
+				"message": "Failed to reach the scrobble server",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Scrobbling connected",
+		"data":    account,
+	})
+}
+
+func (h *ScrobbleHandler) GetAccount(c *gin.Context) {
+	status, err := h.scrobbleService.GetAccount(c.GetUint("user_id"))
+	if err != nil {
+		h.respondAccountError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Scrobble account fetched",
+		"data":    status,
+	})
+}
+
+// Disconnect menghapus token dan antrian listen yang belum terkirim.
+func (h *ScrobbleHandler) Disconnect(c *gin.Context) {
+	if err := h.scrobbleService.Disconnect(c.GetUint("user_id")); err != nil {
+		h.respondAccountError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Scrobbling disconnected",
+	})
+}
+
+// NowPlaying dipanggil frontend saat lagu mulai diputar. Listen selesai dicatat lewat
+// POST /api/user/play/:song_id.
+func (h *ScrobbleHandler) NowPlaying(c *gin.Context) {
+	songID := c.Param("song_id")
+	if _, err := uuid.Parse(songID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid song ID format",
+		})
+		return
+	}
+
+	song, err := h.songRepo.GetSongByID(songID)
+	if err != nil {
+		if errors.Is(err, repository.ErrSongNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"status":  "error",
+				"message": "Song not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to fetch song"})
+		return
+	}
+
+	if err := h.scrobbleService.NowPlaying(c.GetUint("user_id"), song); err != nil {
+		h.respondAccountError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"status":  "success",
+		"message": "Now playing submitted",
+	})
+}
+
+// RetryFailed mengantrikan ulang listen yang sudah melewati batas percobaan.
+func (h *ScrobbleHandler) RetryFailed(c *gin.Context) {
+	count, err := h.scrobbleService.RetryFailed(c.GetUint("user_id"))
+	if err != nil {
+		h.respondAccountError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Failed listens requeued",
+		"data": gin.H{
+			"requeued": count,
+		},
+	})
+}
+
+func (h *ScrobbleHandler) respondAccountError(c *gin.Context, err error) {
+	if errors.Is(err, repository.ErrScrobbleAccountNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "Scrobbling is not connected",
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"status":  "error",
+		"message": "Scrobble request failed",
+	})
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"back_music/internal/services"
+)
+
+type SearchHandler struct {
+	suggestService services.SearchSuggestService
+}
+
+func NewSearchHandler(suggestService services.SearchSuggestService) *SearchHandler {
+	return &SearchHandler{suggestService: suggestService}
+}
+
+// Suggest untuk typeahead: saran lagu, artist, album dan genre dari prefix query.
+func (h *SearchHandler) Suggest(c *gin.Context) {
+	query := c.Query("q")
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit <= 0 || limit > 20 {
+		limit = 10
+	}
+
+	suggestions := []services.SearchSuggestion{}
+	if query != "" {
+		suggestions = h.suggestService.Suggest(query, c.GetUint("user_id"), limit)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Suggestions fetched",
+		"data":    suggestions,
+	})
+}
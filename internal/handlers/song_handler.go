@@ -16,48 +16,56 @@ import (
 	"back_music/internal/models"
 	"back_music/internal/repository"
 	"back_music/internal/services"
+	"back_music/internal/storage"
 )
 
 type SongHandler struct {
     songRepo  repository.SongRepository
     userRepo  repository.UserRepository
+    playEventRepo repository.PlayEventRepository
     spotifyService services.SpotifyService
-    youtubeService services.YouTubeService
+    audioSourceService services.AudioSourceService
 }
 
 
 
-func NewSongHandler(songRepo repository.SongRepository, userRepo repository.UserRepository, spotifyService services.SpotifyService, youtubeService services.YouTubeService, ) *SongHandler {
+func NewSongHandler(songRepo repository.SongRepository, userRepo repository.UserRepository, playEventRepo repository.PlayEventRepository, spotifyService services.SpotifyService, audioSourceService services.AudioSourceService) *SongHandler {
     return &SongHandler{
         songRepo:       songRepo,
         userRepo:       userRepo,
+        playEventRepo:  playEventRepo,
         spotifyService: spotifyService,
         // uploadService:   uploadService,  
-        youtubeService: youtubeService,
+        audioSourceService: audioSourceService,
     }
 }
 
 // handlers/songHandler.go
+// GetAllSongs: browse katalog dengan cursor pagination, sort, filter dan facet.
+// Query: cursor, limit, sort (popularity|created_at|title|tempo), order (asc|desc),
+// genre, artist, mood, year_from, year_to, min_duration_ms, max_duration_ms,
+// min_<fitur>/max_<fitur> (mis. min_energy=0.6).
 func (h *SongHandler) GetAllSongs(c *gin.Context) {
     userID := c.GetUint("user_id")
-    
-  
 
-    var songs []models.Song
-    var err error
-    
-    // LOG REQUEST
-    log.Printf("[Handler GetAllSongs] Request from UserID: %d", userID)
-    
-    if userID > 0 {
-        songs, err = h.songRepo.GetAllSongsWithLikeStatus(userID)
-        log.Printf("[Handler] Used GetAllSongsWithLikeStatus, got %d songs", len(songs))
-    } else {
-        songs, err = h.songRepo.GetAllSongs()
-        log.Printf("[Handler] Used GetAllSongs, got %d songs", len(songs))
+    opts, err := parseSongBrowseOptions(c)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{
+            "status":  "error",
+            "message": "Invalid query: " + err.Error(),
+        })
+        return
     }
-    
+
+    page, err := h.songRepo.BrowseSongs(opts)
     if err != nil {
+        if errors.Is(err, repository.ErrInvalidCursor) {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "status":  "error",
+                "message": "Invalid query: " + err.Error(),
+            })
+            return
+        }
         log.Printf("[Handler GetAllSongs] ERROR: %v", err)
         c.JSON(http.StatusInternalServerError, gin.H{
             "status":  "error",
@@ -65,20 +73,111 @@ func (h *SongHandler) GetAllSongs(c *gin.Context) {
         })
         return
     }
-    
-    
+
+    if userID > 0 && len(page.Songs) > 0 {
+        songIDs := make([]string, len(page.Songs))
+        for i, song := range page.Songs {
+            songIDs[i] = song.ID
+        }
+        likedMap, _ := h.songRepo.GetLikedSongIDs(userID, songIDs)
+        for i := range page.Songs {
+            page.Songs[i].IsLiked = likedMap[page.Songs[i].ID]
+        }
+    }
+
+    order := "asc"
+    if opts.Desc {
+        order = "desc"
+    }
+
     c.JSON(http.StatusOK, gin.H{
         "status":  "success",
         "message": "Songs fetched successfully",
         "data": gin.H{
-            "songs": songs,
+            "songs": page.Songs,
             "metadata": gin.H{
-                "total": len(songs),
+                "total":       page.Total,
+                "count":       len(page.Songs),
+                "limit":       opts.Limit,
+                "sort":        opts.Sort,
+                "order":       order,
+                "has_more":    page.HasMore,
+                "next_cursor": page.NextCursor,
+                "facets":      page.Facets,
             },
         },
     })
 }
 
+func parseSongBrowseOptions(c *gin.Context) (repository.SongBrowseOptions, error) {
+    opts := repository.SongBrowseOptions{
+        Sort:   c.DefaultQuery("sort", "created_at"),
+        Desc:   c.DefaultQuery("order", "desc") != "asc",
+        Cursor: c.Query("cursor"),
+    }
+    if _, ok := repository.SongSortColumns[opts.Sort]; !ok {
+        return opts, fmt.Errorf("unknown sort field: %s", opts.Sort)
+    }
+
+    limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+    if err != nil || limit <= 0 {
+        limit = 20
+    }
+    opts.Limit = min(limit, 100)
+
+    filter := repository.SongBrowseFilter{
+        Genre:  c.Query("genre"),
+        Artist: c.Query("artist"),
+        Mood:   c.Query("mood"),
+    }
+    switch filter.Mood {
+    case "", repository.MoodUpbeat, repository.MoodNeutral, repository.MoodMellow:
+    default:
+        return opts, fmt.Errorf("unknown mood: %s", filter.Mood)
+    }
+
+    intParams := map[string]*int{
+        "year_from":       &filter.YearFrom,
+        "year_to":         &filter.YearTo,
+        "min_duration_ms": &filter.MinDurationMs,
+        "max_duration_ms": &filter.MaxDurationMs,
+    }
+    for name, target := range intParams {
+        if raw := c.Query(name); raw != "" {
+            value, err := strconv.Atoi(raw)
+            if err != nil || value < 0 {
+                return opts, fmt.Errorf("%s must be a non-negative integer", name)
+            }
+            *target = value
+        }
+    }
+
+    for feature := range repository.SongFeatureColumns {
+        featureRange := repository.FeatureRange{Feature: feature}
+        for _, bound := range []struct {
+            param  string
+            target **float64
+        }{
+            {"min_" + feature, &featureRange.Min},
+            {"max_" + feature, &featureRange.Max},
+        } {
+            if raw := c.Query(bound.param); raw != "" {
+                value, err := strconv.ParseFloat(raw, 64)
+                if err != nil {
+                    return opts, fmt.Errorf("%s must be a number", bound.param)
+                }
+                *bound.target = &value
+            }
+        }
+        if featureRange.Min != nil || featureRange.Max != nil {
+            filter.Features = append(filter.Features, featureRange)
+        }
+    }
+
+    opts.Filter = filter
+    return opts, nil
+}
+
 func (h *SongHandler) SearchSongs(c *gin.Context) {
     query := c.Query("q")
     limitStr := c.DefaultQuery("limit", "20")
@@ -113,11 +212,17 @@ func (h *SongHandler) SearchSongs(c *gin.Context) {
         return
     }
     
-    c.JSON(http.StatusOK, gin.H{
+    response := gin.H{
         "status":  "success",
         "message": "Search completed",
         "data":    songs,
-    })
+    }
+    // Saran ejaan untuk query yang (hampir) tidak cocok, mis. "tullus" -> "Tulus"
+    if suggestion, err := h.songRepo.SuggestSearchTerm(query); err == nil && suggestion != "" {
+        response["did_you_mean"] = suggestion
+    }
+
+    c.JSON(http.StatusOK, response)
 }
 
 func (h *SongHandler) GetSongByID(c *gin.Context) {
@@ -162,33 +267,6 @@ func (h *SongHandler) GetSongByID(c *gin.Context) {
     })
 }
 
-func (h *SongHandler) SeedSongs(c *gin.Context) {
-    limitStr := c.DefaultQuery("limit", "100")
-    
-    limit, err := strconv.Atoi(limitStr)
-    if err != nil || limit <= 0 {
-        limit = 100
-    }
-    
-    if limit > 200 {
-        limit = 200 // Safety limit
-    }
-    
-    go func() {
-        if err := h.spotifyService.SeedSongsFromSpotify(limit); err != nil {
-            // Log error but don't return to client since this is async
-        }
-    }()
-    
-    c.JSON(http.StatusAccepted, gin.H{
-        "status":  "success",
-        "message": "Seeding process started in background",
-        "data": gin.H{
-            "limit": limit,
-        },
-    })
-}
-
 func (h *SongHandler) LikeSong(c *gin.Context) {
     userID := c.GetUint("user_id")
     songID := c.Param("song_id")
@@ -323,6 +401,21 @@ func (h *SongHandler) PlaySong(c *gin.Context) {
         return
     }
     
+    // Body opsional {"duration_ms": lama didengar}; dipakai untuk menentukan apakah play
+    // cukup panjang untuk dikirim sebagai scrobble
+    var body struct {
+        DurationMs int `json:"duration_ms"`
+    }
+    if c.Request.ContentLength > 0 {
+        if err := c.ShouldBindJSON(&body); err != nil || body.DurationMs < 0 {
+            c.JSON(http.StatusBadRequest, gin.H{
+                "status":  "error",
+                "message": "Invalid request body",
+            })
+            return
+        }
+    }
+    
     _, err := h.songRepo.GetSongByID(songID)
     if err != nil {
         if errors.Is(err, repository.ErrSongNotFound) {
@@ -370,6 +463,18 @@ func (h *SongHandler) PlaySong(c *gin.Context) {
         }
     }
     
+    // Simpan juga sebagai event individual untuk chart & trending
+    event := models.PlayEvent{
+        UserID:     userID,
+        SongID:     songID,
+        PlayedAt:   play.LastPlayed,
+        Source:     models.PlaySourceApp,
+        DurationMs: body.DurationMs,
+    }
+    if err := h.playEventRepo.CreatePlayEvent(&event); err != nil {
+        log.Printf("[PlaySong] failed to record play event for song %s: %v", songID, err)
+    }
+    
     c.JSON(http.StatusOK, gin.H{
         "status":  "success",
         "message": "Play recorded successfully",
@@ -481,32 +586,45 @@ func (h *SongHandler) GetPopularSongs(c *gin.Context) {
     })
 }
 
-//  TAMBAHAN: Get Popular Indonesian Songs
+//  TAMBAHAN: Get Popular Songs per seed profile (default: profile dari config, mis. Indonesia)
 func (h *SongHandler) GetPopularIndonesianSongs(c *gin.Context) {
     limitStr := c.DefaultQuery("limit", "50")
-    limit, _ := strconv.Atoi(limitStr)
+    limit, err := strconv.Atoi(limitStr)
+    if err != nil || limit <= 0 {
+        limit = 50
+    }
     
     if limit > 100 {
         limit = 100 // Max limit
     }
     
-    songs, err := h.spotifyService.GetPopularIndonesianSongs(limit)
+    profile, err := h.spotifyService.GetSeedProfile(c.Query("profile"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{
+            "status":  "error",
+            "message": err.Error(),
+        })
+        return
+    }
+    
+    songs, err := h.spotifyService.GetPopularSongs(profile.Name, limit)
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{
             "status":  "error",
-            "message": "Failed to fetch popular Indonesian songs",
+            "message": "Failed to fetch popular songs",
         })
         return
     }
     
     c.JSON(http.StatusOK, gin.H{
         "status":  "success",
-        "message": "Popular Indonesian songs fetched successfully",
+        "message": "Popular songs fetched successfully",
         "data": gin.H{
             "total":   len(songs),
             "songs":   songs,
-            "filters": map[string]string{
-                "country": "Indonesia",
+            "filters": map[string]interface{}{
+                "profile": profile.Name,
+                "markets": profile.Markets,
                 "sort":    "popularity",
             },
         },
@@ -616,34 +734,112 @@ func (h *SongHandler) GetAudioSource(c *gin.Context) {
         return
     }
 
-    if song.YoutubeID != "" {
-        c.JSON(http.StatusOK, gin.H{
-            "status": "success",
-            "data": gin.H{
-                "video_id": song.YoutubeID,
-                "source":   "database_cache",
-            },
-        })
+    sources, err := h.audioSourceService.GetSources(c.Request.Context(), song)
+    if err != nil {
+        if errors.Is(err, services.ErrNoAudioSource) {
+            c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Sumber audio tidak ditemukan"})
+            return
+        }
+        log.Printf("[GetAudioSource] failed to resolve sources for song %s: %v", songID, err)
+        c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to resolve audio source"})
         return
     }
 
-    searchQuery := fmt.Sprintf("%s - %s", song.Artist, song.Title)
-    videoID, err := h.youtubeService.SearchAudio(searchQuery)
-    if err != nil {
-        c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Gagal mencari audio otomatis"})
-        return
+    // URL stream bertanda tangan untuk file upload hanya diberikan ke user yang login,
+    // jadi /stream tidak bisa diputar anonim lewat endpoint publik ini
+    if _, ok := c.Get("user_id"); !ok {
+        public := sources[:0:0]
+        for _, source := range sources {
+            if source.Provider != models.SourceProviderLocal {
+                public = append(public, source)
+            }
+        }
+        if len(public) == 0 {
+            c.JSON(http.StatusUnauthorized, gin.H{"status": "error", "message": "Login untuk memutar lagu ini"})
+            return
+        }
+        sources = public
     }
 
-    song.YoutubeID = videoID
-    if err := h.songRepo.UpdateSong(song); err != nil {
-        log.Printf("[GetAudioSource] failed to cache YoutubeID for song %s: %v", songID, err)
+    data := gin.H{
+        "sources": sources,
+        "best":    sources[0],
+    }
+    // Kompatibilitas client lama yang hanya membaca video_id
+    for _, source := range sources {
+        if source.Provider == models.SourceProviderYouTube {
+            data["video_id"] = source.Ref
+            break
+        }
     }
 
     c.JSON(http.StatusOK, gin.H{
         "status": "success",
-        "data": gin.H{
-            "video_id": videoID,
-            "source":   "youtube_api_fresh",
-        },
+        "data":   data,
     })
-}
\ No newline at end of file
+}
+
+// StreamAudio menyajikan file audio tersimpan dengan dukungan Range/If-Range, ETag dan
+// header cache. Akses lewat URL bertanda tangan (source, expires, sig) dari GetAudioSource
+// supaya bisa dipakai langsung di <audio>, atau lewat header Authorization.
+func (h *SongHandler) StreamAudio(c *gin.Context) {
+    songID := c.Param("id")
+    if _, err := uuid.Parse(songID); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid song ID format"})
+        return
+    }
+    var sourceID uint64
+    if raw := c.Query("source"); raw != "" {
+        parsed, err := strconv.ParseUint(raw, 10, 64)
+        if err != nil || parsed == 0 {
+            c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid source ID"})
+            return
+        }
+        sourceID = parsed
+    }
+
+    cacheControl := "private, no-cache"
+    if sig := c.Query("sig"); sig != "" {
+        expiresAt, err := h.audioSourceService.VerifyStreamURL(songID, uint(sourceID), c.Query("expires"), sig)
+        if err != nil {
+            message := "Invalid stream signature"
+            if errors.Is(err, services.ErrStreamURLExpired) {
+                message = "Stream URL expired"
+            }
+            c.JSON(http.StatusForbidden, gin.H{"status": "error", "message": message})
+            return
+        }
+        // Isi file tidak berubah, jadi browser boleh menyimpan selama URL masih berlaku
+        cacheControl = fmt.Sprintf("private, max-age=%d", int(time.Until(expiresAt).Seconds()))
+    } else if _, ok := c.Get("user_id"); !ok {
+        c.JSON(http.StatusUnauthorized, gin.H{"status": "error", "message": "Signed stream URL or authorization header required"})
+        return
+    } else {
+        c.Header("Vary", "Authorization")
+    }
+
+    stream, err := h.audioSourceService.OpenStream(c.Request.Context(), songID, uint(sourceID))
+    if err != nil {
+        if errors.Is(err, repository.ErrSongSourceNotFound) || errors.Is(err, services.ErrSourceNotLocal) ||
+            errors.Is(err, services.ErrNoAudioSource) || errors.Is(err, storage.ErrNotFound) {
+            c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Sumber audio tidak ditemukan"})
+            return
+        }
+        log.Printf("[StreamAudio] failed to open audio for song %s: %v", songID, err)
+        c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to open audio stream"})
+        return
+    }
+    defer stream.Body.Close()
+
+    if stream.Info.ContentType != "" {
+        c.Header("Content-Type", stream.Info.ContentType)
+    }
+    if stream.Info.ETag != "" {
+        c.Header("ETag", `"`+stream.Info.ETag+`"`)
+    }
+    c.Header("Cache-Control", cacheControl)
+    // Get ke storage hanya meminta byte yang diminta client (penting untuk S3)
+    stream.Body.LimitToRange(c.GetHeader("Range"))
+    // ServeContent menangani Range, If-Range, If-None-Match dan HEAD
+    http.ServeContent(c.Writer, c.Request, "", stream.Info.LastModified, stream.Body)
+}
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"back_music/internal/config"
+	"back_music/internal/repository"
+	"back_music/internal/services"
+)
+
+type SpotifyHandler struct {
+	linkService services.SpotifyLinkService
+}
+
+func NewSpotifyHandler(linkService services.SpotifyLinkService) *SpotifyHandler {
+	return &SpotifyHandler{linkService: linkService}
+}
+
+// StartLink mengembalikan URL consent Spotify; frontend mengarahkan user ke URL ini.
+func (h *SpotifyHandler) StartLink(c *gin.Context) {
+	authURL, err := h.linkService.StartLink(c.GetUint("user_id"))
+	if err != nil {
+		if errors.Is(err, services.ErrSpotifyLinkUnavailable) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"status":  "error",
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to start Spotify link",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Open authorize_url to link your Spotify account",
+		"data": gin.H{
+			"authorize_url": authURL,
+		},
+	})
+}
+
+// Callback adalah redirect URI Spotify. Tidak butuh JWT: user diidentifikasi dari state.
+func (h *SpotifyHandler) Callback(c *gin.Context) {
+	if reason := c.Query("error"); reason != "" {
+		h.respondCallback(c, http.StatusBadRequest, "error", "Spotify authorization was denied: "+reason, nil)
+		return
+	}
+
+	state, code := c.Query("state"), c.Query("code")
+	if state == "" || code == "" {
+		h.respondCallback(c, http.StatusBadRequest, "error", "Missing state or code", nil)
+		return
+	}
+
+	account, job, err := h.linkService.CompleteLink(c.Request.Context(), state, code)
+	if err != nil {
+		if errors.Is(err, repository.ErrSpotifyAuthStateNotFound) {
+			h.respondCallback(c, http.StatusBadRequest, "error", "Link request expired or invalid, please try again", nil)
+			return
+		}
+		log.Println("⚠️ Spotify link failed:", err)
+		h.respondCallback(c, http.StatusBadGateway, "error", "Failed to link Spotify account", nil)
+		return
+	}
+
+	h.respondCallback(c, http.StatusOK, "linked", "Spotify account linked", gin.H{
+		"account":    account,
+		"import_job": job,
+	})
+}
+
+// respondCallback: redirect ke frontend jika SPOTIFY_LINK_REDIRECT_URL di-set, selain itu JSON.
+func (h *SpotifyHandler) respondCallback(c *gin.Context, status int, result, message string, data gin.H) {
+	if redirect := config.GlobalConfig.SpotifyLinkRedirectURL; redirect != "" {
+		if target, err := url.Parse(redirect); err == nil {
+			query := target.Query()
+			query.Set("spotify", result)
+			target.RawQuery = query.Encode()
+			c.Redirect(http.StatusFound, target.String())
+			return
+		}
+	}
+
+	body := gin.H{
+		"status":  "success",
+		"message": message,
+	}
+	if status >= http.StatusBadRequest {
+		body["status"] = "error"
+	}
+	if data != nil {
+		body["data"] = data
+	}
+	c.JSON(status, body)
+}
+
+func (h *SpotifyHandler) GetAccount(c *gin.Context) {
+	account, err := h.linkService.GetAccount(c.GetUint("user_id"))
+	if err != nil {
+		h.respondAccountError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Spotify account fetched",
+		"data":    account,
+	})
+}
+
+// Unlink menghapus akun tertaut beserta refresh token-nya. Like/playlist hasil import tetap ada.
+func (h *SpotifyHandler) Unlink(c *gin.Context) {
+	if err := h.linkService.Unlink(c.GetUint("user_id")); err != nil {
+		h.respondAccountError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Spotify account unlinked",
+	})
+}
+
+// StartImport menjalankan ulang import library (saved tracks, top tracks, playlist).
+func (h *SpotifyHandler) StartImport(c *gin.Context) {
+	job, err := h.linkService.StartLibraryImport(c.GetUint("user_id"))
+	if err != nil {
+		h.respondAccountError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"status":  "success",
+		"message": "Spotify library import started",
+		"data":    job,
+	})
+}
+
+func (h *SpotifyHandler) GetImport(c *gin.Context) {
+	jobID := c.Param("id")
+	if _, err := uuid.Parse(jobID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid job ID format",
+		})
+		return
+	}
+
+	job, err := h.linkService.GetImportJob(c.GetUint("user_id"), jobID)
+	if err != nil {
+		if errors.Is(err, repository.ErrJobNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"status":  "error",
+				"message": "Import job not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to fetch import job",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Import job fetched",
+		"data":    job,
+	})
+}
+
+func (h *SpotifyHandler) respondAccountError(c *gin.Context, err error) {
+	if errors.Is(err, repository.ErrSpotifyAccountNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "No Spotify account linked",
+		})
+		return
+	}
+	if errors.Is(err, services.ErrSpotifyLinkUnavailable) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, services.ErrJobAlreadyActive) {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"message": "A Spotify library import is already in progress",
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"status":  "error",
+		"message": "Spotify account request failed",
+	})
+}
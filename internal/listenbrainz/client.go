@@ -0,0 +1,193 @@
+// Package listenbrainz adalah client minimal untuk API ListenBrainz (submit-listens,
+// validate-token). Server lain yang kompatibel bisa dipakai dengan base URL sendiri.
+package listenbrainz
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ListenTypeSingle     = "single"
+	ListenTypePlayingNow = "playing_now"
+	ListenTypeImport     = "import"
+
+	// MaxListensPerSubmit: batas jumlah listen per request submit-listens.
+	MaxListensPerSubmit = 1000
+
+	defaultTimeout = 10 * time.Second
+)
+
+var ErrInvalidToken = errors.New("listenbrainz token is invalid")
+
+// APIError adalah response non-2xx dari server.
+type APIError struct {
+	StatusCode int
+	Message    string
+	RetryAfter time.Duration // dari X-RateLimit-Reset-In untuk 429
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("listenbrainz api error %d: %s", e.StatusCode, e.Message)
+}
+
+// Retryable: rate limit, error server, dan error jaringan layak dicoba ulang; 4xx lain tidak.
+func Retryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+	}
+	return !errors.Is(err, ErrInvalidToken)
+}
+
+// TrackMetadata mengikuti format JSON ListenBrainz.
+type TrackMetadata struct {
+	ArtistName     string                 `json:"artist_name"`
+	TrackName      string                 `json:"track_name"`
+	ReleaseName    string                 `json:"release_name,omitempty"`
+	AdditionalInfo map[string]interface{} `json:"additional_info,omitempty"`
+}
+
+// Listen adalah satu pemutaran. ListenedAt (unix detik) kosong untuk playing_now.
+type Listen struct {
+	ListenedAt    int64         `json:"listened_at,omitempty"`
+	TrackMetadata TrackMetadata `json:"track_metadata"`
+}
+
+type submitRequest struct {
+	ListenType string   `json:"listen_type"`
+	Payload    []Listen `json:"payload"`
+}
+
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient membuat client untuk satu user (token user ListenBrainz).
+func NewClient(baseURL, token string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: defaultTimeout}
+	}
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		token:      token,
+		httpClient: httpClient,
+	}
+}
+
+// ValidateToken mengembalikan username pemilik token.
+func (c *Client) ValidateToken(ctx context.Context) (string, error) {
+	var result struct {
+		Valid    bool   `json:"valid"`
+		UserName string `json:"user_name"`
+		Message  string `json:"message"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/1/validate-token", nil, &result); err != nil {
+		return "", err
+	}
+	if !result.Valid {
+		return "", ErrInvalidToken
+	}
+	return result.UserName, nil
+}
+
+// SubmitListens mengirim listen. playing_now hanya boleh berisi satu listen;
+// lebih dari satu listen bertipe single otomatis dikirim sebagai import.
+func (c *Client) SubmitListens(ctx context.Context, listenType string, listens []Listen) error {
+	if len(listens) == 0 {
+		return nil
+	}
+	if len(listens) > MaxListensPerSubmit {
+		return fmt.Errorf("too many listens in one submission: %d", len(listens))
+	}
+	if listenType == ListenTypeSingle && len(listens) > 1 {
+		// "single" hanya boleh satu listen; batch dikirim sebagai "import"
+		listenType = ListenTypeImport
+	}
+	if listenType == ListenTypePlayingNow {
+		playing := make([]Listen, len(listens))
+		for i, listen := range listens {
+			listen.ListenedAt = 0
+			playing[i] = listen
+		}
+		listens = playing
+	}
+	return c.do(ctx, http.MethodPost, "/1/submit-listens", submitRequest{ListenType: listenType, Payload: listens}, nil)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Token "+c.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return ErrInvalidToken
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		apiErr := &APIError{StatusCode: resp.StatusCode, Message: errorMessage(data)}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			if seconds, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Reset-In")); err == nil && seconds > 0 {
+				apiErr.RetryAfter = time.Duration(seconds) * time.Second
+			}
+		}
+		return apiErr
+	}
+
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("invalid listenbrainz response: %w", err)
+	}
+	return nil
+}
+
+// errorMessage membaca {"code": 400, "error": "..."}; selain itu potongan body.
+func errorMessage(body []byte) string {
+	var payload struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &payload); err == nil && payload.Error != "" {
+		return payload.Error
+	}
+	message := strings.TrimSpace(string(body))
+	if len(message) > 200 {
+		message = message[:200]
+	}
+	return message
+}
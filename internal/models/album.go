@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// Album dari Spotify. Lagu di-link lewat Song.AlbumID dengan DiscNumber/TrackNumber.
+type Album struct {
+	ID                   string       `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	SpotifyID            string       `gorm:"type:varchar(100);uniqueIndex;not null" json:"spotify_id"`
+	Title                string       `gorm:"type:varchar(255);not null" json:"title"`
+	AlbumType            string       `gorm:"type:varchar(20)" json:"album_type"` // album, single, compilation
+	ReleaseDate          string       `gorm:"type:varchar(10)" json:"release_date"`
+	ReleaseDatePrecision string       `gorm:"type:varchar(10)" json:"release_date_precision"` // year, month, day
+	TotalTracks          int          `json:"total_tracks"`
+	Label                string       `gorm:"type:varchar(255)" json:"label"`
+	Images               []AlbumImage `gorm:"type:text;serializer:json" json:"images"`
+	CreatedAt            time.Time    `json:"created_at"`
+	UpdatedAt            time.Time    `json:"updated_at"`
+
+	Tracks []Song `gorm:"foreignKey:AlbumID;constraint:OnDelete:SET NULL" json:"tracks,omitempty"`
+}
+
+// AlbumImage adalah satu ukuran cover art (Spotify biasanya 640, 300 dan 64 px).
+type AlbumImage struct {
+	URL    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
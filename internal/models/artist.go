@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+)
+
+// Artist adalah entitas artist. SpotifyID kosong untuk artist hasil backfill
+// dari string Song.Artist yang belum pernah dicocokkan ke Spotify; di antara artist
+// tersebut NormalizedName unik (partial unique index dibuat di database.AutoMigrate).
+type Artist struct {
+	ID             string    `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	SpotifyID      *string   `gorm:"type:varchar(100);uniqueIndex" json:"spotify_id,omitempty"`
+	Name           string    `gorm:"type:varchar(255);not null" json:"name"`
+	NormalizedName string    `gorm:"type:varchar(255);not null;index" json:"-"`
+	ImageURL       string    `json:"image_url"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// SongArtist adalah join table lagu <-> artist; Position 0 = artist utama.
+type SongArtist struct {
+	SongID   string `gorm:"type:uuid;primaryKey" json:"song_id"`
+	ArtistID string `gorm:"type:uuid;primaryKey;index" json:"artist_id"`
+	Position int    `gorm:"default:0" json:"position"`
+}
+
+// ArtistCredit adalah artist yang dikreditkan pada track dari Spotify,
+// dipakai saat ingest sebelum lagu punya ID.
+type ArtistCredit struct {
+	SpotifyID string `json:"spotify_id"`
+	Name      string `json:"name"`
+}
+
+// ArtistSimilarity adalah cache hasil perhitungan similar artists.
+type ArtistSimilarity struct {
+	ArtistID          string    `gorm:"type:uuid;primaryKey" json:"-"`
+	SimilarArtistID   string    `gorm:"type:uuid;primaryKey" json:"similar_artist_id"`
+	Score             float64   `json:"score"`
+	SharedListeners   int       `json:"shared_listeners"`
+	ListenerScore     float64   `json:"listener_score"`
+	FeatureSimilarity float64   `json:"feature_similarity"`
+	ComputedAt        time.Time `json:"computed_at"`
+
+	SimilarArtist Artist `gorm:"foreignKey:SimilarArtistID" json:"artist"`
+}
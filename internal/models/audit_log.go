@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// AuditLog mencatat setiap perubahan katalog yang dilakukan admin.
+type AuditLog struct {
+	ID         uint                   `gorm:"primaryKey" json:"id"`
+	ActorID    uint                   `gorm:"not null;index" json:"actor_id"`
+	Action     string                 `gorm:"type:varchar(50);not null;index" json:"action"`
+	EntityType string                 `gorm:"type:varchar(30);not null;index:idx_audit_logs_entity" json:"entity_type"`
+	EntityID   string                 `gorm:"type:varchar(100);index:idx_audit_logs_entity" json:"entity_id"`
+	Details    map[string]interface{} `gorm:"type:text;serializer:json" json:"details,omitempty"`
+	CreatedAt  time.Time              `gorm:"index" json:"created_at"`
+}
+
+const (
+	AuditSongCreate     = "song.create"
+	AuditSongUpdate     = "song.update"
+	AuditSongDelete     = "song.delete"
+	AuditSongMerge      = "song.merge"
+	AuditSongBulkUpdate = "song.bulk_update"
+	AuditSongImport     = "song.import"
+)
+
+const (
+	AuditEntitySong = "song"
+)
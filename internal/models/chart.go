@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+)
+
+// PlayEvent adalah satu kali pemutaran lagu. Berbeda dengan UserPlay yang hanya
+// menyimpan agregat per user/lagu, event ini dipakai untuk chart berbasis waktu.
+type PlayEvent struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	UserID     uint      `gorm:"not null;index" json:"user_id"`
+	SongID     string    `gorm:"type:uuid;not null;index" json:"song_id"`
+	PlayedAt   time.Time `gorm:"not null;index" json:"played_at"`
+	Source     string    `gorm:"type:varchar(30);default:'app'" json:"source"`
+	DurationMs int       `gorm:"default:0" json:"duration_ms"` // lama didengar, 0 = tidak diketahui
+}
+
+const (
+	PlaySourceApp            = "app"
+	PlaySourceSpotifyHistory = "spotify_history"
+	PlaySourceLastFM         = "lastfm"
+)
+
+// ImportedPlaySources adalah sumber play event dari riwayat eksternal yang diupload user.
+// Event ini ikut dipakai untuk rekomendasi, tapi tidak untuk chart.
+var ImportedPlaySources = []string{PlaySourceSpotifyHistory, PlaySourceLastFM}
+
+const (
+	ChartTopSongs   = "top_songs"
+	ChartTopArtists = "top_artists"
+	ChartGenre      = "genre"
+	ChartRising     = "rising"
+
+	ChartPeriodDaily  = "daily"
+	ChartPeriodWeekly = "weekly"
+
+	ChartMovementNew  = "new"
+	ChartMovementUp   = "up"
+	ChartMovementDown = "down"
+	ChartMovementSame = "same"
+)
+
+// ChartEntry adalah satu baris pada snapshot chart bertanggal.
+// Untuk chart artist SongID kosong, ArtistID dan Artist terisi (snapshot lama hanya Artist).
+type ChartEntry struct {
+	ID           uint      `gorm:"primaryKey" json:"-"`
+	ChartType    string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_chart_entries_snapshot_rank" json:"chart_type"`
+	Period       string    `gorm:"type:varchar(10);not null;uniqueIndex:idx_chart_entries_snapshot_rank" json:"period"`
+	Genre        string    `gorm:"type:varchar(100);not null;default:'';uniqueIndex:idx_chart_entries_snapshot_rank" json:"genre,omitempty"`
+	SnapshotDate time.Time `gorm:"type:date;not null;uniqueIndex:idx_chart_entries_snapshot_rank" json:"snapshot_date"`
+	Rank         int       `gorm:"not null;uniqueIndex:idx_chart_entries_snapshot_rank" json:"rank"`
+	SongID       *string   `gorm:"type:uuid;index" json:"song_id,omitempty"`
+	ArtistID     *string   `gorm:"type:uuid;index" json:"artist_id,omitempty"`
+	Artist       string    `gorm:"type:varchar(255)" json:"artist"`
+	Plays        int       `json:"plays"`
+	Listeners    int       `json:"listeners"`
+	Velocity     float64   `json:"velocity,omitempty"` // khusus chart rising
+	PreviousRank *int      `json:"previous_rank"`
+	Movement     string    `gorm:"type:varchar(10)" json:"movement"`
+	CreatedAt    time.Time `json:"created_at"`
+
+	Song *Song `gorm:"foreignKey:SongID" json:"song,omitempty"`
+}
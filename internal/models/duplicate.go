@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+)
+
+// DuplicateCluster adalah sekelompok lagu yang diduga rekaman yang sama,
+// menunggu review admin (merge atau dismiss).
+type DuplicateCluster struct {
+	ID         string     `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	MatchKey   string     `gorm:"type:varchar(500);uniqueIndex;not null" json:"match_key"`
+	Reason     string     `gorm:"type:varchar(20);not null" json:"reason"` // isrc, metadata
+	Status     string     `gorm:"type:varchar(20);not null;index" json:"status"`
+	SongIDs    []string   `gorm:"type:text;serializer:json" json:"song_ids"`
+	SurvivorID *string    `gorm:"type:uuid" json:"survivor_id,omitempty"`
+	ResolvedBy *uint      `json:"resolved_by,omitempty"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	Songs []Song `gorm:"-" json:"songs,omitempty"`
+}
+
+const (
+	DuplicateReasonISRC     = "isrc"
+	DuplicateReasonMetadata = "metadata"
+)
+
+const (
+	DuplicateStatusPending   = "pending"
+	DuplicateStatusMerged    = "merged"
+	DuplicateStatusDismissed = "dismissed"
+	// DuplicateStatusResolved: tersisa kurang dari 2 lagu karena lagunya dihapus atau
+	// di-merge di luar antrian review; ditandai otomatis oleh scan.
+	DuplicateStatusResolved = "resolved"
+)
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// FeatureStat menyimpan statistik distribusi satu fitur audio di seluruh katalog.
+// Dipakai content-based service untuk normalisasi z-score / quantile.
+type FeatureStat struct {
+	Feature    string    `gorm:"type:varchar(50);primaryKey" json:"feature"`
+	Mean       float64   `json:"mean"`
+	StdDev     float64   `json:"std_dev"`
+	Min        float64   `json:"min"`
+	Max        float64   `json:"max"`
+	Quantiles  []float64 `gorm:"type:text;serializer:json" json:"quantiles"` // p0, p5, ..., p100
+	SampleSize int       `json:"sample_size"`
+	ComputedAt time.Time `json:"computed_at"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+)
+
+// Job adalah pekerjaan background yang dilacak (import katalog, seeding Spotify).
+type Job struct {
+	ID         string                 `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	Type       string                 `gorm:"type:varchar(50);not null;index" json:"type"`
+	Status     string                 `gorm:"type:varchar(20);not null;index" json:"status"`
+	Total      int                    `json:"total"`
+	Processed  int                    `json:"processed"`
+	Params     map[string]interface{} `gorm:"type:text;serializer:json" json:"params,omitempty"`
+	Result     interface{}            `gorm:"type:text;serializer:json" json:"result,omitempty"`
+	Error      string                 `gorm:"type:text" json:"error,omitempty"`
+	ErrorLog   []string               `gorm:"type:text;serializer:json" json:"error_log,omitempty"`
+	CreatedBy  uint                   `gorm:"index" json:"created_by"`
+	StartedAt  *time.Time             `json:"started_at"`
+	FinishedAt *time.Time             `json:"finished_at"`
+	CreatedAt  time.Time              `json:"created_at"`
+	UpdatedAt  time.Time              `json:"updated_at"`
+}
+
+const (
+	JobTypeCatalogImport  = "catalog_import"
+	JobTypeSpotifySeed    = "spotify_seed"
+	JobTypeSpotifyLibrary = "spotify_library_import"
+	JobTypeHistoryImport  = "listening_history_import"
+)
+
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+	JobStatusCanceled  = "canceled"
+)
+
+// Finished: job sudah berhenti dan tidak akan berubah lagi.
+func (j *Job) Finished() bool {
+	switch j.Status {
+	case JobStatusSucceeded, JobStatusFailed, JobStatusCanceled:
+		return true
+	}
+	return false
+}
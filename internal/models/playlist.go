@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	PlaylistKindCustom         = "custom"
+	PlaylistKindDiscoverWeekly = "discover_weekly"
+	PlaylistKindSpotifyImport  = "spotify_import"
+)
+
+// Playlist milik user. Playlist yang dibuat sistem (misal Discover Weekly)
+// ditandai SystemOwned dan punya SnapshotDate per minggu, jadi minggu-minggu
+// sebelumnya tetap bisa diakses.
+type Playlist struct {
+	ID           string         `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	UserID       uint           `gorm:"not null;uniqueIndex:idx_playlists_user_kind_snapshot" json:"user_id"`
+	Name         string         `gorm:"type:varchar(255);not null" json:"name"`
+	Description  string         `gorm:"type:text" json:"description"`
+	Kind         string         `gorm:"type:varchar(30);not null;default:'custom';uniqueIndex:idx_playlists_user_kind_snapshot" json:"kind"`
+	SystemOwned  bool           `gorm:"default:false" json:"system_owned"`
+	SnapshotDate *time.Time     `gorm:"type:date;uniqueIndex:idx_playlists_user_kind_snapshot" json:"snapshot_date,omitempty"`
+	ExternalID   string         `gorm:"type:varchar(100);index" json:"external_id,omitempty"` // mis. ID playlist Spotify untuk playlist hasil import
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	Items        []PlaylistItem `gorm:"foreignKey:PlaylistID;constraint:OnDelete:CASCADE" json:"items,omitempty"`
+
+	// Diisi saat listing tanpa items
+	TrackCount int `gorm:"-" json:"track_count"`
+}
+
+type PlaylistItem struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	PlaylistID string    `gorm:"type:uuid;not null;index" json:"playlist_id"`
+	SongID     string    `gorm:"type:uuid;not null;index" json:"song_id"`
+	Position   int       `gorm:"not null" json:"position"`
+	Score      float64   `json:"score,omitempty"`
+	Source     string    `gorm:"type:varchar(30)" json:"source,omitempty"` // collaborative, content, novelty
+	AddedAt    time.Time `json:"added_at"`
+
+	Song Song `gorm:"foreignKey:SongID" json:"song"`
+}
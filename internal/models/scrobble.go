@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+)
+
+// ScrobbleAccount adalah koneksi user ke server ListenBrainz-compatible (satu per user).
+// Token user disimpan terenkripsi, tidak pernah dikirim ke client.
+type ScrobbleAccount struct {
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	UserID          uint       `gorm:"not null;uniqueIndex" json:"user_id"`
+	APIURL          string     `gorm:"type:varchar(255);not null" json:"api_url"`
+	Username        string     `gorm:"type:varchar(255)" json:"username"`
+	TokenEncrypted  string     `gorm:"type:text;not null" json:"-"`
+	Enabled         bool       `gorm:"default:true" json:"enabled"`
+	LastSubmittedAt *time.Time `json:"last_submitted_at,omitempty"`
+	LastError       string     `gorm:"type:text" json:"last_error,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// ScrobbleQueueItem adalah listen yang menunggu dikirim. Metadata lagu disalin saat
+// play supaya listen tetap bisa dikirim walau lagu kemudian di-merge/dihapus.
+type ScrobbleQueueItem struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	UserID        uint      `gorm:"not null;index" json:"user_id"`
+	SongID        string    `gorm:"type:uuid" json:"song_id"`
+	ListenedAt    time.Time `gorm:"not null" json:"listened_at"`
+	ArtistName    string    `gorm:"type:varchar(255);not null" json:"artist_name"`
+	TrackName     string    `gorm:"type:varchar(255);not null" json:"track_name"`
+	ReleaseName   string    `gorm:"type:varchar(255)" json:"release_name,omitempty"`
+	DurationMs    int       `json:"duration_ms,omitempty"`
+	SpotifyID     string    `gorm:"type:varchar(100)" json:"spotify_id,omitempty"`
+	ISRC          string    `gorm:"type:varchar(20)" json:"isrc,omitempty"`
+	Status        string    `gorm:"type:varchar(20);not null;default:'pending';index:idx_scrobble_queue_due,priority:1" json:"status"`
+	Attempts      int       `gorm:"default:0" json:"attempts"`
+	NextAttemptAt time.Time `gorm:"not null;index:idx_scrobble_queue_due,priority:2" json:"next_attempt_at"`
+	LastError     string    `gorm:"type:text" json:"last_error,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+const (
+	ScrobbleStatusPending = "pending"
+	// ScrobbleStatusFailed: batas percobaan habis; user bisa mengantrikan ulang.
+	ScrobbleStatusFailed = "failed"
+)
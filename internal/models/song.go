@@ -8,9 +8,13 @@ import (
 type Song struct {
     ID           string    `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
     SpotifyID    string    `gorm:"type:varchar(100);uniqueIndex;not null" json:"spotify_id"`
+    ISRC         string    `gorm:"column:isrc;type:varchar(20);index" json:"isrc,omitempty"`
     Title        string    `gorm:"type:varchar(255);not null" json:"title"`
     Artist       string    `gorm:"type:varchar(255);not null" json:"artist"`
     Album        string    `gorm:"type:varchar(255)" json:"album"`
+    AlbumID      *string   `gorm:"type:uuid;index" json:"album_id,omitempty"`
+    DiscNumber   int       `gorm:"default:1" json:"disc_number"`
+    TrackNumber  int       `gorm:"default:0" json:"track_number"`
     Genre        string    `gorm:"type:varchar(100)" json:"genre"`
     Popularity   int       `json:"popularity"`
     DurationMs   int       `json:"duration_ms"`
@@ -33,7 +37,14 @@ type Song struct {
     
     // For similarity calculations
     FeatureVector []float64 `gorm:"-" json:"-"`
+    
+    // Artist dari Spotify saat ingest, di-link ke tabel song_artists setelah lagu disimpan
+    ArtistCredits []ArtistCredit `gorm:"-" json:"-"`
+    // Album dari Spotify saat ingest, disimpan ke tabel albums sebelum lagu disimpan
+    SpotifyAlbum  *Album `gorm:"-" json:"-"`
     YoutubeID        string    `json:"youtube_id"`
+    // Skor kecocokan (0-1) YoutubeID hasil pencarian otomatis; 1 jika diset admin
+    YoutubeConfidence float64  `json:"youtube_confidence"`
 }
 
 type AudioFeatures struct {
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+)
+
+// SongSource adalah satu sumber audio yang bisa diputar untuk sebuah lagu
+// (file upload yang disimpan server, preview Spotify, video YouTube, atau URL eksternal).
+type SongSource struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	SongID      string     `gorm:"type:uuid;not null;uniqueIndex:idx_song_sources_ref,priority:1" json:"song_id"`
+	Provider    string     `gorm:"type:varchar(30);not null;uniqueIndex:idx_song_sources_ref,priority:2" json:"provider"`
+	Ref         string     `gorm:"type:varchar(1000);not null;uniqueIndex:idx_song_sources_ref,priority:3" json:"ref"` // key BlobStore, video ID, atau URL
+	Quality     string     `gorm:"type:varchar(20);not null;default:'full'" json:"quality"`
+	BitrateKbps int        `gorm:"default:0" json:"bitrate_kbps,omitempty"`
+	DurationMs  int        `gorm:"default:0" json:"duration_ms,omitempty"`
+	MimeType    string     `gorm:"type:varchar(100)" json:"mime_type,omitempty"`
+	Checksum    string     `gorm:"type:varchar(64);index" json:"checksum,omitempty"` // sha256 file upload
+	SizeBytes   int64      `gorm:"default:0" json:"size_bytes,omitempty"`
+	Status      string     `gorm:"type:varchar(20);not null;default:'unverified'" json:"status"`
+	VerifiedAt  *time.Time `json:"verified_at,omitempty"`
+	LastError   string     `gorm:"type:text" json:"last_error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	// URL yang dipakai client untuk memutar, diisi saat response dibuat
+	PlaybackURL string `gorm:"-" json:"url"`
+}
+
+const (
+	SourceProviderLocal          = "local"
+	SourceProviderSpotifyPreview = "spotify_preview"
+	SourceProviderYouTube        = "youtube"
+	SourceProviderExternal       = "external"
+)
+
+const (
+	SourceQualityFull    = "full"
+	SourceQualityPreview = "preview"
+)
+
+const (
+	SourceStatusUnverified = "unverified"
+	SourceStatusVerified   = "verified"
+	SourceStatusBroken     = "broken"
+)
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// SpotifyAccount adalah akun Spotify yang ditautkan user (satu per user).
+// Refresh token disimpan terenkripsi (AES-GCM), tidak pernah dikirim ke client.
+type SpotifyAccount struct {
+	ID                    uint       `gorm:"primaryKey" json:"id"`
+	UserID                uint       `gorm:"not null;uniqueIndex" json:"user_id"`
+	SpotifyUserID         string     `gorm:"type:varchar(100);not null;index" json:"spotify_user_id"`
+	DisplayName           string     `gorm:"type:varchar(255)" json:"display_name"`
+	Scopes                string     `gorm:"type:text" json:"scopes"`
+	RefreshTokenEncrypted string     `gorm:"type:text;not null" json:"-"`
+	LinkedAt              time.Time  `json:"linked_at"`
+	LastImportAt          *time.Time `json:"last_import_at,omitempty"`
+	CreatedAt             time.Time  `json:"created_at"`
+	UpdatedAt             time.Time  `json:"updated_at"`
+}
+
+// SpotifyAuthState menyimpan code_verifier PKCE selama user berada di halaman consent Spotify.
+type SpotifyAuthState struct {
+	State        string    `gorm:"type:varchar(100);primaryKey" json:"-"`
+	UserID       uint      `gorm:"not null;index" json:"-"`
+	CodeVerifier string    `gorm:"type:varchar(200);not null" json:"-"`
+	ExpiresAt    time.Time `gorm:"not null;index" json:"-"`
+	CreatedAt    time.Time `json:"-"`
+}
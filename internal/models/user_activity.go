@@ -33,5 +33,25 @@ type RecommendationScore struct {
     Score       float64 `json:"score"`
     ScoreType   string  `json:"score_type"` // "content", "collaborative", "hybrid"
     Explanation string  `json:"explanation,omitempty"` // ⭐⭐ TAMBAH INI
+    Reasons     []RecommendationReason `json:"reasons"`
     Rank        int     `json:"rank,omitempty"`        // ⭐⭐ TAMBAH INI
+}
+
+// Tipe reason untuk explanation terstruktur (dilokalisasi di frontend)
+const (
+    ReasonSameArtist          = "same_artist"
+    ReasonSameGenre           = "same_genre"
+    ReasonSimilarFeature      = "similar_feature"
+    ReasonLikedBySimilarUsers = "liked_by_similar_users"
+    ReasonPopular             = "popular"
+)
+
+// RecommendationReason adalah satu alasan kenapa lagu direkomendasikan.
+// Contribution = porsi skor akhir yang berasal dari alasan ini.
+type RecommendationReason struct {
+    Type         string  `json:"type"`
+    Feature      string  `json:"feature,omitempty"` // nama fitur audio untuk similar_feature
+    Value        string  `json:"value,omitempty"`   // nama artist/genre untuk same_artist/same_genre
+    Seed         string  `json:"seed,omitempty"`    // ID lagu seed
+    Contribution float64 `json:"contribution"`
 }
\ No newline at end of file
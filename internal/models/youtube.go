@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+)
+
+// YoutubeQuotaUsage mencatat pemakaian kuota YouTube Data API per hari kuota
+// (hari dihitung di zona waktu Pacific, mengikuti reset kuota Google).
+type YoutubeQuotaUsage struct {
+	Day         string    `gorm:"type:varchar(10);primaryKey" json:"day"` // YYYY-MM-DD
+	Units       int       `gorm:"not null;default:0" json:"units"`
+	SearchCalls int       `gorm:"not null;default:0" json:"search_calls"`
+	VideoCalls  int       `gorm:"not null;default:0" json:"video_calls"`
+	Exhausted   bool      `gorm:"default:false" json:"exhausted"` // API sudah menolak dengan quotaExceeded
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// YoutubeMiss adalah negative cache pencarian YouTube per lagu: lagu tidak dicari
+// ulang sebelum RetryAt.
+type YoutubeMiss struct {
+	SongID    string    `gorm:"type:uuid;primaryKey" json:"song_id"`
+	Reason    string    `gorm:"type:varchar(30);not null" json:"reason"`
+	Misses    int       `gorm:"not null;default:1" json:"misses"`
+	LastError string    `gorm:"type:text" json:"last_error,omitempty"`
+	RetryAt   time.Time `gorm:"not null;index" json:"retry_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+const (
+	YoutubeMissNotFound = "not_found" // tidak ada kandidat yang cukup cocok
+	YoutubeMissError    = "error"     // error API selain kuota
+)
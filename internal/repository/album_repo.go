@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"errors"
+
+	"back_music/internal/database"
+	"back_music/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var ErrAlbumNotFound = errors.New("album not found")
+
+type AlbumRepository interface {
+	GetAlbumBySpotifyID(spotifyID string) (*models.Album, error)
+	UpsertAlbum(album *models.Album) error
+	GetAlbumByID(id string) (*models.Album, error)
+	GetAllAlbums() ([]models.Album, error)
+}
+
+type albumRepo struct {
+	db *gorm.DB
+}
+
+func NewAlbumRepository() AlbumRepository {
+	return &albumRepo{db: database.DB}
+}
+
+func (r *albumRepo) GetAlbumBySpotifyID(spotifyID string) (*models.Album, error) {
+	var album models.Album
+	err := r.db.First(&album, "spotify_id = ?", spotifyID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAlbumNotFound
+		}
+		return nil, err
+	}
+	return &album, nil
+}
+
+// UpsertAlbum membuat album baru atau memperbarui metadata album dengan Spotify ID yang sama.
+// Setelah dipanggil, album.ID terisi ID di database.
+func (r *albumRepo) UpsertAlbum(album *models.Album) error {
+	existing, err := r.GetAlbumBySpotifyID(album.SpotifyID)
+	if errors.Is(err, ErrAlbumNotFound) {
+		return r.db.Omit("Tracks").Create(album).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	album.ID = existing.ID
+	album.CreatedAt = existing.CreatedAt
+	if album.Label == "" {
+		album.Label = existing.Label
+	}
+	if len(album.Images) == 0 {
+		album.Images = existing.Images
+	}
+	return r.db.Omit("Tracks").Save(album).Error
+}
+
+// GetAlbumByID mengembalikan album beserta tracklist urut disc lalu nomor track.
+func (r *albumRepo) GetAlbumByID(id string) (*models.Album, error) {
+	var album models.Album
+	err := r.db.
+		Preload("Tracks", func(db *gorm.DB) *gorm.DB {
+			return db.Order("disc_number ASC, track_number ASC, title ASC")
+		}).
+		First(&album, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAlbumNotFound
+		}
+		return nil, err
+	}
+	return &album, nil
+}
+
+func (r *albumRepo) GetAllAlbums() ([]models.Album, error) {
+	var albums []models.Album
+	err := r.db.Order("title").Find(&albums).Error
+	return albums, err
+}
@@ -0,0 +1,392 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"back_music/internal/database"
+	"back_music/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrArtistNotFound = errors.New("artist not found")
+
+// ArtistAlbum adalah ringkasan album dari lagu-lagu seorang artist.
+type ArtistAlbum struct {
+	AlbumID    *string `json:"album_id,omitempty"`
+	Album      string  `json:"album"`
+	ImageURL   string  `json:"image_url"`
+	TrackCount int     `json:"track_count"`
+}
+
+// ArtistListenerStats adalah jumlah pendengar unik dan total play seorang artist.
+type ArtistListenerStats struct {
+	Listeners  int `json:"listeners"`
+	TotalPlays int `json:"total_plays"`
+}
+
+// ArtistFeatureCentroid adalah rata-rata fitur audio dari lagu-lagu seorang artist.
+type ArtistFeatureCentroid struct {
+	ArtistID         string
+	Danceability     float64
+	Energy           float64
+	Key              float64
+	Loudness         float64
+	Mode             float64
+	Speechiness      float64
+	Acousticness     float64
+	Instrumentalness float64
+	Liveness         float64
+	Valence          float64
+	Tempo            float64
+	TimeSignature    float64
+	Popularity       float64
+}
+
+// ArtistOverlap adalah jumlah pendengar yang sama antara artist target dan artist lain.
+type ArtistOverlap struct {
+	ArtistID        string
+	SharedListeners int
+}
+
+type ArtistRepository interface {
+	FindOrCreateArtist(credit models.ArtistCredit) (*models.Artist, error)
+	LinkSongArtists(songID string, credits []models.ArtistCredit) error
+	ReplaceSongArtists(songID string, credits []models.ArtistCredit) error
+	GetArtistByID(id string) (*models.Artist, error)
+	GetArtistsByIDs(ids []string) ([]models.Artist, error)
+	SearchArtists(query string, limit int) ([]models.Artist, error)
+	GetArtistsForSong(songID string) ([]models.Artist, error)
+	GetArtistSongs(artistID string, limit int) ([]models.Song, error)
+	GetArtistAlbums(artistID string) ([]ArtistAlbum, error)
+	GetArtistListenerStats(artistID string) (*ArtistListenerStats, error)
+	// GetSongsWithoutArtists mengembalikan lagu tanpa relasi artist dengan ID > afterID, urut ID.
+	GetSongsWithoutArtists(afterID string, limit int) ([]models.Song, error)
+	GetAllArtists() ([]models.Artist, error)
+	GetSongArtistLinks() ([]models.SongArtist, error)
+
+	GetListenerCounts() (map[string]int, error)
+	GetListenerOverlaps(artistID string, limit int) ([]ArtistOverlap, error)
+	GetFeatureCentroids() ([]ArtistFeatureCentroid, error)
+	GetSimilarArtists(artistID string, limit int) ([]models.ArtistSimilarity, error)
+	SaveSimilarArtists(artistID string, similarities []models.ArtistSimilarity) error
+}
+
+type artistRepo struct {
+	db *gorm.DB
+}
+
+func NewArtistRepository() ArtistRepository {
+	return &artistRepo{db: database.DB}
+}
+
+// NormalizeArtistName dipakai untuk mencocokkan nama artist tanpa peduli huruf besar/spasi.
+func NormalizeArtistName(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
+// FindOrCreateArtist mencari artist berdasarkan Spotify ID, lalu nama ter-normalisasi.
+// Artist hasil backfill (tanpa Spotify ID) dilengkapi Spotify ID-nya ketika ketemu.
+// Seeding, import library dan import riwayat bisa memanggil ini bersamaan untuk artist
+// yang sama, jadi insert memakai ON CONFLICT terhadap unique index lalu membaca ulang.
+func (r *artistRepo) FindOrCreateArtist(credit models.ArtistCredit) (*models.Artist, error) {
+	name := strings.TrimSpace(credit.Name)
+	normalized := NormalizeArtistName(name)
+	if normalized == "" {
+		return nil, errors.New("artist name is empty")
+	}
+
+	var artist models.Artist
+	if credit.SpotifyID != "" {
+		err := r.db.Where("spotify_id = ?", credit.SpotifyID).First(&artist).Error
+		if err == nil {
+			return &artist, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	err := r.db.Where("normalized_name = ? AND spotify_id IS NULL", normalized).First(&artist).Error
+	if err == nil {
+		if credit.SpotifyID == "" {
+			return &artist, nil
+		}
+		result := r.db.Model(&models.Artist{}).
+			Where("id = ? AND spotify_id IS NULL", artist.ID).
+			Update("spotify_id", credit.SpotifyID)
+		if result.Error == nil && result.RowsAffected == 1 {
+			spotifyID := credit.SpotifyID
+			artist.SpotifyID = &spotifyID
+			return &artist, nil
+		}
+		// Request lain lebih dulu membuat artist dengan Spotify ID ini, atau melengkapi
+		// artist backfill ini dengan Spotify ID lain (lalu artist baru dibuat di bawah)
+		if existing, err := r.findArtist(credit.SpotifyID, normalized); err == nil {
+			return existing, nil
+		}
+		if result.Error != nil {
+			return nil, result.Error
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	// Tanpa Spotify ID, pakai artist Spotify dengan nama sama kalau sudah ada
+	if credit.SpotifyID == "" {
+		err := r.db.Where("normalized_name = ?", normalized).Order("created_at").First(&artist).Error
+		if err == nil {
+			return &artist, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	artist = models.Artist{
+		Name:           name,
+		NormalizedName: normalized,
+	}
+	conflict := clause.OnConflict{
+		Columns:     []clause.Column{{Name: "normalized_name"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "spotify_id IS NULL"}}},
+		DoNothing:   true,
+	}
+	if credit.SpotifyID != "" {
+		spotifyID := credit.SpotifyID
+		artist.SpotifyID = &spotifyID
+		conflict = clause.OnConflict{Columns: []clause.Column{{Name: "spotify_id"}}, DoNothing: true}
+	}
+	result := r.db.Clauses(conflict).Create(&artist)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		// Kalah balapan dengan insert lain: pakai artist milik pemenang
+		return r.findArtist(credit.SpotifyID, normalized)
+	}
+	return &artist, nil
+}
+
+// findArtist membaca artist dengan Spotify ID, atau artist tanpa Spotify ID dengan nama
+// ter-normalisasi yang sama jika spotifyID kosong.
+func (r *artistRepo) findArtist(spotifyID, normalized string) (*models.Artist, error) {
+	var artist models.Artist
+	query := r.db.Where("normalized_name = ? AND spotify_id IS NULL", normalized)
+	if spotifyID != "" {
+		query = r.db.Where("spotify_id = ?", spotifyID)
+	}
+	if err := query.First(&artist).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrArtistNotFound
+		}
+		return nil, err
+	}
+	return &artist, nil
+}
+
+func (r *artistRepo) LinkSongArtists(songID string, credits []models.ArtistCredit) error {
+	for position, credit := range credits {
+		artist, err := r.FindOrCreateArtist(credit)
+		if err != nil {
+			return err
+		}
+		link := models.SongArtist{SongID: songID, ArtistID: artist.ID, Position: position}
+		if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&link).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReplaceSongArtists mengganti seluruh kredit artist sebuah lagu (mis. setelah diedit admin).
+func (r *artistRepo) ReplaceSongArtists(songID string, credits []models.ArtistCredit) error {
+	if err := r.db.Where("song_id = ?", songID).Delete(&models.SongArtist{}).Error; err != nil {
+		return err
+	}
+	return r.LinkSongArtists(songID, credits)
+}
+
+func (r *artistRepo) GetArtistByID(id string) (*models.Artist, error) {
+	var artist models.Artist
+	err := r.db.First(&artist, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrArtistNotFound
+		}
+		return nil, err
+	}
+	return &artist, nil
+}
+
+func (r *artistRepo) GetArtistsByIDs(ids []string) ([]models.Artist, error) {
+	var artists []models.Artist
+	err := r.db.Where("id IN ?", ids).Find(&artists).Error
+	return artists, err
+}
+
+func (r *artistRepo) SearchArtists(query string, limit int) ([]models.Artist, error) {
+	var artists []models.Artist
+	pattern := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(NormalizeArtistName(query))
+	err := r.db.Where("normalized_name LIKE ?", "%"+pattern+"%").
+		Order("name").
+		Limit(limit).
+		Find(&artists).Error
+	return artists, err
+}
+
+func (r *artistRepo) GetArtistsForSong(songID string) ([]models.Artist, error) {
+	var artists []models.Artist
+	err := r.db.Joins("JOIN song_artists sa ON sa.artist_id = artists.id").
+		Where("sa.song_id = ?", songID).
+		Order("sa.position").
+		Find(&artists).Error
+	return artists, err
+}
+
+func (r *artistRepo) GetArtistSongs(artistID string, limit int) ([]models.Song, error) {
+	var songs []models.Song
+	err := r.db.Joins("JOIN song_artists sa ON sa.song_id = songs.id").
+		Where("sa.artist_id = ?", artistID).
+		Order("songs.popularity DESC").
+		Limit(limit).
+		Find(&songs).Error
+	return songs, err
+}
+
+func (r *artistRepo) GetArtistAlbums(artistID string) ([]ArtistAlbum, error) {
+	var albums []ArtistAlbum
+	err := r.db.Table("songs").
+		Select("MAX(songs.album_id::text) AS album_id, songs.album, MAX(songs.image_url) AS image_url, COUNT(*) AS track_count").
+		Joins("JOIN song_artists sa ON sa.song_id = songs.id").
+		Where("sa.artist_id = ? AND songs.album IS NOT NULL AND songs.album <> ''", artistID).
+		Group("songs.album").
+		Order("track_count DESC, songs.album").
+		Scan(&albums).Error
+	return albums, err
+}
+
+func (r *artistRepo) GetArtistListenerStats(artistID string) (*ArtistListenerStats, error) {
+	var stats ArtistListenerStats
+	err := r.db.Raw(`
+		SELECT COUNT(DISTINCT up.user_id) AS listeners, COALESCE(SUM(up.play_count), 0) AS total_plays
+		FROM user_plays up
+		JOIN song_artists sa ON sa.song_id::text = up.song_id
+		WHERE sa.artist_id = ?`, artistID).
+		Scan(&stats).Error
+	return &stats, err
+}
+
+func (r *artistRepo) GetSongsWithoutArtists(afterID string, limit int) ([]models.Song, error) {
+	var songs []models.Song
+	query := r.db.Where("NOT EXISTS (SELECT 1 FROM song_artists sa WHERE sa.song_id = songs.id)")
+	if afterID != "" {
+		query = query.Where("songs.id > ?", afterID)
+	}
+	err := query.
+		Order("songs.id").
+		Limit(limit).
+		Find(&songs).Error
+	return songs, err
+}
+
+func (r *artistRepo) GetAllArtists() ([]models.Artist, error) {
+	var artists []models.Artist
+	err := r.db.Order("name").Find(&artists).Error
+	return artists, err
+}
+
+func (r *artistRepo) GetSongArtistLinks() ([]models.SongArtist, error) {
+	var links []models.SongArtist
+	err := r.db.Order("song_id, position").Find(&links).Error
+	return links, err
+}
+
+// artistListenersSQL adalah pasangan (artist, user) unik dari play maupun like.
+const artistListenersSQL = `
+	SELECT sa.artist_id, up.user_id FROM user_plays up
+	JOIN song_artists sa ON sa.song_id::text = up.song_id
+	UNION
+	SELECT sa.artist_id, ul.user_id FROM user_likes ul
+	JOIN song_artists sa ON sa.song_id::text = ul.song_id`
+
+func (r *artistRepo) GetListenerCounts() (map[string]int, error) {
+	var rows []struct {
+		ArtistID  string
+		Listeners int
+	}
+	err := r.db.Raw(`
+		SELECT artist_id, COUNT(*) AS listeners
+		FROM (` + artistListenersSQL + `) l
+		GROUP BY artist_id`).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		counts[row.ArtistID] = row.Listeners
+	}
+	return counts, nil
+}
+
+func (r *artistRepo) GetListenerOverlaps(artistID string, limit int) ([]ArtistOverlap, error) {
+	var overlaps []ArtistOverlap
+	err := r.db.Raw(`
+		WITH listeners AS (`+artistListenersSQL+`)
+		SELECT other.artist_id, COUNT(*) AS shared_listeners
+		FROM listeners mine
+		JOIN listeners other ON other.user_id = mine.user_id AND other.artist_id <> mine.artist_id
+		WHERE mine.artist_id = ?
+		GROUP BY other.artist_id
+		ORDER BY shared_listeners DESC
+		LIMIT ?`, artistID, limit).
+		Scan(&overlaps).Error
+	return overlaps, err
+}
+
+func (r *artistRepo) GetFeatureCentroids() ([]ArtistFeatureCentroid, error) {
+	var centroids []ArtistFeatureCentroid
+	err := r.db.Table("song_artists sa").
+		Select(`sa.artist_id,
+			AVG(s.danceability) AS danceability, AVG(s.energy) AS energy, AVG(s.key) AS key,
+			AVG(s.loudness) AS loudness, AVG(s.mode) AS mode, AVG(s.speechiness) AS speechiness,
+			AVG(s.acousticness) AS acousticness, AVG(s.instrumentalness) AS instrumentalness,
+			AVG(s.liveness) AS liveness, AVG(s.valence) AS valence, AVG(s.tempo) AS tempo,
+			AVG(s.time_signature) AS time_signature, AVG(s.popularity) AS popularity`).
+		Joins("JOIN songs s ON s.id = sa.song_id").
+		Group("sa.artist_id").
+		Scan(&centroids).Error
+	return centroids, err
+}
+
+func (r *artistRepo) GetSimilarArtists(artistID string, limit int) ([]models.ArtistSimilarity, error) {
+	var similarities []models.ArtistSimilarity
+	err := r.db.Preload("SimilarArtist").
+		Where("artist_id = ?", artistID).
+		Order("score DESC").
+		Limit(limit).
+		Find(&similarities).Error
+	return similarities, err
+}
+
+func (r *artistRepo) SaveSimilarArtists(artistID string, similarities []models.ArtistSimilarity) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("artist_id = ?", artistID).Delete(&models.ArtistSimilarity{}).Error; err != nil {
+			return err
+		}
+		if len(similarities) == 0 {
+			return nil
+		}
+		now := time.Now()
+		for i := range similarities {
+			similarities[i].ArtistID = artistID
+			similarities[i].ComputedAt = now
+		}
+		return tx.Omit("SimilarArtist").Create(&similarities).Error
+	})
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"back_music/internal/database"
+	"back_music/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type AuditLogRepository interface {
+	CreateAuditLog(entry *models.AuditLog) error
+	ListAuditLogs(entityType, entityID string, limit int) ([]models.AuditLog, error)
+}
+
+type auditLogRepo struct {
+	db *gorm.DB
+}
+
+func NewAuditLogRepository() AuditLogRepository {
+	return &auditLogRepo{db: database.DB}
+}
+
+func (r *auditLogRepo) CreateAuditLog(entry *models.AuditLog) error {
+	return r.db.Create(entry).Error
+}
+
+func (r *auditLogRepo) ListAuditLogs(entityType, entityID string, limit int) ([]models.AuditLog, error) {
+	logs := []models.AuditLog{}
+	query := r.db.Order("created_at DESC, id DESC").Limit(limit)
+	if entityType != "" {
+		query = query.Where("entity_type = ?", entityType)
+	}
+	if entityID != "" {
+		query = query.Where("entity_id = ?", entityID)
+	}
+	err := query.Find(&logs).Error
+	return logs, err
+}
@@ -0,0 +1,276 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"back_music/internal/database"
+	"back_music/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var ErrMergeSameSong = errors.New("cannot merge a song into itself")
+
+// bulkUpdateBatchSize menjaga jumlah parameter IN di bawah batas Postgres.
+const bulkUpdateBatchSize = 1000
+
+// SongStats adalah ringkasan penggunaan satu lagu untuk admin.
+type SongStats struct {
+	SongID     string     `json:"song_id"`
+	Likes      int64      `json:"likes"`
+	TotalPlays int64      `json:"total_plays"`
+	Listeners  int64      `json:"listeners"`
+	Plays7d    int64      `json:"plays_7d"`
+	Plays30d   int64      `json:"plays_30d"`
+	Playlists  int64      `json:"playlists"`
+	BestRank   *int       `json:"best_chart_rank"`
+	LastPlayed *time.Time `json:"last_played"`
+}
+
+// CatalogRepository berisi operasi katalog yang menyentuh banyak tabel sekaligus
+// (hapus/merge lagu beserta semua referensinya).
+type CatalogRepository interface {
+	DeleteSong(songID string) error
+	MergeSongs(sourceID, targetID string) error
+	BulkUpdateSongs(filter SongBrowseFilter, updates map[string]interface{}) (int64, error)
+	GetSongStats(songID string) (*SongStats, error)
+	// OnSongsChanged mendaftarkan listener yang dipanggil setelah delete, merge atau bulk
+	// update berhasil, dengan ID lagu yang berubah dan yang terhapus. Daftarkan saat startup saja.
+	OnSongsChanged(listener func(savedIDs, deletedIDs []string))
+}
+
+type catalogRepo struct {
+	db              *gorm.DB
+	changeListeners []func(savedIDs, deletedIDs []string)
+}
+
+func NewCatalogRepository() CatalogRepository {
+	return &catalogRepo{db: database.DB}
+}
+
+func (r *catalogRepo) OnSongsChanged(listener func(savedIDs, deletedIDs []string)) {
+	r.changeListeners = append(r.changeListeners, listener)
+}
+
+func (r *catalogRepo) notifySongsChanged(savedIDs, deletedIDs []string) {
+	for _, listener := range r.changeListeners {
+		listener(savedIDs, deletedIDs)
+	}
+}
+
+// DeleteSong menghapus lagu beserta like, play, item playlist dan relasi artist-nya.
+// Entry chart lama tetap disimpan tanpa referensi lagu.
+func (r *catalogRepo) DeleteSong(songID string) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&models.Song{}, "id = ?", songID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrSongNotFound
+			}
+			return err
+		}
+
+		cleanups := []interface{}{
+			&models.UserLike{},
+			&models.UserPlay{},
+			&models.PlayEvent{},
+			&models.PlaylistItem{},
+			&models.SongArtist{},
+			&models.SongSource{},
+			&models.YoutubeMiss{},
+		}
+		for _, model := range cleanups {
+			if err := tx.Where("song_id = ?", songID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&models.ChartEntry{}).Where("song_id = ?", songID).
+			Update("song_id", nil).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(&models.Song{}, "id = ?", songID).Error
+	})
+	if err != nil {
+		return err
+	}
+	r.notifySongsChanged(nil, []string{songID})
+	return nil
+}
+
+// MergeSongs memindahkan semua referensi lagu source ke target lalu menghapus source.
+// Like ganda digabung, play_count dijumlahkan, dan field kosong di target diisi dari source.
+func (r *catalogRepo) MergeSongs(sourceID, targetID string) error {
+	if sourceID == targetID {
+		return ErrMergeSameSong
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var source, target models.Song
+		if err := tx.First(&source, "id = ?", sourceID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrSongNotFound
+			}
+			return err
+		}
+		if err := tx.First(&target, "id = ?", targetID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrSongNotFound
+			}
+			return err
+		}
+
+		statements := []struct {
+			sql  string
+			args []interface{}
+		}{
+			// Likes: pindahkan yang belum ada di target, sisanya dihapus
+			{`UPDATE user_likes SET song_id = ? WHERE song_id = ? AND user_id NOT IN
+				(SELECT user_id FROM user_likes WHERE song_id = ?)`, []interface{}{targetID, sourceID, targetID}},
+			{`DELETE FROM user_likes WHERE song_id = ?`, []interface{}{sourceID}},
+			// Plays: jumlahkan ke baris target yang sudah ada
+			{`UPDATE user_plays t SET play_count = t.play_count + s.play_count,
+				last_played = GREATEST(t.last_played, s.last_played)
+				FROM user_plays s
+				WHERE t.song_id = ? AND s.song_id = ? AND s.user_id = t.user_id`, []interface{}{targetID, sourceID}},
+			{`DELETE FROM user_plays WHERE song_id = ? AND user_id IN
+				(SELECT user_id FROM user_plays WHERE song_id = ?)`, []interface{}{sourceID, targetID}},
+			{`UPDATE user_plays SET song_id = ? WHERE song_id = ?`, []interface{}{targetID, sourceID}},
+			{`UPDATE play_events SET song_id = ? WHERE song_id = ?`, []interface{}{targetID, sourceID}},
+			// Playlist: lagu yang sudah ada di playlist yang sama cukup dihapus
+			{`DELETE FROM playlist_items WHERE song_id = ? AND playlist_id IN
+				(SELECT playlist_id FROM playlist_items WHERE song_id = ?)`, []interface{}{sourceID, targetID}},
+			{`UPDATE playlist_items SET song_id = ? WHERE song_id = ?`, []interface{}{targetID, sourceID}},
+			{`INSERT INTO song_artists (song_id, artist_id, position)
+				SELECT ?, artist_id, position FROM song_artists WHERE song_id = ?
+				ON CONFLICT DO NOTHING`, []interface{}{targetID, sourceID}},
+			{`DELETE FROM song_artists WHERE song_id = ?`, []interface{}{sourceID}},
+			{`UPDATE chart_entries SET song_id = ? WHERE song_id = ?`, []interface{}{targetID, sourceID}},
+			// Sumber audio: yang sama persis dengan milik target dibuang
+			{`UPDATE song_sources SET song_id = ? WHERE song_id = ? AND (provider, ref) NOT IN
+				(SELECT provider, ref FROM song_sources WHERE song_id = ?)`, []interface{}{targetID, sourceID, targetID}},
+			{`DELETE FROM song_sources WHERE song_id = ?`, []interface{}{sourceID}},
+			{`DELETE FROM youtube_misses WHERE song_id = ?`, []interface{}{sourceID}},
+		}
+		for _, stmt := range statements {
+			if err := tx.Exec(stmt.sql, stmt.args...).Error; err != nil {
+				return err
+			}
+		}
+
+		fill := map[string]interface{}{}
+		if target.YoutubeID == "" && source.YoutubeID != "" {
+			fill["youtube_id"] = source.YoutubeID
+			fill["youtube_confidence"] = source.YoutubeConfidence
+		}
+		if target.PreviewURL == "" && source.PreviewURL != "" {
+			fill["preview_url"] = source.PreviewURL
+		}
+		if target.ImageURL == "" && source.ImageURL != "" {
+			fill["image_url"] = source.ImageURL
+		}
+		if target.Genre == "" && source.Genre != "" {
+			fill["genre"] = source.Genre
+		}
+		if target.ISRC == "" && source.ISRC != "" {
+			fill["isrc"] = source.ISRC
+		}
+
+		if err := tx.Delete(&models.Song{}, "id = ?", sourceID).Error; err != nil {
+			return err
+		}
+		if len(fill) > 0 {
+			return tx.Model(&models.Song{}).Where("id = ?", targetID).Updates(fill).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	r.notifySongsChanged([]string{targetID}, []string{sourceID})
+	return nil
+}
+
+// BulkUpdateSongs meng-update kolom yang sama untuk semua lagu yang cocok dengan filter.
+func (r *catalogRepo) BulkUpdateSongs(filter SongBrowseFilter, updates map[string]interface{}) (int64, error) {
+	var ids []string
+	if err := applySongBrowseFilter(r.db.Model(&models.Song{}), filter).Pluck("songs.id", &ids).Error; err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	var affected int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for start := 0; start < len(ids); start += bulkUpdateBatchSize {
+			batch := ids[start:min(start+bulkUpdateBatchSize, len(ids))]
+			result := tx.Model(&models.Song{}).Where("id IN ?", batch).Updates(updates)
+			if result.Error != nil {
+				return result.Error
+			}
+			affected += result.RowsAffected
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	r.notifySongsChanged(ids, nil)
+	return affected, nil
+}
+
+func (r *catalogRepo) GetSongStats(songID string) (*SongStats, error) {
+	if err := r.db.Select("id").First(&models.Song{}, "id = ?", songID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSongNotFound
+		}
+		return nil, err
+	}
+
+	stats := &SongStats{SongID: songID}
+	now := time.Now()
+
+	if err := r.db.Model(&models.UserLike{}).Where("song_id = ?", songID).Count(&stats.Likes).Error; err != nil {
+		return nil, err
+	}
+
+	var plays struct {
+		TotalPlays int64
+		Listeners  int64
+		LastPlayed *time.Time
+	}
+	err := r.db.Model(&models.UserPlay{}).
+		Select("COALESCE(SUM(play_count), 0) AS total_plays, COUNT(DISTINCT user_id) AS listeners, MAX(last_played) AS last_played").
+		Where("song_id = ?", songID).
+		Scan(&plays).Error
+	if err != nil {
+		return nil, err
+	}
+	stats.TotalPlays, stats.Listeners, stats.LastPlayed = plays.TotalPlays, plays.Listeners, plays.LastPlayed
+
+	if err := r.db.Model(&models.PlayEvent{}).
+		Where("song_id = ? AND played_at >= ?", songID, now.AddDate(0, 0, -7)).
+		Count(&stats.Plays7d).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Model(&models.PlayEvent{}).
+		Where("song_id = ? AND played_at >= ?", songID, now.AddDate(0, 0, -30)).
+		Count(&stats.Plays30d).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Model(&models.PlaylistItem{}).
+		Where("song_id = ?", songID).
+		Distinct("playlist_id").
+		Count(&stats.Playlists).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Model(&models.ChartEntry{}).
+		Select("MIN(rank)").
+		Where("song_id = ?", songID).
+		Scan(&stats.BestRank).Error; err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"back_music/internal/database"
+	"back_music/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var ErrChartNotFound = errors.New("chart not found")
+
+type ChartRepository interface {
+	ReplaceChart(chartType, period, genre string, snapshotDate time.Time, entries []models.ChartEntry) error
+	GetChart(chartType, period, genre string, snapshotDate time.Time, limit int) ([]models.ChartEntry, error)
+	GetLatestSnapshotDate(chartType, period, genre string) (time.Time, error)
+	GetChartGenres(period string, snapshotDate time.Time) ([]string, error)
+	HasSnapshot(period string, snapshotDate time.Time) (bool, error)
+}
+
+type chartRepo struct {
+	db *gorm.DB
+}
+
+func NewChartRepository() ChartRepository {
+	return &chartRepo{db: database.DB}
+}
+
+// ReplaceChart menimpa snapshot chart (idempotent kalau job dijalankan ulang).
+func (r *chartRepo) ReplaceChart(chartType, period, genre string, snapshotDate time.Time, entries []models.ChartEntry) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("chart_type = ? AND period = ? AND genre = ? AND snapshot_date = ?",
+			chartType, period, genre, snapshotDate.Format("2006-01-02")).
+			Delete(&models.ChartEntry{}).Error; err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+		return tx.Create(&entries).Error
+	})
+}
+
+func (r *chartRepo) GetChart(chartType, period, genre string, snapshotDate time.Time, limit int) ([]models.ChartEntry, error) {
+	var entries []models.ChartEntry
+	err := r.db.Preload("Song").
+		Where("chart_type = ? AND period = ? AND genre = ? AND snapshot_date = ?",
+			chartType, period, genre, snapshotDate.Format("2006-01-02")).
+		Order("rank ASC").
+		Limit(limit).
+		Find(&entries).Error
+	return entries, err
+}
+
+func (r *chartRepo) GetLatestSnapshotDate(chartType, period, genre string) (time.Time, error) {
+	var entry models.ChartEntry
+	err := r.db.
+		Where("chart_type = ? AND period = ? AND genre = ?", chartType, period, genre).
+		Order("snapshot_date DESC").
+		First(&entry).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return time.Time{}, ErrChartNotFound
+		}
+		return time.Time{}, err
+	}
+	return entry.SnapshotDate, nil
+}
+
+func (r *chartRepo) GetChartGenres(period string, snapshotDate time.Time) ([]string, error) {
+	var genres []string
+	err := r.db.Model(&models.ChartEntry{}).
+		Where("chart_type = ? AND period = ? AND snapshot_date = ?",
+			models.ChartGenre, period, snapshotDate.Format("2006-01-02")).
+		Distinct().
+		Order("genre").
+		Pluck("genre", &genres).Error
+	return genres, err
+}
+
+func (r *chartRepo) HasSnapshot(period string, snapshotDate time.Time) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.ChartEntry{}).
+		Where("period = ? AND snapshot_date = ?", period, snapshotDate.Format("2006-01-02")).
+		Count(&count).Error
+	return count > 0, err
+}
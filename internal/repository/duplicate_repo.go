@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+
+	"back_music/internal/database"
+	"back_music/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var ErrDuplicateClusterNotFound = errors.New("duplicate cluster not found")
+
+type DuplicateRepository interface {
+	GetClusterByID(id string) (*models.DuplicateCluster, error)
+	GetClusterByMatchKey(matchKey string) (*models.DuplicateCluster, error)
+	// ListClustersByMatchKeyPrefix mengambil cluster dengan match key berawalan prefix
+	// (mis. semua bucket durasi untuk artist + judul yang sama).
+	ListClustersByMatchKeyPrefix(prefix string) ([]models.DuplicateCluster, error)
+	ListClustersByStatus(status string) ([]models.DuplicateCluster, error)
+	SaveCluster(cluster *models.DuplicateCluster) error
+	ListClusters(status string, limit, offset int) ([]models.DuplicateCluster, int64, error)
+}
+
+type duplicateRepo struct {
+	db *gorm.DB
+}
+
+func NewDuplicateRepository() DuplicateRepository {
+	return &duplicateRepo{db: database.DB}
+}
+
+func (r *duplicateRepo) GetClusterByID(id string) (*models.DuplicateCluster, error) {
+	var cluster models.DuplicateCluster
+	err := r.db.First(&cluster, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDuplicateClusterNotFound
+		}
+		return nil, err
+	}
+	return &cluster, nil
+}
+
+func (r *duplicateRepo) GetClusterByMatchKey(matchKey string) (*models.DuplicateCluster, error) {
+	var cluster models.DuplicateCluster
+	err := r.db.First(&cluster, "match_key = ?", matchKey).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDuplicateClusterNotFound
+		}
+		return nil, err
+	}
+	return &cluster, nil
+}
+
+func (r *duplicateRepo) ListClustersByMatchKeyPrefix(prefix string) ([]models.DuplicateCluster, error) {
+	var clusters []models.DuplicateCluster
+	pattern := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(prefix) + "%"
+	err := r.db.Where("match_key LIKE ?", pattern).Order("created_at").Find(&clusters).Error
+	return clusters, err
+}
+
+func (r *duplicateRepo) ListClustersByStatus(status string) ([]models.DuplicateCluster, error) {
+	var clusters []models.DuplicateCluster
+	err := r.db.Where("status = ?", status).Order("created_at").Find(&clusters).Error
+	return clusters, err
+}
+
+func (r *duplicateRepo) SaveCluster(cluster *models.DuplicateCluster) error {
+	if cluster.ID == "" {
+		return r.db.Create(cluster).Error
+	}
+	return r.db.Save(cluster).Error
+}
+
+func (r *duplicateRepo) ListClusters(status string, limit, offset int) ([]models.DuplicateCluster, int64, error) {
+	clusters := []models.DuplicateCluster{}
+	query := r.db.Model(&models.DuplicateCluster{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&clusters).Error
+	return clusters, total, err
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"back_music/internal/database"
+	"back_music/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type FeatureStatRepository interface {
+	GetFeatureStats() ([]models.FeatureStat, error)
+	SaveFeatureStats(stats []models.FeatureStat) error
+}
+
+type featureStatRepo struct {
+	db *gorm.DB
+}
+
+func NewFeatureStatRepository() FeatureStatRepository {
+	return &featureStatRepo{db: database.DB}
+}
+
+func (r *featureStatRepo) GetFeatureStats() ([]models.FeatureStat, error) {
+	var stats []models.FeatureStat
+	err := r.db.Order("feature").Find(&stats).Error
+	return stats, err
+}
+
+// SaveFeatureStats mengganti seluruh snapshot statistik dalam satu transaksi,
+// supaya reader tidak pernah melihat campuran statistik lama dan baru.
+func (r *featureStatRepo) SaveFeatureStats(stats []models.FeatureStat) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&models.FeatureStat{}).Error; err != nil {
+			return err
+		}
+		if len(stats) == 0 {
+			return nil
+		}
+		return tx.Create(&stats).Error
+	})
+}
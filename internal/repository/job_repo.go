@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"back_music/internal/database"
+	"back_music/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var ErrJobNotFound = errors.New("job not found")
+
+type JobRepository interface {
+	CreateJob(job *models.Job) error
+	UpdateJob(job *models.Job) error
+	GetJobByID(id string) (*models.Job, error)
+	ListJobs(jobType, status string, limit, offset int) ([]models.Job, int64, error)
+	// FailUnfinishedJobs menandai job queued/running (sisa proses sebelumnya) sebagai failed.
+	FailUnfinishedJobs(reason string) (int64, error)
+	// FindActiveJob mengembalikan job queued/running dengan tipe dan pembuat tersebut.
+	FindActiveJob(jobType string, createdBy uint) (*models.Job, error)
+}
+
+type jobRepo struct {
+	db *gorm.DB
+}
+
+func NewJobRepository() JobRepository {
+	return &jobRepo{db: database.DB}
+}
+
+func (r *jobRepo) CreateJob(job *models.Job) error {
+	return r.db.Create(job).Error
+}
+
+func (r *jobRepo) UpdateJob(job *models.Job) error {
+	return r.db.Save(job).Error
+}
+
+func (r *jobRepo) GetJobByID(id string) (*models.Job, error) {
+	var job models.Job
+	err := r.db.First(&job, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrJobNotFound
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *jobRepo) ListJobs(jobType, status string, limit, offset int) ([]models.Job, int64, error) {
+	query := r.db.Model(&models.Job{})
+	if jobType != "" {
+		query = query.Where("type = ?", jobType)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Result (laporan import) bisa besar, tidak ikut di listing
+	var jobs []models.Job
+	err := query.
+		Omit("result").
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&jobs).Error
+	return jobs, total, err
+}
+
+func (r *jobRepo) FailUnfinishedJobs(reason string) (int64, error) {
+	result := r.db.Model(&models.Job{}).
+		Where("status IN ?", []string{models.JobStatusQueued, models.JobStatusRunning}).
+		Updates(map[string]interface{}{
+			"status":      models.JobStatusFailed,
+			"error":       reason,
+			"finished_at": time.Now(),
+		})
+	return result.RowsAffected, result.Error
+}
+
+func (r *jobRepo) FindActiveJob(jobType string, createdBy uint) (*models.Job, error) {
+	var job models.Job
+	err := r.db.Omit("result").
+		Where("type = ? AND created_by = ? AND status IN ?", jobType, createdBy,
+			[]string{models.JobStatusQueued, models.JobStatusRunning}).
+		Order("created_at DESC").
+		First(&job).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrJobNotFound
+		}
+		return nil, err
+	}
+	return &job, nil
+}
@@ -0,0 +1,257 @@
+package repository
+
+import (
+	"slices"
+	"strconv"
+	"time"
+
+	"back_music/internal/database"
+	"back_music/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// SongPlayStat adalah agregat play event per lagu dalam satu window waktu.
+type SongPlayStat struct {
+	SongID    string
+	Artist    string
+	Plays     int
+	Listeners int
+}
+
+// ArtistPlayStat adalah agregat play event per artist dalam satu window waktu.
+type ArtistPlayStat struct {
+	ArtistID  string
+	Artist    string
+	Plays     int
+	Listeners int
+}
+
+// SongPlayVelocity membandingkan jumlah play window sekarang dengan window sebelumnya.
+type SongPlayVelocity struct {
+	SongID        string
+	Artist        string
+	CurrentPlays  int
+	PreviousPlays int
+	Listeners     int
+}
+
+type PlayEventRepository interface {
+	CreatePlayEvent(event *models.PlayEvent) error
+	GetTopSongs(from, to time.Time, genre string, limit int) ([]SongPlayStat, error)
+	GetTopArtists(from, to time.Time, limit int) ([]ArtistPlayStat, error)
+	GetPlayedGenres(from, to time.Time) ([]string, error)
+	GetSongVelocities(previousFrom, currentFrom, to time.Time) ([]SongPlayVelocity, error)
+	// BackfillPlayEvents menyimpan play event riwayat (timestamp asli) milik satu user dan
+	// menambahkan agregat UserPlay. Event yang sudah pernah diimport dilewati.
+	BackfillPlayEvents(userID uint, events []models.PlayEvent) (int, error)
+	// OnPlayRecorded mendaftarkan listener yang dipanggil setelah CreatePlayEvent berhasil
+	// (tidak untuk backfill). Daftarkan saat startup saja.
+	OnPlayRecorded(listener func(event models.PlayEvent))
+}
+
+type playEventRepo struct {
+	db            *gorm.DB
+	playListeners []func(event models.PlayEvent)
+}
+
+func NewPlayEventRepository() PlayEventRepository {
+	return &playEventRepo{db: database.DB}
+}
+
+func (r *playEventRepo) CreatePlayEvent(event *models.PlayEvent) error {
+	if event.PlayedAt.IsZero() {
+		event.PlayedAt = time.Now()
+	}
+	if err := r.db.Create(event).Error; err != nil {
+		return err
+	}
+	for _, listener := range r.playListeners {
+		listener(*event)
+	}
+	return nil
+}
+
+func (r *playEventRepo) OnPlayRecorded(listener func(event models.PlayEvent)) {
+	r.playListeners = append(r.playListeners, listener)
+}
+
+func (r *playEventRepo) BackfillPlayEvents(userID uint, events []models.PlayEvent) (int, error) {
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	sources := make([]string, 0, 2)
+	from, to := events[0].PlayedAt, events[0].PlayedAt
+	for _, event := range events {
+		if !slices.Contains(sources, event.Source) {
+			sources = append(sources, event.Source)
+		}
+		if event.PlayedAt.Before(from) {
+			from = event.PlayedAt
+		}
+		if event.PlayedAt.After(to) {
+			to = event.PlayedAt
+		}
+	}
+
+	inserted := 0
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Upload ulang file yang sama tidak boleh menggandakan riwayat
+		var existing []models.PlayEvent
+		if err := tx.Select("song_id, played_at").
+			Where("user_id = ? AND source IN ? AND played_at BETWEEN ? AND ?", userID, sources, from, to).
+			Find(&existing).Error; err != nil {
+			return err
+		}
+		seen := make(map[string]bool, len(existing)+len(events))
+		for _, event := range existing {
+			seen[playEventKey(event.SongID, event.PlayedAt)] = true
+		}
+
+		fresh := make([]models.PlayEvent, 0, len(events))
+		type playAggregate struct {
+			count      int
+			lastPlayed time.Time
+		}
+		aggregates := make(map[string]*playAggregate)
+		for _, event := range events {
+			key := playEventKey(event.SongID, event.PlayedAt)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			event.ID = 0
+			event.UserID = userID
+			fresh = append(fresh, event)
+
+			agg := aggregates[event.SongID]
+			if agg == nil {
+				agg = &playAggregate{}
+				aggregates[event.SongID] = agg
+			}
+			agg.count++
+			if event.PlayedAt.After(agg.lastPlayed) {
+				agg.lastPlayed = event.PlayedAt
+			}
+		}
+		if len(fresh) == 0 {
+			return nil
+		}
+		if err := tx.CreateInBatches(fresh, 500).Error; err != nil {
+			return err
+		}
+		inserted = len(fresh)
+
+		songIDs := make([]string, 0, len(aggregates))
+		for songID := range aggregates {
+			songIDs = append(songIDs, songID)
+		}
+		var plays []models.UserPlay
+		if err := tx.Where("user_id = ? AND song_id IN ?", userID, songIDs).Find(&plays).Error; err != nil {
+			return err
+		}
+		for _, play := range plays {
+			agg := aggregates[play.SongID]
+			if agg == nil {
+				continue
+			}
+			lastPlayed := play.LastPlayed
+			if agg.lastPlayed.After(lastPlayed) {
+				lastPlayed = agg.lastPlayed
+			}
+			if err := tx.Model(&models.UserPlay{}).Where("id = ?", play.ID).
+				Updates(map[string]interface{}{
+					"play_count":  gorm.Expr("play_count + ?", agg.count),
+					"last_played": lastPlayed,
+				}).Error; err != nil {
+				return err
+			}
+			delete(aggregates, play.SongID)
+		}
+
+		created := make([]models.UserPlay, 0, len(aggregates))
+		for songID, agg := range aggregates {
+			created = append(created, models.UserPlay{
+				UserID:     userID,
+				SongID:     songID,
+				PlayCount:  agg.count,
+				LastPlayed: agg.lastPlayed,
+			})
+		}
+		if len(created) > 0 {
+			return tx.Omit("User", "Song").CreateInBatches(created, 500).Error
+		}
+		return nil
+	})
+	return inserted, err
+}
+
+func playEventKey(songID string, playedAt time.Time) string {
+	return songID + "|" + strconv.FormatInt(playedAt.Unix(), 10)
+}
+
+func (r *playEventRepo) GetTopSongs(from, to time.Time, genre string, limit int) ([]SongPlayStat, error) {
+	var stats []SongPlayStat
+	query := r.db.Table("play_events AS pe").
+		Select("pe.song_id, s.artist, COUNT(*) AS plays, COUNT(DISTINCT pe.user_id) AS listeners").
+		Joins("JOIN songs s ON s.id = pe.song_id").
+		Where("pe.played_at >= ? AND pe.played_at < ?", from, to).
+		Where("COALESCE(pe.source, '') NOT IN ?", models.ImportedPlaySources)
+	if genre != "" {
+		query = query.Where("s.genre = ?", genre)
+	}
+	err := query.
+		Group("pe.song_id, s.artist").
+		Order("plays DESC, listeners DESC, pe.song_id").
+		Limit(limit).
+		Scan(&stats).Error
+	return stats, err
+}
+
+func (r *playEventRepo) GetTopArtists(from, to time.Time, limit int) ([]ArtistPlayStat, error) {
+	var stats []ArtistPlayStat
+	// Dihitung per artist yang dikreditkan, bukan per string songs.artist: play lagu
+	// kolaborasi "A, B" masuk ke A dan ke B
+	err := r.db.Table("play_events AS pe").
+		Select("a.id AS artist_id, a.name AS artist, COUNT(*) AS plays, COUNT(DISTINCT pe.user_id) AS listeners").
+		Joins("JOIN song_artists sa ON sa.song_id = pe.song_id").
+		Joins("JOIN artists a ON a.id = sa.artist_id").
+		Where("pe.played_at >= ? AND pe.played_at < ?", from, to).
+		Where("COALESCE(pe.source, '') NOT IN ?", models.ImportedPlaySources).
+		Group("a.id, a.name").
+		Order("plays DESC, listeners DESC, a.name").
+		Limit(limit).
+		Scan(&stats).Error
+	return stats, err
+}
+
+func (r *playEventRepo) GetPlayedGenres(from, to time.Time) ([]string, error) {
+	var genres []string
+	err := r.db.Table("play_events AS pe").
+		Joins("JOIN songs s ON s.id = pe.song_id").
+		Where("pe.played_at >= ? AND pe.played_at < ?", from, to).
+		Where("COALESCE(pe.source, '') NOT IN ?", models.ImportedPlaySources).
+		Where("s.genre IS NOT NULL AND s.genre <> ''").
+		Distinct().
+		Pluck("s.genre", &genres).Error
+	return genres, err
+}
+
+// GetSongVelocities menghitung play per lagu di window [currentFrom, to) dan
+// window sebelumnya [previousFrom, currentFrom) dalam satu query.
+func (r *playEventRepo) GetSongVelocities(previousFrom, currentFrom, to time.Time) ([]SongPlayVelocity, error) {
+	var stats []SongPlayVelocity
+	err := r.db.Table("play_events AS pe").
+		Select(`pe.song_id, s.artist,
+			COUNT(*) FILTER (WHERE pe.played_at >= ?) AS current_plays,
+			COUNT(*) FILTER (WHERE pe.played_at < ?) AS previous_plays,
+			COUNT(DISTINCT pe.user_id) FILTER (WHERE pe.played_at >= ?) AS listeners`,
+			currentFrom, currentFrom, currentFrom).
+		Joins("JOIN songs s ON s.id = pe.song_id").
+		Where("pe.played_at >= ? AND pe.played_at < ?", previousFrom, to).
+		Where("COALESCE(pe.source, '') NOT IN ?", models.ImportedPlaySources).
+		Group("pe.song_id, s.artist").
+		Scan(&stats).Error
+	return stats, err
+}
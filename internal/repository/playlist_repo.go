@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"back_music/internal/database"
+	"back_music/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrPlaylistNotFound = errors.New("playlist not found")
+
+type PlaylistRepository interface {
+	CreatePlaylist(playlist *models.Playlist) error
+	GetPlaylistByID(id string) (*models.Playlist, error)
+	GetLatestPlaylistByKind(userID uint, kind string) (*models.Playlist, error)
+	ListPlaylistsByKind(userID uint, kind string, limit int) ([]models.Playlist, error)
+	HasSnapshot(userID uint, kind string, snapshotDate time.Time) (bool, error)
+	// CreateSnapshotPlaylist menyimpan playlist snapshot (mis. Discover Weekly) hanya jika
+	// snapshot minggu itu belum ada; created false berarti proses lain sudah membuatnya.
+	CreateSnapshotPlaylist(playlist *models.Playlist) (created bool, err error)
+	GetSnapshotPlaylist(userID uint, kind string, snapshotDate time.Time) (*models.Playlist, error)
+	// UpsertExternalPlaylist membuat/memperbarui playlist berdasarkan (UserID, ExternalID)
+	// dan mengganti seluruh items-nya.
+	UpsertExternalPlaylist(playlist *models.Playlist) error
+}
+
+type playlistRepo struct {
+	db *gorm.DB
+}
+
+func NewPlaylistRepository() PlaylistRepository {
+	return &playlistRepo{db: database.DB}
+}
+
+// CreatePlaylist menyimpan playlist beserta items-nya (gorm membungkusnya dalam satu transaksi).
+func (r *playlistRepo) CreatePlaylist(playlist *models.Playlist) error {
+	return r.db.Create(playlist).Error
+}
+
+func (r *playlistRepo) GetPlaylistByID(id string) (*models.Playlist, error) {
+	var playlist models.Playlist
+	err := r.db.
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position ASC")
+		}).
+		Preload("Items.Song").
+		First(&playlist, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPlaylistNotFound
+		}
+		return nil, err
+	}
+	playlist.TrackCount = len(playlist.Items)
+	return &playlist, nil
+}
+
+func (r *playlistRepo) GetLatestPlaylistByKind(userID uint, kind string) (*models.Playlist, error) {
+	var playlist models.Playlist
+	err := r.db.
+		Where("user_id = ? AND kind = ?", userID, kind).
+		Order("snapshot_date DESC NULLS LAST, created_at DESC").
+		First(&playlist).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPlaylistNotFound
+		}
+		return nil, err
+	}
+	return r.GetPlaylistByID(playlist.ID)
+}
+
+// ListPlaylistsByKind mengembalikan playlist tanpa items (hanya jumlah lagu).
+func (r *playlistRepo) ListPlaylistsByKind(userID uint, kind string, limit int) ([]models.Playlist, error) {
+	var playlists []models.Playlist
+	err := r.db.
+		Where("user_id = ? AND kind = ?", userID, kind).
+		Order("snapshot_date DESC NULLS LAST, created_at DESC").
+		Limit(limit).
+		Find(&playlists).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(playlists) == 0 {
+		return []models.Playlist{}, nil
+	}
+
+	ids := make([]string, len(playlists))
+	for i, p := range playlists {
+		ids[i] = p.ID
+	}
+
+	var counts []struct {
+		PlaylistID string
+		Total      int
+	}
+	if err := r.db.Model(&models.PlaylistItem{}).
+		Select("playlist_id, COUNT(*) AS total").
+		Where("playlist_id IN ?", ids).
+		Group("playlist_id").
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+
+	countMap := make(map[string]int, len(counts))
+	for _, c := range counts {
+		countMap[c.PlaylistID] = c.Total
+	}
+	for i := range playlists {
+		playlists[i].TrackCount = countMap[playlists[i].ID]
+	}
+	return playlists, nil
+}
+
+func (r *playlistRepo) HasSnapshot(userID uint, kind string, snapshotDate time.Time) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Playlist{}).
+		Where("user_id = ? AND kind = ? AND snapshot_date = ?", userID, kind, snapshotDate.Format("2006-01-02")).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *playlistRepo) CreateSnapshotPlaylist(playlist *models.Playlist) (bool, error) {
+	created := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// ON CONFLICT DO NOTHING: scheduler dan request on-demand bisa membuat snapshot
+		// yang sama bersamaan; yang kalah tidak gagal, cukup memakai milik pemenang
+		result := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "kind"}, {Name: "snapshot_date"}},
+			DoNothing: true,
+		}).Omit("Items").Create(playlist)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		created = true
+
+		for i := range playlist.Items {
+			playlist.Items[i].PlaylistID = playlist.ID
+		}
+		if len(playlist.Items) == 0 {
+			return nil
+		}
+		return tx.Omit("Song").Create(&playlist.Items).Error
+	})
+	return created, err
+}
+
+func (r *playlistRepo) GetSnapshotPlaylist(userID uint, kind string, snapshotDate time.Time) (*models.Playlist, error) {
+	var playlist models.Playlist
+	err := r.db.
+		Where("user_id = ? AND kind = ? AND snapshot_date = ?", userID, kind, snapshotDate.Format("2006-01-02")).
+		First(&playlist).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPlaylistNotFound
+		}
+		return nil, err
+	}
+	return r.GetPlaylistByID(playlist.ID)
+}
+
+func (r *playlistRepo) UpsertExternalPlaylist(playlist *models.Playlist) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var existing models.Playlist
+		err := tx.Where("user_id = ? AND kind = ? AND external_id = ?", playlist.UserID, playlist.Kind, playlist.ExternalID).
+			First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return tx.Create(playlist).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		items := playlist.Items
+		playlist.ID = existing.ID
+		playlist.CreatedAt = existing.CreatedAt
+		playlist.Items = nil
+		if err := tx.Save(playlist).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("playlist_id = ?", playlist.ID).Delete(&models.PlaylistItem{}).Error; err != nil {
+			return err
+		}
+		for i := range items {
+			items[i].PlaylistID = playlist.ID
+		}
+		if len(items) > 0 {
+			if err := tx.CreateInBatches(&items, 200).Error; err != nil {
+				return err
+			}
+		}
+		playlist.Items = items
+		return nil
+	})
+}
@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"back_music/internal/database"
+	"back_music/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrScrobbleAccountNotFound = errors.New("scrobble account not connected")
+
+// ScrobbleQueueStats adalah jumlah listen di antrian per status.
+type ScrobbleQueueStats struct {
+	Pending int64 `json:"pending"`
+	Failed  int64 `json:"failed"`
+}
+
+type ScrobbleRepository interface {
+	GetAccount(userID uint) (*models.ScrobbleAccount, error)
+	// UpsertAccount menyimpan akun berdasarkan user_id (connect ulang menimpa token lama).
+	UpsertAccount(account *models.ScrobbleAccount) error
+	// MarkSubmitted, MarkSubmitError dan DisableAccount hanya mengubah kolom status, dan hanya
+	// jika token yang tersimpan masih token yang dipakai untuk submit: disconnect atau connect
+	// ulang selama flush tidak boleh dihidupkan lagi atau ditimpa state lama.
+	MarkSubmitted(userID uint, tokenEncrypted string, at time.Time) error
+	MarkSubmitError(userID uint, tokenEncrypted string, lastError string) error
+	DisableAccount(userID uint, tokenEncrypted string, lastError string) error
+	// DeleteAccount juga membuang antrian listen milik user.
+	DeleteAccount(userID uint) error
+
+	Enqueue(item *models.ScrobbleQueueItem) error
+	// DueItems mengambil listen pending yang sudah waktunya dikirim, hanya untuk akun yang aktif.
+	DueItems(now time.Time, limit int) ([]models.ScrobbleQueueItem, error)
+	DeleteItems(ids []uint) error
+	// MarkAttemptFailed menaikkan attempts; item yang mencapai maxAttempts menjadi failed.
+	MarkAttemptFailed(ids []uint, lastError string, nextAttemptAt time.Time, maxAttempts int) error
+	// RetryFailed mengantrikan ulang listen failed milik user.
+	RetryFailed(userID uint) (int64, error)
+	QueueStats(userID uint) (*ScrobbleQueueStats, error)
+}
+
+type scrobbleRepo struct {
+	db *gorm.DB
+}
+
+func NewScrobbleRepository() ScrobbleRepository {
+	return &scrobbleRepo{db: database.DB}
+}
+
+func (r *scrobbleRepo) GetAccount(userID uint) (*models.ScrobbleAccount, error) {
+	var account models.ScrobbleAccount
+	err := r.db.Where("user_id = ?", userID).First(&account).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrScrobbleAccountNotFound
+		}
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (r *scrobbleRepo) UpsertAccount(account *models.ScrobbleAccount) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"api_url", "username", "token_encrypted", "enabled", "last_error", "updated_at",
+		}),
+	}).Create(account).Error
+}
+
+func (r *scrobbleRepo) MarkSubmitted(userID uint, tokenEncrypted string, at time.Time) error {
+	return r.updateAccountStatus(userID, tokenEncrypted, map[string]interface{}{
+		"last_submitted_at": at,
+		"last_error":        "",
+	})
+}
+
+func (r *scrobbleRepo) MarkSubmitError(userID uint, tokenEncrypted string, lastError string) error {
+	return r.updateAccountStatus(userID, tokenEncrypted, map[string]interface{}{
+		"last_error": lastError,
+	})
+}
+
+func (r *scrobbleRepo) DisableAccount(userID uint, tokenEncrypted string, lastError string) error {
+	return r.updateAccountStatus(userID, tokenEncrypted, map[string]interface{}{
+		"enabled":    false,
+		"last_error": lastError,
+	})
+}
+
+// updateAccountStatus: 0 baris berarti akun sudah di-disconnect atau tokennya diganti;
+// itu bukan error bagi flusher.
+func (r *scrobbleRepo) updateAccountStatus(userID uint, tokenEncrypted string, fields map[string]interface{}) error {
+	return r.db.Model(&models.ScrobbleAccount{}).
+		Where("user_id = ? AND token_encrypted = ?", userID, tokenEncrypted).
+		Updates(fields).Error
+}
+
+func (r *scrobbleRepo) DeleteAccount(userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("user_id = ?", userID).Delete(&models.ScrobbleAccount{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrScrobbleAccountNotFound
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.ScrobbleQueueItem{}).Error
+	})
+}
+
+func (r *scrobbleRepo) Enqueue(item *models.ScrobbleQueueItem) error {
+	if item.Status == "" {
+		item.Status = models.ScrobbleStatusPending
+	}
+	if item.NextAttemptAt.IsZero() {
+		item.NextAttemptAt = time.Now()
+	}
+	return r.db.Create(item).Error
+}
+
+func (r *scrobbleRepo) DueItems(now time.Time, limit int) ([]models.ScrobbleQueueItem, error) {
+	var items []models.ScrobbleQueueItem
+	err := r.db.Table("scrobble_queue_items AS q").
+		Select("q.*").
+		Joins("JOIN scrobble_accounts a ON a.user_id = q.user_id AND a.enabled").
+		Where("q.status = ? AND q.next_attempt_at <= ?", models.ScrobbleStatusPending, now).
+		Order("q.user_id, q.listened_at").
+		Limit(limit).
+		Find(&items).Error
+	return items, err
+}
+
+func (r *scrobbleRepo) DeleteItems(ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Where("id IN ?", ids).Delete(&models.ScrobbleQueueItem{}).Error
+}
+
+func (r *scrobbleRepo) MarkAttemptFailed(ids []uint, lastError string, nextAttemptAt time.Time, maxAttempts int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Model(&models.ScrobbleQueueItem{}).
+		Where("id IN ?", ids).
+		Updates(map[string]interface{}{
+			"attempts":        gorm.Expr("attempts + 1"),
+			"last_error":      lastError,
+			"next_attempt_at": nextAttemptAt,
+			"status": gorm.Expr("CASE WHEN attempts + 1 >= ? THEN ? ELSE ? END",
+				maxAttempts, models.ScrobbleStatusFailed, models.ScrobbleStatusPending),
+		}).Error
+}
+
+func (r *scrobbleRepo) RetryFailed(userID uint) (int64, error) {
+	result := r.db.Model(&models.ScrobbleQueueItem{}).
+		Where("user_id = ? AND status = ?", userID, models.ScrobbleStatusFailed).
+		Updates(map[string]interface{}{
+			"status":          models.ScrobbleStatusPending,
+			"attempts":        0,
+			"next_attempt_at": time.Now(),
+		})
+	return result.RowsAffected, result.Error
+}
+
+func (r *scrobbleRepo) QueueStats(userID uint) (*ScrobbleQueueStats, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	err := r.db.Model(&models.ScrobbleQueueItem{}).
+		Select("status, COUNT(*) AS count").
+		Where("user_id = ?", userID).
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &ScrobbleQueueStats{}
+	for _, row := range rows {
+		switch row.Status {
+		case models.ScrobbleStatusPending:
+			stats.Pending = row.Count
+		case models.ScrobbleStatusFailed:
+			stats.Failed = row.Count
+		}
+	}
+	return stats, nil
+}
@@ -0,0 +1,301 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"back_music/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// SongSortColumns adalah kolom yang boleh dipakai untuk sort=..., dipetakan ke kolom SQL.
+var SongSortColumns = map[string]string{
+	"popularity": "songs.popularity",
+	"created_at": "songs.created_at",
+	"title":      "songs.title",
+	"tempo":      "songs.tempo",
+}
+
+// SongFeatureColumns adalah fitur audio yang bisa difilter dengan min_<fitur>/max_<fitur>.
+var SongFeatureColumns = map[string]string{
+	"danceability":     "songs.danceability",
+	"energy":           "songs.energy",
+	"valence":          "songs.valence",
+	"acousticness":     "songs.acousticness",
+	"instrumentalness": "songs.instrumentalness",
+	"speechiness":      "songs.speechiness",
+	"liveness":         "songs.liveness",
+	"tempo":            "songs.tempo",
+	"loudness":         "songs.loudness",
+}
+
+// Mood diturunkan dari valence, sama dengan penjelasan rekomendasi content-based.
+const (
+	MoodUpbeat  = "upbeat"
+	MoodNeutral = "neutral"
+	MoodMellow  = "mellow"
+)
+
+const songMoodSQL = `CASE WHEN songs.valence > 0.7 THEN 'upbeat' WHEN songs.valence < 0.3 THEN 'mellow' ELSE 'neutral' END`
+
+// songYearSQL mengambil tahun rilis dari album (release_date bisa "2019", "2019-05" atau "2019-05-01").
+const songYearSQL = `CAST(NULLIF(substring(albums.release_date from '^[0-9]{4}'), '') AS integer)`
+
+// FeatureRange adalah filter rentang untuk satu kolom di SongFeatureColumns.
+type FeatureRange struct {
+	Feature string
+	Min     *float64
+	Max     *float64
+}
+
+type SongBrowseFilter struct {
+	Genre         string
+	Artist        string
+	Mood          string
+	YearFrom      int
+	YearTo        int
+	MinDurationMs int
+	MaxDurationMs int
+	Features      []FeatureRange
+}
+
+type SongBrowseOptions struct {
+	Filter SongBrowseFilter
+	Sort   string // key dari SongSortColumns
+	Desc   bool
+	Cursor string
+	Limit  int
+}
+
+// FacetCount adalah jumlah lagu untuk satu nilai facet.
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+type SongFacets struct {
+	Genres  []FacetCount `json:"genres"`
+	Moods   []FacetCount `json:"moods"`
+	Decades []FacetCount `json:"decades"`
+}
+
+type SongBrowsePage struct {
+	Songs      []models.Song `json:"songs"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+	HasMore    bool          `json:"has_more"`
+	Total      int64         `json:"total"`
+	Facets     SongFacets    `json:"facets"`
+}
+
+// songCursor adalah posisi keyset: nilai kolom sort + song ID lagu terakhir. Sort dan
+// arahnya ikut disimpan karena nilai hanya bermakna untuk urutan yang membuatnya.
+type songCursor struct {
+	Sort  string      `json:"s"`
+	Desc  bool        `json:"d"`
+	Value interface{} `json:"v"`
+	ID    string      `json:"id"`
+}
+
+func encodeSongCursor(sort string, desc bool, value interface{}, id string) string {
+	data, _ := json.Marshal(songCursor{Sort: sort, Desc: desc, Value: value, ID: id})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeSongCursor menolak cursor dari sort/order lain dan mengembalikan nilai sort dengan
+// tipe kolomnya, supaya perbandingan keyset tidak pernah gagal di database.
+func decodeSongCursor(cursor, sort string, desc bool) (*songCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var decoded songCursor
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.Value == nil {
+		return nil, ErrInvalidCursor
+	}
+	if decoded.Sort != sort || decoded.Desc != desc {
+		return nil, fmt.Errorf("%w: it belongs to a different sort or order", ErrInvalidCursor)
+	}
+	if _, err := uuid.Parse(decoded.ID); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	switch value := decoded.Value.(type) {
+	case float64:
+		if sort == "title" || sort == "created_at" || (sort == "popularity" && value != math.Trunc(value)) {
+			return nil, ErrInvalidCursor
+		}
+	case string:
+		if sort == "created_at" {
+			createdAt, err := time.Parse(time.RFC3339Nano, value)
+			if err != nil {
+				return nil, ErrInvalidCursor
+			}
+			decoded.Value = createdAt
+		} else if sort != "title" {
+			return nil, ErrInvalidCursor
+		}
+	default:
+		return nil, ErrInvalidCursor
+	}
+	return &decoded, nil
+}
+
+func songSortValue(song models.Song, sort string) interface{} {
+	switch sort {
+	case "created_at":
+		return song.CreatedAt
+	case "title":
+		return song.Title
+	case "tempo":
+		return song.Tempo
+	default:
+		return song.Popularity
+	}
+}
+
+// applySongBrowseFilter menerapkan filter ke query songs (LEFT JOIN albums untuk tahun).
+func applySongBrowseFilter(db *gorm.DB, filter SongBrowseFilter) *gorm.DB {
+	db = db.Joins("LEFT JOIN albums ON albums.id = songs.album_id")
+
+	if filter.Genre != "" {
+		db = db.Where("songs.genre ILIKE ?", filter.Genre)
+	}
+	if filter.Artist != "" {
+		db = db.Where(`EXISTS (
+			SELECT 1 FROM song_artists sa JOIN artists a ON a.id = sa.artist_id
+			WHERE sa.song_id = songs.id AND a.normalized_name = ?)`, NormalizeArtistName(filter.Artist))
+	}
+	if filter.Mood != "" {
+		db = db.Where(songMoodSQL+" = ?", filter.Mood)
+	}
+	if filter.YearFrom > 0 {
+		db = db.Where(songYearSQL+" >= ?", filter.YearFrom)
+	}
+	if filter.YearTo > 0 {
+		db = db.Where(songYearSQL+" <= ?", filter.YearTo)
+	}
+	if filter.MinDurationMs > 0 {
+		db = db.Where("songs.duration_ms >= ?", filter.MinDurationMs)
+	}
+	if filter.MaxDurationMs > 0 {
+		db = db.Where("songs.duration_ms <= ?", filter.MaxDurationMs)
+	}
+	for _, feature := range filter.Features {
+		column, ok := SongFeatureColumns[feature.Feature]
+		if !ok {
+			continue
+		}
+		if feature.Min != nil {
+			db = db.Where(column+" >= ?", *feature.Min)
+		}
+		if feature.Max != nil {
+			db = db.Where(column+" <= ?", *feature.Max)
+		}
+	}
+	return db
+}
+
+// BrowseSongs mengembalikan satu halaman lagu dengan keyset pagination (sort column + id),
+// total yang cocok dengan filter, dan facet genre/mood/dekade.
+func (r *songRepo) BrowseSongs(opts SongBrowseOptions) (*SongBrowsePage, error) {
+	column, ok := SongSortColumns[opts.Sort]
+	if !ok {
+		opts.Sort = "popularity"
+		column = SongSortColumns[opts.Sort]
+	}
+	direction, comparator := "ASC", ">"
+	if opts.Desc {
+		direction, comparator = "DESC", "<"
+	}
+
+	query := applySongBrowseFilter(r.db.Model(&models.Song{}), opts.Filter)
+
+	if opts.Cursor != "" {
+		cursor, err := decodeSongCursor(opts.Cursor, opts.Sort, opts.Desc)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where(
+			fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND songs.id %[2]s ?::uuid))", column, comparator),
+			cursor.Value, cursor.Value, cursor.ID,
+		)
+	}
+
+	songs := []models.Song{}
+	err := query.
+		Select("songs.*").
+		Order(fmt.Sprintf("%s %s, songs.id %s", column, direction, direction)).
+		Limit(opts.Limit + 1).
+		Find(&songs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	page := &SongBrowsePage{Songs: songs}
+	if len(songs) > opts.Limit {
+		page.Songs = songs[:opts.Limit]
+		page.HasMore = true
+		last := page.Songs[len(page.Songs)-1]
+		page.NextCursor = encodeSongCursor(opts.Sort, opts.Desc, songSortValue(last, opts.Sort), last.ID)
+	}
+
+	if err := applySongBrowseFilter(r.db.Model(&models.Song{}), opts.Filter).Count(&page.Total).Error; err != nil {
+		return nil, err
+	}
+
+	facets, err := r.songFacets(opts.Filter)
+	if err != nil {
+		return nil, err
+	}
+	page.Facets = *facets
+
+	return page, nil
+}
+
+func (r *songRepo) songFacets(filter SongBrowseFilter) (*SongFacets, error) {
+	facets := &SongFacets{
+		Genres:  []FacetCount{},
+		Moods:   []FacetCount{},
+		Decades: []FacetCount{},
+	}
+
+	// Setiap facet dihitung tanpa filter miliknya sendiri, supaya memilih satu genre
+	// tidak menyembunyikan jumlah genre lain
+	withoutGenre, withoutMood, withoutYear := filter, filter, filter
+	withoutGenre.Genre = ""
+	withoutMood.Mood = ""
+	withoutYear.YearFrom, withoutYear.YearTo = 0, 0
+
+	facetQueries := []struct {
+		expr   string
+		filter SongBrowseFilter
+		target *[]FacetCount
+		order  string
+	}{
+		{"songs.genre", withoutGenre, &facets.Genres, "count DESC, value"},
+		{songMoodSQL, withoutMood, &facets.Moods, "count DESC, value"},
+		{"((" + songYearSQL + ") / 10 * 10)::text || 's'", withoutYear, &facets.Decades, "value DESC"},
+	}
+
+	for _, facet := range facetQueries {
+		err := applySongBrowseFilter(r.db.Model(&models.Song{}), facet.filter).
+			Select(facet.expr+" AS value, COUNT(*) AS count").
+			Where(facet.expr+" IS NOT NULL AND "+facet.expr+" <> ''").
+			Group("value").
+			Order(facet.order).
+			Scan(facet.target).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return facets, nil
+}
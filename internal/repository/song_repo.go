@@ -3,6 +3,7 @@ package repository
 import (
 	"errors"
 	"log"
+	"strings"
 
 	"back_music/internal/database"
 	"back_music/internal/models"
@@ -18,7 +19,10 @@ type SongRepository interface {
     GetSongByID(id string) (*models.Song, error)
     GetSongBySpotifyID(spotifyID string) (*models.Song, error)
     GetAllSongs() ([]models.Song, error)
+    BrowseSongs(opts SongBrowseOptions) (*SongBrowsePage, error)
     GetSongsByIDs(ids []string) ([]models.Song, error)
+    GetSongsBySpotifyIDs(spotifyIDs []string) ([]models.Song, error)
+    FindSongsByTitlePrefix(title string, limit int) ([]models.Song, error)
     GetRandomSongs(limit int) ([]models.Song, error)
     SearchSongs(query string, limit int) ([]models.Song, error)
     GetSongsByGenre(genre string, limit int) ([]models.Song, error)
@@ -27,10 +31,15 @@ type SongRepository interface {
      IsSongLikedByUser(songID string, userID uint) (bool, error)
  GetAllSongsWithLikeStatus(userID uint) ([]models.Song, error)
   SearchSongsWithLikeStatus(query string, limit int, userID uint) ([]models.Song, error)
+    SuggestSearchTerm(query string) (string, error)
+    GetLikedSongIDs(userID uint, songIDs []string) (map[string]bool, error)
+    AddLikes(userID uint, songIDs []string) (int, error)
+    OnSongSaved(listener func(song models.Song))
 }
 
 type songRepo struct {
     db *gorm.DB
+    saveListeners []func(song models.Song)
 }
 
 func NewSongRepository() SongRepository {
@@ -81,7 +90,11 @@ func (r *songRepo) GetAllSongsWithLikeStatus(userID uint) ([]models.Song, error)
 // ================ METHOD LAINNYA DENGAN LOGGING ================
 
 func (r *songRepo) CreateSong(song *models.Song) error {
-    return r.db.Create(song).Error
+    if err := r.db.Create(song).Error; err != nil {
+        return err
+    }
+    r.notifySongSaved(song)
+    return nil
 }
 
 func (r *songRepo) GetSongByID(id string) (*models.Song, error) {
@@ -100,7 +113,13 @@ func (r *songRepo) GetSongByID(id string) (*models.Song, error) {
 func (r *songRepo) GetSongBySpotifyID(spotifyID string) (*models.Song, error) {
     var song models.Song
     err := r.db.First(&song, "spotify_id = ?", spotifyID).Error
-    return &song, err
+    if err != nil {
+        if errors.Is(err, gorm.ErrRecordNotFound) {
+            return nil, ErrSongNotFound
+        }
+        return nil, err
+    }
+    return &song, nil
 }
 
 func (r *songRepo) GetSongsByIDs(ids []string) ([]models.Song, error) {
@@ -109,21 +128,136 @@ func (r *songRepo) GetSongsByIDs(ids []string) ([]models.Song, error) {
     return songs, err
 }
 
+func (r *songRepo) GetSongsBySpotifyIDs(spotifyIDs []string) ([]models.Song, error) {
+    var songs []models.Song
+    if len(spotifyIDs) == 0 {
+        return songs, nil
+    }
+    err := r.db.Where("spotify_id IN ?", spotifyIDs).Find(&songs).Error
+    return songs, err
+}
+
+// FindSongsByTitlePrefix mencari kandidat lagu yang judulnya diawali title (case-insensitive),
+// jadi "Song" juga menemukan "Song - Remastered 2011". Pencocokan artist dilakukan pemanggil.
+func (r *songRepo) FindSongsByTitlePrefix(title string, limit int) ([]models.Song, error) {
+    var songs []models.Song
+    pattern := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(strings.TrimSpace(title)) + "%"
+    err := r.db.Where("title ILIKE ?", pattern).
+        Order("popularity DESC").
+        Limit(limit).
+        Find(&songs).Error
+    return songs, err
+}
+
 func (r *songRepo) GetRandomSongs(limit int) ([]models.Song, error) {
     var songs []models.Song
     err := r.db.Order("RANDOM()").Limit(limit).Find(&songs).Error
     return songs, err
 }
 
+// SearchSongs memakai full-text search (songs.search_vector), diurutkan berdasarkan ts_rank
+// lalu popularity. Jika hasilnya sedikit (typo, ejaan lain), sisanya diisi dari pencarian
+// trigram. Lihat buildSearchTSQuery untuk sintaks phrase/prefix.
 func (r *songRepo) SearchSongs(query string, limit int) ([]models.Song, error) {
+    songs := []models.Song{}
+    tsQuery := buildSearchTSQuery(query)
+    if tsQuery == "" {
+        return songs, nil
+    }
+
+    err := r.db.
+        Where("search_vector @@ to_tsquery('simple', immutable_unaccent(?))", tsQuery).
+        Order(gorm.Expr("ts_rank(search_vector, to_tsquery('simple', immutable_unaccent(?))) DESC, popularity DESC", tsQuery)).
+        Limit(limit).
+        Find(&songs).Error
+    if err != nil {
+        return nil, err
+    }
+
+    if len(songs) >= minFullTextResults || len(songs) >= limit {
+        return songs, nil
+    }
+
+    fuzzy, err := r.searchSongsByTrigram(query, songs, limit-len(songs))
+    if err != nil {
+        log.Printf("[SearchSongs] trigram fallback failed: %v", err)
+        return songs, nil
+    }
+    return append(songs, fuzzy...), nil
+}
+
+// searchSongsByTrigram mencari lagu yang title + artist-nya mirip query (word similarity),
+// tanpa mengulang lagu yang sudah ada di exclude.
+func (r *songRepo) searchSongsByTrigram(query string, exclude []models.Song, limit int) ([]models.Song, error) {
     var songs []models.Song
-    err := r.db.Where("title ILIKE ? OR artist ILIKE ? OR genre ILIKE ?", 
-        "%"+query+"%", "%"+query+"%", "%"+query+"%").
+    normalized := normalizeSearchQuery(query)
+    if normalized == "" || limit <= 0 {
+        return songs, nil
+    }
+
+    db := r.db.Where("immutable_unaccent(?) <% song_search_text(title, artist)", normalized)
+    if len(exclude) > 0 {
+        excludeIDs := make([]string, len(exclude))
+        for i, song := range exclude {
+            excludeIDs[i] = song.ID
+        }
+        db = db.Where("id NOT IN ?", excludeIDs)
+    }
+
+    err := db.
+        Order(gorm.Expr("word_similarity(immutable_unaccent(?), song_search_text(title, artist)) DESC, popularity DESC", normalized)).
         Limit(limit).
         Find(&songs).Error
     return songs, err
 }
 
+// SuggestSearchTerm mengembalikan nama artist atau judul lagu yang paling mirip query
+// ("did you mean"). Kosong jika query sudah cukup cocok via full-text search atau
+// tidak ada istilah yang mirip.
+func (r *songRepo) SuggestSearchTerm(query string) (string, error) {
+    normalized := normalizeSearchQuery(query)
+    tsQuery := buildSearchTSQuery(query)
+    if normalized == "" || tsQuery == "" {
+        return "", nil
+    }
+
+    var matches int64
+    err := r.db.Table("(?) AS m", r.db.Model(&models.Song{}).
+        Select("1").
+        Where("search_vector @@ to_tsquery('simple', immutable_unaccent(?))", tsQuery).
+        Limit(minFullTextResults)).
+        Count(&matches).Error
+    if err != nil {
+        return "", err
+    }
+    if matches >= minFullTextResults {
+        return "", nil
+    }
+
+    var terms []string
+    err = r.db.Raw(`
+        SELECT term FROM (
+            SELECT name AS term, similarity(immutable_unaccent(normalized_name), immutable_unaccent(?)) AS score
+            FROM artists
+            WHERE immutable_unaccent(normalized_name) % immutable_unaccent(?)
+            UNION ALL
+            SELECT title AS term, similarity(lower(immutable_unaccent(title)), immutable_unaccent(?)) AS score
+            FROM songs
+            WHERE lower(immutable_unaccent(title)) % immutable_unaccent(?)
+        ) vocabulary
+        ORDER BY score DESC
+        LIMIT 1`, normalized, normalized, normalized, normalized).
+        Pluck("term", &terms).Error
+    if err != nil || len(terms) == 0 {
+        return "", err
+    }
+
+    if normalizeSearchQuery(terms[0]) == normalized {
+        return "", nil
+    }
+    return terms[0], nil
+}
+
 func (r *songRepo) GetSongsByGenre(genre string, limit int) ([]models.Song, error) {
     var songs []models.Song
     err := r.db.Where("genre ILIKE ?", "%"+genre+"%").
@@ -148,7 +282,23 @@ func (r *songRepo) GetPopularSongs(limit int) ([]models.Song, error) {
 
 func (r *songRepo) UpdateSong(song *models.Song) error {
     log.Printf("[UpdateSong] Updating song: %s - %s", song.Title, song.Artist)
-    return r.db.Save(song).Error
+    if err := r.db.Save(song).Error; err != nil {
+        return err
+    }
+    r.notifySongSaved(song)
+    return nil
+}
+
+// OnSongSaved mendaftarkan listener yang dipanggil setelah lagu dibuat/diupdate
+// (mis. untuk memperbarui index autocomplete). Daftarkan saat startup saja.
+func (r *songRepo) OnSongSaved(listener func(song models.Song)) {
+    r.saveListeners = append(r.saveListeners, listener)
+}
+
+func (r *songRepo) notifySongSaved(song *models.Song) {
+    for _, listener := range r.saveListeners {
+        listener(*song)
+    }
 }
 
 func (r *songRepo) IsSongLikedByUser(songID string, userID uint) (bool, error) {
@@ -161,44 +311,69 @@ func (r *songRepo) IsSongLikedByUser(songID string, userID uint) (bool, error) {
 }
 
 func (r *songRepo) SearchSongsWithLikeStatus(query string, limit int, userID uint) ([]models.Song, error) {
-    var songs []models.Song
-    
-    // Search songs
-    if err := r.db.Where("title ILIKE ? OR artist ILIKE ?", 
-        "%"+query+"%", "%"+query+"%").Limit(limit).Find(&songs).Error; err != nil {
-        return nil, err
+    songs, err := r.SearchSongs(query, limit)
+    if err != nil || len(songs) == 0 {
+        return songs, err
     }
-    
-    if len(songs) == 0 {
-        return songs, nil
-    }
-    
-    // Get song IDs
+
     songIDs := make([]string, len(songs))
     for i, song := range songs {
         songIDs[i] = song.ID
     }
-    
-    // Get which of these songs are liked by user
-    var likedSongIDs []string
-    err := r.db.Model(&models.UserLike{}).
-        Where("user_id = ? AND song_id IN ?", userID, songIDs).
-        Pluck("song_id", &likedSongIDs).Error
-    
+
+    likedMap, err := r.GetLikedSongIDs(userID, songIDs)
     if err != nil {
         return songs, nil
     }
+    for i := range songs {
+        songs[i].IsLiked = likedMap[songs[i].ID]
+    }
+
+    return songs, nil
+}
+
+// AddLikes menambahkan like untuk songIDs yang belum di-like user; mengembalikan jumlah like baru.
+func (r *songRepo) AddLikes(userID uint, songIDs []string) (int, error) {
+    liked, err := r.GetLikedSongIDs(userID, songIDs)
+    if err != nil {
+        return 0, err
+    }
+    
+    likes := make([]models.UserLike, 0, len(songIDs))
+    seen := make(map[string]bool, len(songIDs))
+    for _, songID := range songIDs {
+        if liked[songID] || seen[songID] {
+            continue
+        }
+        seen[songID] = true
+        likes = append(likes, models.UserLike{UserID: userID, SongID: songID})
+    }
+    if len(likes) == 0 {
+        return 0, nil
+    }
     
-    // Create map for faster lookup
+    if err := r.db.CreateInBatches(&likes, 100).Error; err != nil {
+        return 0, err
+    }
+    return len(likes), nil
+}
+
+// GetLikedSongIDs mengembalikan set song ID (dari songIDs) yang di-like user.
+func (r *songRepo) GetLikedSongIDs(userID uint, songIDs []string) (map[string]bool, error) {
     likedMap := make(map[string]bool)
-    for _, id := range likedSongIDs {
-        likedMap[id] = true
+    if userID == 0 || len(songIDs) == 0 {
+        return likedMap, nil
     }
     
-    // Set IsLiked field
-    for i := range songs {
-        songs[i].IsLiked = likedMap[songs[i].ID]
+    var likedSongIDs []string
+    if err := r.db.Model(&models.UserLike{}).
+        Where("user_id = ? AND song_id IN ?", userID, songIDs).
+        Pluck("song_id", &likedSongIDs).Error; err != nil {
+        return likedMap, err
     }
     
-    return songs, nil
+    for _, id := range likedSongIDs {
+        likedMap[id] = true
+    }
+    return likedMap, nil
 }
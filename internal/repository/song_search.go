@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"strings"
+	"unicode"
+)
+
+// minFullTextResults adalah jumlah hasil full-text minimum sebelum fallback trigram
+// dan saran "did you mean" dipakai.
+const minFullTextResults = 5
+
+// buildSearchTSQuery mengubah input user menjadi tsquery untuk songs.search_vector.
+// Teks dalam tanda kutip menjadi phrase query ("dewa 19" -> dewa <-> 19),
+// kata lain menjadi prefix query (dew -> dew:*), semuanya digabung dengan AND.
+// Karakter selain huruf/angka dibuang agar operator tsquery dari user tidak ikut terbaca.
+// Mengembalikan "" jika tidak ada kata yang bisa dicari.
+func buildSearchTSQuery(query string) string {
+	var clauses []string
+
+	for i, part := range strings.Split(query, `"`) {
+		words := searchTokens(part)
+		if len(words) == 0 {
+			continue
+		}
+
+		// Bagian ganjil berada di dalam tanda kutip
+		if i%2 == 1 {
+			if len(words) == 1 {
+				clauses = append(clauses, words[0])
+			} else {
+				clauses = append(clauses, "("+strings.Join(words, " <-> ")+")")
+			}
+			continue
+		}
+
+		for _, word := range words {
+			clauses = append(clauses, word+":*")
+		}
+	}
+
+	return strings.Join(clauses, " & ")
+}
+
+// searchTokens memecah teks menjadi kata huruf kecil yang hanya berisi huruf/angka.
+func searchTokens(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// normalizeSearchQuery menyamakan query untuk perbandingan trigram ("D'Masiv!" -> "d masiv").
+func normalizeSearchQuery(query string) string {
+	return strings.Join(searchTokens(query), " ")
+}
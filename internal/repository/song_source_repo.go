@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"errors"
+
+	"back_music/internal/database"
+	"back_music/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrSongSourceNotFound = errors.New("song source not found")
+
+type SongSourceRepository interface {
+	ListBySong(songID string) ([]models.SongSource, error)
+	GetByID(songID string, id uint) (*models.SongSource, error)
+	// Upsert menyimpan sumber berdasarkan (song_id, provider, ref); metadata diperbarui,
+	// status verifikasi yang sudah ada dipertahankan.
+	Upsert(source *models.SongSource) error
+	Update(source *models.SongSource) error
+	Delete(songID string, id uint) error
+	// FindByChecksum mencari sumber upload dengan isi file yang sama (untuk dedup).
+	FindByChecksum(checksum string) ([]models.SongSource, error)
+	// CountByRef menghitung sumber yang masih memakai object yang sama.
+	CountByRef(provider, ref string) (int64, error)
+}
+
+type songSourceRepo struct {
+	db *gorm.DB
+}
+
+func NewSongSourceRepository() SongSourceRepository {
+	return &songSourceRepo{db: database.DB}
+}
+
+func (r *songSourceRepo) ListBySong(songID string) ([]models.SongSource, error) {
+	var sources []models.SongSource
+	err := r.db.Where("song_id = ?", songID).Order("id").Find(&sources).Error
+	return sources, err
+}
+
+func (r *songSourceRepo) GetByID(songID string, id uint) (*models.SongSource, error) {
+	var source models.SongSource
+	err := r.db.Where("song_id = ? AND id = ?", songID, id).First(&source).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSongSourceNotFound
+		}
+		return nil, err
+	}
+	return &source, nil
+}
+
+func (r *songSourceRepo) Upsert(source *models.SongSource) error {
+	if source.Status == "" {
+		source.Status = models.SourceStatusUnverified
+	}
+	if source.Quality == "" {
+		source.Quality = models.SourceQualityFull
+	}
+	err := r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "song_id"}, {Name: "provider"}, {Name: "ref"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"quality", "bitrate_kbps", "duration_ms", "mime_type", "updated_at",
+		}),
+	}).Create(source).Error
+	if err != nil {
+		return err
+	}
+	// Ambil ulang supaya ID dan status verifikasi baris lama ikut terisi
+	return r.db.Where("song_id = ? AND provider = ? AND ref = ?", source.SongID, source.Provider, source.Ref).
+		First(source).Error
+}
+
+func (r *songSourceRepo) Update(source *models.SongSource) error {
+	return r.db.Save(source).Error
+}
+
+func (r *songSourceRepo) Delete(songID string, id uint) error {
+	result := r.db.Where("song_id = ? AND id = ?", songID, id).Delete(&models.SongSource{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrSongSourceNotFound
+	}
+	return nil
+}
+
+func (r *songSourceRepo) FindByChecksum(checksum string) ([]models.SongSource, error) {
+	var sources []models.SongSource
+	err := r.db.Where("checksum = ?", checksum).Order("id").Find(&sources).Error
+	return sources, err
+}
+
+func (r *songSourceRepo) CountByRef(provider, ref string) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.SongSource{}).Where("provider = ? AND ref = ?", provider, ref).Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"back_music/internal/database"
+	"back_music/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrSpotifyAccountNotFound   = errors.New("spotify account not linked")
+	ErrSpotifyAuthStateNotFound = errors.New("spotify auth state not found or expired")
+)
+
+type SpotifyAccountRepository interface {
+	GetByUserID(userID uint) (*models.SpotifyAccount, error)
+	// Upsert menyimpan akun berdasarkan user_id (link ulang menimpa token lama).
+	Upsert(account *models.SpotifyAccount) error
+	// UpdateRefreshToken hanya menimpa token jika yang tersimpan masih previous, supaya job
+	// yang berjalan tidak menghidupkan lagi akun yang sudah di-unlink atau menimpa link baru.
+	UpdateRefreshToken(userID uint, previous, encrypted string) error
+	MarkImported(userID uint, at time.Time) error
+	Delete(userID uint) error
+
+	SaveAuthState(state *models.SpotifyAuthState) error
+	// ConsumeAuthState mengambil dan menghapus state (sekali pakai); state kedaluwarsa dianggap tidak ada.
+	ConsumeAuthState(state string) (*models.SpotifyAuthState, error)
+}
+
+type spotifyAccountRepo struct {
+	db *gorm.DB
+}
+
+func NewSpotifyAccountRepository() SpotifyAccountRepository {
+	return &spotifyAccountRepo{db: database.DB}
+}
+
+func (r *spotifyAccountRepo) GetByUserID(userID uint) (*models.SpotifyAccount, error) {
+	var account models.SpotifyAccount
+	err := r.db.Where("user_id = ?", userID).First(&account).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSpotifyAccountNotFound
+		}
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (r *spotifyAccountRepo) Upsert(account *models.SpotifyAccount) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"spotify_user_id", "display_name", "scopes", "refresh_token_encrypted", "linked_at", "updated_at",
+		}),
+	}).Create(account).Error
+}
+
+func (r *spotifyAccountRepo) UpdateRefreshToken(userID uint, previous, encrypted string) error {
+	result := r.db.Model(&models.SpotifyAccount{}).
+		Where("user_id = ? AND refresh_token_encrypted = ?", userID, previous).
+		Update("refresh_token_encrypted", encrypted)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrSpotifyAccountNotFound
+	}
+	return nil
+}
+
+func (r *spotifyAccountRepo) MarkImported(userID uint, at time.Time) error {
+	result := r.db.Model(&models.SpotifyAccount{}).
+		Where("user_id = ?", userID).
+		Update("last_import_at", at)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrSpotifyAccountNotFound
+	}
+	return nil
+}
+
+func (r *spotifyAccountRepo) Delete(userID uint) error {
+	result := r.db.Where("user_id = ?", userID).Delete(&models.SpotifyAccount{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrSpotifyAccountNotFound
+	}
+	return nil
+}
+
+func (r *spotifyAccountRepo) SaveAuthState(state *models.SpotifyAuthState) error {
+	// Sekalian bersihkan state lama yang tidak pernah kembali dari halaman consent
+	r.db.Where("expires_at < ?", time.Now()).Delete(&models.SpotifyAuthState{})
+	return r.db.Create(state).Error
+}
+
+func (r *spotifyAccountRepo) ConsumeAuthState(state string) (*models.SpotifyAuthState, error) {
+	var authState models.SpotifyAuthState
+	result := r.db.Clauses(clause.Returning{}).
+		Where("state = ?", state).
+		Delete(&authState)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 || time.Now().After(authState.ExpiresAt) {
+		return nil, ErrSpotifyAuthStateNotFound
+	}
+	return &authState, nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"time"
 
@@ -13,12 +14,21 @@ import (
 
 var ErrUserNotFound = errors.New("user not found")
 
+// UserAffinity adalah bobot ketertarikan user per artist ID dan per genre,
+// dihitung dari like (bobot 3) dan jumlah play.
+type UserAffinity struct {
+	Artists map[string]float64
+	Genres  map[string]float64
+}
+
 type UserRepository interface {
 	CreateUser(user *models.User) error
 	FindUserByEmail(email string) (*models.User, error)
 	FindUserByID(id uint) (*models.User, error)
 	HashPassword(password string) (string, error)
 	VerifyPassword(hashedPassword, password string) error
+	GetActiveUserIDs(since time.Time) ([]uint, error)
+	GetUserAffinity(userID uint) (*UserAffinity, error)
 }
 
 type userRepo struct {
@@ -81,3 +91,67 @@ func (r *userRepo) HashPassword(password string) (string, error) {
 func (r *userRepo) VerifyPassword(hashedPassword, password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 }
+
+// GetActiveUserIDs mengembalikan user yang punya play atau like sejak waktu tertentu.
+func (r *userRepo) GetActiveUserIDs(since time.Time) ([]uint, error) {
+	var ids []uint
+	err := r.db.Raw(`
+		SELECT user_id FROM user_plays WHERE last_played >= ?
+		UNION
+		SELECT user_id FROM user_likes WHERE created_at >= ?
+		ORDER BY user_id`, since, since).
+		Scan(&ids).Error
+	return ids, err
+}
+
+// userSongWeightsSQL adalah bobot tiap lagu untuk seorang user: like = 3, play = play_count.
+const userSongWeightsSQL = `
+	SELECT song_id, SUM(weight) AS weight FROM (
+		SELECT song_id, 3.0 AS weight FROM user_likes WHERE user_id = @user
+		UNION ALL
+		SELECT song_id, play_count::float AS weight FROM user_plays WHERE user_id = @user
+	) w GROUP BY song_id`
+
+func (r *userRepo) GetUserAffinity(userID uint) (*UserAffinity, error) {
+	affinity := &UserAffinity{
+		Artists: make(map[string]float64),
+		Genres:  make(map[string]float64),
+	}
+
+	var artistRows []struct {
+		ArtistID string
+		Weight   float64
+	}
+	err := r.db.Raw(`
+		SELECT sa.artist_id, SUM(w.weight) AS weight
+		FROM (`+userSongWeightsSQL+`) w
+		JOIN song_artists sa ON sa.song_id::text = w.song_id
+		GROUP BY sa.artist_id`, sql.Named("user", userID)).
+		Scan(&artistRows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range artistRows {
+		affinity.Artists[row.ArtistID] = row.Weight
+	}
+
+	var genreRows []struct {
+		Genre  string
+		Weight float64
+	}
+	err = r.db.Raw(`
+		SELECT s.genre, SUM(w.weight) AS weight
+		FROM (`+userSongWeightsSQL+`) w
+		JOIN songs s ON s.id::text = w.song_id
+		WHERE s.genre IS NOT NULL AND s.genre <> ''
+		GROUP BY s.genre`, sql.Named("user", userID)).
+		Scan(&genreRows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range genreRows {
+		affinity.Genres[row.Genre] = row.Weight
+	}
+
+	return affinity, nil
+}
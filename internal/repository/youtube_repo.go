@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"back_music/internal/database"
+	"back_music/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrYoutubeMissNotFound = errors.New("youtube miss not found")
+
+type YoutubeRepository interface {
+	GetQuotaUsage(day string) (*models.YoutubeQuotaUsage, error)
+	ListQuotaUsage(limit int) ([]models.YoutubeQuotaUsage, error)
+	// AddQuotaUsage menambah pemakaian hari itu secara atomik.
+	AddQuotaUsage(day string, units, searchCalls, videoCalls int) error
+	MarkQuotaExhausted(day string) error
+
+	GetMiss(songID string) (*models.YoutubeMiss, error)
+	// RecordMiss menyimpan/menambah negative cache lagu.
+	RecordMiss(songID, reason, lastError string, retryAt time.Time) error
+	ClearMiss(songID string) error
+	CountActiveMisses(now time.Time) (int64, error)
+
+	// SongsNeedingLookup: lagu tanpa YoutubeID, tidak sedang di negative cache, dan belum
+	// punya sumber audio full yang bisa diputar; lagu populer lebih dulu.
+	SongsNeedingLookup(now time.Time, limit int) ([]models.Song, error)
+	CountSongsWithoutYoutubeID() (int64, error)
+}
+
+type youtubeRepo struct {
+	db *gorm.DB
+}
+
+func NewYoutubeRepository() YoutubeRepository {
+	return &youtubeRepo{db: database.DB}
+}
+
+func (r *youtubeRepo) GetQuotaUsage(day string) (*models.YoutubeQuotaUsage, error) {
+	var usage models.YoutubeQuotaUsage
+	err := r.db.Where("day = ?", day).First(&usage).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &models.YoutubeQuotaUsage{Day: day}, nil
+		}
+		return nil, err
+	}
+	return &usage, nil
+}
+
+func (r *youtubeRepo) ListQuotaUsage(limit int) ([]models.YoutubeQuotaUsage, error) {
+	var usages []models.YoutubeQuotaUsage
+	err := r.db.Order("day DESC").Limit(limit).Find(&usages).Error
+	return usages, err
+}
+
+func (r *youtubeRepo) AddQuotaUsage(day string, units, searchCalls, videoCalls int) error {
+	usage := models.YoutubeQuotaUsage{
+		Day:         day,
+		Units:       units,
+		SearchCalls: searchCalls,
+		VideoCalls:  videoCalls,
+		UpdatedAt:   time.Now(),
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "day"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"units":        gorm.Expr("youtube_quota_usages.units + ?", units),
+			"search_calls": gorm.Expr("youtube_quota_usages.search_calls + ?", searchCalls),
+			"video_calls":  gorm.Expr("youtube_quota_usages.video_calls + ?", videoCalls),
+			"updated_at":   usage.UpdatedAt,
+		}),
+	}).Create(&usage).Error
+}
+
+func (r *youtubeRepo) MarkQuotaExhausted(day string) error {
+	usage := models.YoutubeQuotaUsage{Day: day, Exhausted: true, UpdatedAt: time.Now()}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "day"}},
+		DoUpdates: clause.AssignmentColumns([]string{"exhausted", "updated_at"}),
+	}).Create(&usage).Error
+}
+
+func (r *youtubeRepo) GetMiss(songID string) (*models.YoutubeMiss, error) {
+	var miss models.YoutubeMiss
+	err := r.db.Where("song_id = ?", songID).First(&miss).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrYoutubeMissNotFound
+		}
+		return nil, err
+	}
+	return &miss, nil
+}
+
+func (r *youtubeRepo) RecordMiss(songID, reason, lastError string, retryAt time.Time) error {
+	miss := models.YoutubeMiss{
+		SongID:    songID,
+		Reason:    reason,
+		Misses:    1,
+		LastError: lastError,
+		RetryAt:   retryAt,
+		UpdatedAt: time.Now(),
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "song_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"reason":     reason,
+			"misses":     gorm.Expr("youtube_misses.misses + 1"),
+			"last_error": lastError,
+			"retry_at":   retryAt,
+			"updated_at": miss.UpdatedAt,
+		}),
+	}).Create(&miss).Error
+}
+
+func (r *youtubeRepo) ClearMiss(songID string) error {
+	return r.db.Where("song_id = ?", songID).Delete(&models.YoutubeMiss{}).Error
+}
+
+func (r *youtubeRepo) CountActiveMisses(now time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.YoutubeMiss{}).Where("retry_at > ?", now).Count(&count).Error
+	return count, err
+}
+
+func (r *youtubeRepo) SongsNeedingLookup(now time.Time, limit int) ([]models.Song, error) {
+	var songs []models.Song
+	err := r.db.
+		Where("COALESCE(songs.youtube_id, '') = ''").
+		Where("NOT EXISTS (SELECT 1 FROM youtube_misses m WHERE m.song_id = songs.id AND m.retry_at > ?)", now).
+		Where(`NOT EXISTS (SELECT 1 FROM song_sources ss WHERE ss.song_id = songs.id
+			AND ss.quality = ? AND ss.status <> ?)`, models.SourceQualityFull, models.SourceStatusBroken).
+		Order("songs.popularity DESC").
+		Limit(limit).
+		Find(&songs).Error
+	return songs, err
+}
+
+func (r *youtubeRepo) CountSongsWithoutYoutubeID() (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Song{}).Where("COALESCE(youtube_id, '') = ''").Count(&count).Error
+	return count, err
+}
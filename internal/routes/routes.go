@@ -16,6 +16,15 @@ func SetupRoutes(
 	authHandler *handlers.AuthHandler,
 	songHandler *handlers.SongHandler,
 	recommendationHandler *handlers.RecommendationHandler,
+	playlistHandler *handlers.PlaylistHandler,
+	chartHandler *handlers.ChartHandler,
+	artistHandler *handlers.ArtistHandler,
+	albumHandler *handlers.AlbumHandler,
+	searchHandler *handlers.SearchHandler,
+	adminHandler *handlers.AdminHandler,
+	spotifyHandler *handlers.SpotifyHandler,
+	historyHandler *handlers.HistoryHandler,
+	scrobbleHandler *handlers.ScrobbleHandler,
 	userRepo repository.UserRepository,
 ) *gin.Engine {
 
@@ -35,9 +44,9 @@ func SetupRoutes(
 
 	corsConfig := cors.Config{
         // JANGAN set AllowAllOrigins: true jika ingin pakai AllowCredentials
-        AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-        AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With"},
-        ExposeHeaders:    []string{"Content-Length"},
+        AllowMethods:     []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+        AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With", "Range", "If-Range"},
+        ExposeHeaders:    []string{"Content-Length", "Content-Range", "Accept-Ranges", "ETag"},
         AllowCredentials: true,
         MaxAge:           12 * time.Hour,
     }
@@ -85,12 +94,47 @@ func SetupRoutes(
 			songs.GET("", songHandler.GetAllSongs)
 			songs.GET("/search", songHandler.SearchSongs)
 			songs.GET("/popular-id", songHandler.GetPopularIndonesianSongs)
-			songs.POST("/seed", songHandler.SeedSongs)
 			songs.GET("/:id", songHandler.GetSongByID)
 			songs.GET("/:id/audio", songHandler.GetAudioSource)
 			songs.GET("/:id/source", songHandler.GetAudioSource)
+			songs.GET("/:id/stream", songHandler.StreamAudio)
+			songs.HEAD("/:id/stream", songHandler.StreamAudio)
 		}
 
+		// ---------- ARTISTS (optional JWT for like status) ----------
+		artists := api.Group("/artists")
+		artists.Use(middleware.OptionalJWTMiddleware())
+		{
+			artists.GET("", artistHandler.SearchArtists)
+			artists.GET("/:id", artistHandler.GetArtistByID)
+			artists.GET("/:id/similar", artistHandler.GetSimilarArtists)
+		}
+
+		// ---------- SEARCH (optional JWT for personal ranking) ----------
+		search := api.Group("/search")
+		search.Use(middleware.OptionalJWTMiddleware())
+		{
+			search.GET("/suggest", searchHandler.Suggest)
+		}
+
+		// ---------- ALBUMS (optional JWT for like status) ----------
+		albums := api.Group("/albums")
+		albums.Use(middleware.OptionalJWTMiddleware())
+		{
+			albums.GET("/:id", albumHandler.GetAlbumByID)
+		}
+
+		// ---------- CHARTS (optional JWT for like status) ----------
+		charts := api.Group("/charts")
+		charts.Use(middleware.OptionalJWTMiddleware())
+		{
+			charts.GET("", chartHandler.GetChart)
+			charts.GET("/genres", chartHandler.GetChartGenres)
+		}
+
+		// ---------- SPOTIFY OAUTH CALLBACK (user dikenali dari state) ----------
+		api.GET("/spotify/callback", spotifyHandler.Callback)
+
 		// ---------- PROTECTED ----------
 		protected := api.Group("/")
 		protected.Use(middleware.JWTMiddleware())
@@ -103,6 +147,36 @@ func SetupRoutes(
 				user.POST("/play/:song_id", songHandler.PlaySong)
 				user.GET("/likes", songHandler.GetUserLikes)
 				user.GET("/plays", songHandler.GetUserPlays)
+				user.POST("/history/import", historyHandler.ImportHistory)
+				user.GET("/history/imports/:id", historyHandler.GetImport)
+			}
+
+			// PLAYLISTS
+			playlists := protected.Group("/playlists")
+			{
+				playlists.GET("/discover", playlistHandler.GetDiscoverPlaylist)
+				playlists.GET("/discover/history", playlistHandler.GetDiscoverHistory)
+				playlists.GET("/:id", playlistHandler.GetPlaylistByID)
+			}
+
+			// SPOTIFY ACCOUNT LINK
+			spotify := protected.Group("/spotify")
+			{
+				spotify.POST("/link", spotifyHandler.StartLink)
+				spotify.GET("/account", spotifyHandler.GetAccount)
+				spotify.DELETE("/account", spotifyHandler.Unlink)
+				spotify.POST("/import", spotifyHandler.StartImport)
+				spotify.GET("/imports/:id", spotifyHandler.GetImport)
+			}
+
+			// SCROBBLING (ListenBrainz-compatible)
+			scrobble := protected.Group("/scrobble")
+			{
+				scrobble.PUT("/account", scrobbleHandler.Connect)
+				scrobble.GET("/account", scrobbleHandler.GetAccount)
+				scrobble.DELETE("/account", scrobbleHandler.Disconnect)
+				scrobble.POST("/now-playing/:song_id", scrobbleHandler.NowPlaying)
+				scrobble.POST("/queue/retry", scrobbleHandler.RetryFailed)
 			}
 
 			// RECOMMENDATIONS
@@ -115,12 +189,37 @@ func SetupRoutes(
 				recommendations.GET("/popular", recommendationHandler.GetPopularSongs)
 			}
 
-			// ADMIN (OPTIONAL)
-			// admin := protected.Group("/admin")
-			// admin.Use(middleware.AdminMiddleware(userRepo))
-			// {
-			// 	admin.POST("/songs/:song_id/upload", songHandler.UploadCustomMP3)
-			// }
+			// ADMIN
+			admin := protected.Group("/admin")
+			admin.Use(middleware.AdminMiddleware(userRepo))
+			{
+				// admin.POST("/songs/:song_id/upload", songHandler.UploadCustomMP3)
+				admin.POST("/songs", adminHandler.CreateSong)
+				admin.POST("/songs/bulk-update", adminHandler.BulkUpdateSongs)
+				admin.PATCH("/songs/:id", adminHandler.UpdateSong)
+				admin.DELETE("/songs/:id", adminHandler.DeleteSong)
+				admin.POST("/songs/:id/merge", adminHandler.MergeSongs)
+				admin.GET("/songs/:id/stats", adminHandler.GetSongStats)
+				admin.GET("/songs/:id/sources", adminHandler.ListSongSources)
+				admin.POST("/songs/:id/sources", adminHandler.AddSongSource)
+				admin.POST("/songs/:id/sources/upload", adminHandler.UploadSongAudio)
+				admin.POST("/songs/:id/sources/verify", adminHandler.VerifySongSources)
+				admin.DELETE("/songs/:id/sources/:source_id", adminHandler.DeleteSongSource)
+				admin.GET("/audit-logs", adminHandler.GetAuditLogs)
+				admin.POST("/imports", adminHandler.ImportCatalog)
+				admin.GET("/imports/:id", adminHandler.GetJob)
+				admin.POST("/seed", adminHandler.SeedSongs)
+				admin.GET("/seed/profiles", adminHandler.GetSeedProfiles)
+				admin.GET("/spotify/token-stats", adminHandler.GetSpotifyTokenStats)
+				admin.GET("/youtube/quota", adminHandler.GetYouTubeQuota)
+				admin.GET("/jobs", adminHandler.ListJobs)
+				admin.GET("/jobs/:id", adminHandler.GetJob)
+				admin.POST("/jobs/:id/cancel", adminHandler.CancelJob)
+				admin.GET("/duplicates", adminHandler.ListDuplicates)
+				admin.POST("/duplicates/scan", adminHandler.ScanDuplicates)
+				admin.POST("/duplicates/:id/merge", adminHandler.MergeDuplicate)
+				admin.POST("/duplicates/:id/dismiss", adminHandler.DismissDuplicate)
+			}
 		}
 	}
 
@@ -0,0 +1,272 @@
+package services
+
+import (
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"back_music/internal/models"
+	"back_music/internal/repository"
+)
+
+const (
+	similarArtistListenerWeight = 0.6
+	similarArtistFeatureWeight  = 0.4
+	similarArtistCacheTTL       = 24 * time.Hour
+	similarArtistMaxResults     = 20
+)
+
+// ArtistDetail adalah isi halaman artist.
+type ArtistDetail struct {
+	Artist     models.Artist            `json:"artist"`
+	TopSongs   []models.Song            `json:"top_songs"`
+	Albums     []repository.ArtistAlbum `json:"albums"`
+	Listeners  int                      `json:"listeners"`
+	TotalPlays int                      `json:"total_plays"`
+}
+
+type ArtistService interface {
+	GetArtistDetail(artistID string, userID uint) (*ArtistDetail, error)
+	GetSimilarArtists(artistID string, limit int) ([]models.ArtistSimilarity, error)
+	SearchArtists(query string, limit int) ([]models.Artist, error)
+	BackfillSongArtists() (int, error)
+}
+
+type artistService struct {
+	artistRepo   repository.ArtistRepository
+	songRepo     repository.SongRepository
+	featureStats FeatureStatsService
+}
+
+func NewArtistService(artistRepo repository.ArtistRepository, songRepo repository.SongRepository, featureStats FeatureStatsService) ArtistService {
+	return &artistService{
+		artistRepo:   artistRepo,
+		songRepo:     songRepo,
+		featureStats: featureStats,
+	}
+}
+
+// SplitArtistNames memecah string Song.Artist ("A, B") yang dibuat SearchTracks.
+func SplitArtistNames(artist string) []string {
+	names := make([]string, 0, 2)
+	for _, name := range strings.Split(artist, ", ") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (s *artistService) GetArtistDetail(artistID string, userID uint) (*ArtistDetail, error) {
+	artist, err := s.artistRepo.GetArtistByID(artistID)
+	if err != nil {
+		return nil, err
+	}
+
+	topSongs, err := s.artistRepo.GetArtistSongs(artistID, 10)
+	if err != nil {
+		return nil, err
+	}
+	if userID > 0 && len(topSongs) > 0 {
+		ids := make([]string, len(topSongs))
+		for i, song := range topSongs {
+			ids[i] = song.ID
+		}
+		likedMap, _ := s.songRepo.GetLikedSongIDs(userID, ids)
+		for i := range topSongs {
+			topSongs[i].IsLiked = likedMap[topSongs[i].ID]
+		}
+	}
+
+	albums, err := s.artistRepo.GetArtistAlbums(artistID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := s.artistRepo.GetArtistListenerStats(artistID)
+	if err != nil {
+		return nil, err
+	}
+
+	if topSongs == nil {
+		topSongs = []models.Song{}
+	}
+	if albums == nil {
+		albums = []repository.ArtistAlbum{}
+	}
+
+	return &ArtistDetail{
+		Artist:     *artist,
+		TopSongs:   topSongs,
+		Albums:     albums,
+		Listeners:  stats.Listeners,
+		TotalPlays: stats.TotalPlays,
+	}, nil
+}
+
+func (s *artistService) SearchArtists(query string, limit int) ([]models.Artist, error) {
+	return s.artistRepo.SearchArtists(query, limit)
+}
+
+// GetSimilarArtists memakai cache di tabel artist_similarities, dihitung ulang
+// setelah similarArtistCacheTTL.
+func (s *artistService) GetSimilarArtists(artistID string, limit int) ([]models.ArtistSimilarity, error) {
+	if _, err := s.artistRepo.GetArtistByID(artistID); err != nil {
+		return nil, err
+	}
+
+	cached, err := s.artistRepo.GetSimilarArtists(artistID, limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(cached) > 0 && time.Since(cached[0].ComputedAt) < similarArtistCacheTTL {
+		return cached, nil
+	}
+
+	if err := s.computeSimilarArtists(artistID); err != nil {
+		return nil, err
+	}
+	return s.artistRepo.GetSimilarArtists(artistID, limit)
+}
+
+// computeSimilarArtists menggabungkan dua sinyal:
+//   - pendengar bersama (Jaccard atas user yang play/like lagu kedua artist)
+//   - kemiripan centroid fitur audio (dinormalisasi dengan statistik katalog)
+func (s *artistService) computeSimilarArtists(artistID string) error {
+	listenerCounts, err := s.artistRepo.GetListenerCounts()
+	if err != nil {
+		return err
+	}
+	overlaps, err := s.artistRepo.GetListenerOverlaps(artistID, 100)
+	if err != nil {
+		return err
+	}
+	centroids, err := s.artistRepo.GetFeatureCentroids()
+	if err != nil {
+		return err
+	}
+
+	vectors := make(map[string][]float64, len(centroids))
+	for _, centroid := range centroids {
+		vectors[centroid.ArtistID] = s.featureStats.NormalizeSong(centroidSong(centroid))
+	}
+
+	target, hasTarget := vectors[artistID]
+	targetListeners := listenerCounts[artistID]
+
+	shared := make(map[string]int, len(overlaps))
+	for _, overlap := range overlaps {
+		shared[overlap.ArtistID] = overlap.SharedListeners
+	}
+
+	similarities := make([]models.ArtistSimilarity, 0, len(vectors))
+	for otherID, vector := range vectors {
+		if otherID == artistID {
+			continue
+		}
+
+		listenerScore := 0.0
+		if common := shared[otherID]; common > 0 {
+			union := targetListeners + listenerCounts[otherID] - common
+			if union > 0 {
+				listenerScore = float64(common) / float64(union)
+			}
+		}
+
+		featureScore := 0.0
+		if hasTarget {
+			featureScore = cosineSimilarity(target, vector)
+		}
+
+		score := listenerScore*similarArtistListenerWeight + featureScore*similarArtistFeatureWeight
+		similarities = append(similarities, models.ArtistSimilarity{
+			SimilarArtistID:   otherID,
+			Score:             math.Round(score*1000) / 1000,
+			SharedListeners:   shared[otherID],
+			ListenerScore:     math.Round(listenerScore*1000) / 1000,
+			FeatureSimilarity: math.Round(featureScore*1000) / 1000,
+		})
+	}
+
+	sort.Slice(similarities, func(i, j int) bool {
+		return similarities[i].Score > similarities[j].Score
+	})
+	if len(similarities) > similarArtistMaxResults {
+		similarities = similarities[:similarArtistMaxResults]
+	}
+
+	return s.artistRepo.SaveSimilarArtists(artistID, similarities)
+}
+
+// BackfillSongArtists mengisi song_artists untuk lagu lama dengan memecah Song.Artist.
+// Dibaca per halaman dengan cursor ID, jadi lagu yang tidak bisa di-link tidak menghalangi
+// sisa katalog.
+func (s *artistService) BackfillSongArtists() (int, error) {
+	linked := 0
+	afterID := ""
+	for {
+		songs, err := s.artistRepo.GetSongsWithoutArtists(afterID, 200)
+		if err != nil {
+			return linked, err
+		}
+		if len(songs) == 0 {
+			break
+		}
+		afterID = songs[len(songs)-1].ID
+
+		for _, song := range songs {
+			names := SplitArtistNames(song.Artist)
+			credits := make([]models.ArtistCredit, len(names))
+			for i, name := range names {
+				credits[i] = models.ArtistCredit{Name: name}
+			}
+			if len(credits) == 0 {
+				continue
+			}
+			if err := s.artistRepo.LinkSongArtists(song.ID, credits); err != nil {
+				log.Printf("⚠️ [Artist backfill] %s - %s: %v", song.Artist, song.Title, err)
+				continue
+			}
+			linked++
+		}
+	}
+
+	if linked > 0 {
+		log.Printf("🎤 [Artist backfill] Linked %d songs to artists", linked)
+	}
+	return linked, nil
+}
+
+func centroidSong(c repository.ArtistFeatureCentroid) *models.Song {
+	return &models.Song{
+		Danceability:     c.Danceability,
+		Energy:           c.Energy,
+		Key:              int(math.Round(c.Key)),
+		Loudness:         c.Loudness,
+		Mode:             int(math.Round(c.Mode)),
+		Speechiness:      c.Speechiness,
+		Acousticness:     c.Acousticness,
+		Instrumentalness: c.Instrumentalness,
+		Liveness:         c.Liveness,
+		Valence:          c.Valence,
+		Tempo:            c.Tempo,
+		TimeSignature:    int(math.Round(c.TimeSignature)),
+		Popularity:       int(math.Round(c.Popularity)),
+	}
+}
+
+// cosineSimilarity untuk vector yang sudah di-center, dipetakan ke 0..1.
+func cosineSimilarity(a, b []float64) float64 {
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return (dot/(math.Sqrt(normA)*math.Sqrt(normB)) + 1) / 2
+}
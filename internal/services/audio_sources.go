@@ -0,0 +1,676 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"back_music/internal/models"
+	"back_music/internal/repository"
+	"back_music/internal/storage"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	audioVerifyTimeout = 10 * time.Second
+	// Pencarian sumber berjalan di request publik: dibatasi jumlah dan waktunya.
+	audioDiscoverySlots   = 4
+	audioDiscoveryTimeout = 20 * time.Second
+	// Verifikasi di background lewat antrian terbatas; yang tidak muat dicoba lagi
+	// pada request berikutnya (sumber tetap unverified).
+	audioVerifyWorkers   = 2
+	audioVerifyQueueSize = 256
+	// spotifyPreviewDurationMs: preview Spotify selalu potongan 30 detik.
+	spotifyPreviewDurationMs = 30 * 1000
+)
+
+var (
+	ErrNoAudioSource    = errors.New("no playable audio source found")
+	ErrInvalidSourceURL = errors.New("source url must be an absolute http(s) url")
+	ErrSourceNotLocal   = errors.New("source is not a local file")
+)
+
+// AudioSourceResolver adalah satu provider sumber audio dalam chain.
+type AudioSourceResolver interface {
+	Provider() string
+	// Resolve mencari sumber baru untuk lagu. found berisi sumber yang sudah diketahui
+	// (urut ranking), sehingga resolver mahal bisa dilewati jika sudah ada sumber full.
+	Resolve(ctx context.Context, song *models.Song, found []models.SongSource) ([]models.SongSource, error)
+	// CanDiscover: Resolve mungkin menemukan sumber baru (tanpa I/O). Resolver yang
+	// sumbernya hanya didaftarkan admin selalu false.
+	CanDiscover(song *models.Song, found []models.SongSource) bool
+	// Verify memeriksa apakah sumber masih bisa diputar; error berarti broken.
+	Verify(ctx context.Context, source *models.SongSource) error
+	// PlaybackURL adalah URL yang diberikan ke client untuk sumber ini.
+	PlaybackURL(source *models.SongSource) string
+}
+
+type AudioSourceService interface {
+	// GetSources mengembalikan sumber yang bisa diputar (tanpa yang broken), urut dari terbaik.
+	GetSources(ctx context.Context, song *models.Song) ([]models.SongSource, error)
+	// ListSources mengembalikan semua sumber termasuk yang broken (admin).
+	ListSources(songID string) ([]models.SongSource, error)
+	AddExternalSource(songID, rawURL string, durationMs, bitrateKbps int) (*models.SongSource, error)
+	RemoveSource(songID string, id uint) error
+	VerifySources(ctx context.Context, songID string) ([]models.SongSource, error)
+	// OpenStream membuka file upload milik sumber "local" dari BlobStore. id 0 berarti
+	// sumber local terbaik milik lagu.
+	OpenStream(ctx context.Context, songID string, id uint) (*AudioStream, error)
+	// VerifyStreamURL memeriksa tanda tangan URL stream dan mengembalikan waktu kedaluwarsanya.
+	VerifyStreamURL(songID string, id uint, expires, sig string) (time.Time, error)
+	// StartVerifier menjalankan worker verifikasi sumber baru sampai ctx selesai.
+	StartVerifier(ctx context.Context)
+}
+
+// AudioStream adalah file audio tersimpan yang siap disajikan dengan Range request.
+type AudioStream struct {
+	Body   *storage.ObjectReader
+	Info   *storage.ObjectInfo
+	Source *models.SongSource
+}
+
+type audioSourceService struct {
+	repo      repository.SongSourceRepository
+	songRepo  repository.SongRepository
+	resolvers []AudioSourceResolver
+	store     storage.BlobStore
+	signer    *streamSigner
+
+	// discovery: request bersamaan untuk lagu yang sama berbagi satu pencarian
+	discovery      singleflight.Group
+	discoverySlots chan struct{}
+
+	verifyQueue   chan models.SongSource
+	verifyMu      sync.Mutex
+	verifyPending map[uint]bool
+}
+
+// NewAudioSourceService membuat chain dengan urutan provider: file upload, preview Spotify,
+// YouTube, lalu URL eksternal.
+func NewAudioSourceService(
+	repo repository.SongSourceRepository,
+	songRepo repository.SongRepository,
+	youtubeService YouTubeService,
+	store storage.BlobStore,
+) AudioSourceService {
+	httpClient := &http.Client{Timeout: audioVerifyTimeout}
+	signer := newStreamSigner()
+	return &audioSourceService{
+		repo:     repo,
+		songRepo: songRepo,
+		store:    store,
+		signer:   signer,
+
+		discoverySlots: make(chan struct{}, audioDiscoverySlots),
+		verifyQueue:    make(chan models.SongSource, audioVerifyQueueSize),
+		verifyPending:  make(map[uint]bool),
+		resolvers: []AudioSourceResolver{
+			&storedFileResolver{store: store, signer: signer},
+			&spotifyPreviewResolver{httpClient: httpClient},
+			&youtubeResolver{youtube: youtubeService, songRepo: songRepo, httpClient: httpClient},
+			&externalURLResolver{httpClient: httpClient},
+		},
+	}
+}
+
+func (s *audioSourceService) resolver(provider string) AudioSourceResolver {
+	for _, resolver := range s.resolvers {
+		if resolver.Provider() == provider {
+			return resolver
+		}
+	}
+	return nil
+}
+
+func (s *audioSourceService) GetSources(ctx context.Context, song *models.Song) ([]models.SongSource, error) {
+	stored, err := s.repo.ListBySong(song.ID)
+	if err != nil {
+		return nil, err
+	}
+	found := s.rankSources(stored)
+
+	if s.needsDiscovery(song, found) {
+		result, err, _ := s.discovery.Do(song.ID, func() (interface{}, error) {
+			return s.discover(ctx, song, found)
+		})
+		if err != nil {
+			log.Printf("⚠️ Audio source discovery skipped for song %s: %v", song.ID, err)
+		} else {
+			found = result.([]models.SongSource)
+		}
+	}
+
+	// Sumber baru (atau yang belum sempat masuk antrian) diverifikasi di background
+	for _, source := range found {
+		if source.Status == models.SourceStatusUnverified {
+			s.queueVerification(source)
+		}
+	}
+
+	playable := make([]models.SongSource, 0, len(found))
+	for _, source := range found {
+		if source.Status != models.SourceStatusBroken {
+			playable = append(playable, source)
+		}
+	}
+	if len(playable) == 0 {
+		return nil, ErrNoAudioSource
+	}
+	return playable, nil
+}
+
+// needsDiscovery: masih ada resolver yang bisa menemukan sumber untuk provider yang
+// belum punya sumber yang bisa diputar.
+func (s *audioSourceService) needsDiscovery(song *models.Song, found []models.SongSource) bool {
+	for _, resolver := range s.resolvers {
+		if !hasPlayableSource(found, resolver.Provider()) && resolver.CanDiscover(song, found) {
+			return true
+		}
+	}
+	return false
+}
+
+// discover menjalankan chain resolver untuk provider yang belum punya sumber. Jumlah
+// pencarian bersamaan dibatasi; jika penuh request memakai sumber yang sudah tersimpan.
+func (s *audioSourceService) discover(ctx context.Context, song *models.Song, found []models.SongSource) ([]models.SongSource, error) {
+	select {
+	case s.discoverySlots <- struct{}{}:
+		defer func() { <-s.discoverySlots }()
+	default:
+		// Tidak menunggu slot: request audio tidak boleh antri di belakang pencarian YouTube
+		return found, nil
+	}
+
+	// Hasil dipakai bersama request lain, jadi tidak ikut batal saat client ini putus
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), audioDiscoveryTimeout)
+	defer cancel()
+
+	for _, resolver := range s.resolvers {
+		// Provider yang sudah punya sumber yang bisa diputar tidak perlu dicari ulang
+		if hasPlayableSource(found, resolver.Provider()) || !resolver.CanDiscover(song, found) {
+			continue
+		}
+		sources, err := resolver.Resolve(ctx, song, found)
+		if err != nil {
+			log.Printf("⚠️ Audio source %s failed for song %s: %v", resolver.Provider(), song.ID, err)
+			continue
+		}
+		for i := range sources {
+			source := sources[i]
+			source.SongID = song.ID
+			source.Provider = resolver.Provider()
+			if err := s.repo.Upsert(&source); err != nil {
+				log.Printf("⚠️ Failed to save %s source for song %s: %v", source.Provider, song.ID, err)
+				continue
+			}
+			found = s.rankSources(append(found, source))
+		}
+	}
+	return found, nil
+}
+
+// queueVerification memasukkan sumber ke antrian verifikasi; sumber yang sudah antri
+// tidak dimasukkan dua kali, dan antrian penuh berarti dicoba lagi di request berikutnya.
+func (s *audioSourceService) queueVerification(source models.SongSource) {
+	s.verifyMu.Lock()
+	defer s.verifyMu.Unlock()
+	if s.verifyPending[source.ID] {
+		return
+	}
+	select {
+	case s.verifyQueue <- source:
+		s.verifyPending[source.ID] = true
+	default:
+	}
+}
+
+func (s *audioSourceService) StartVerifier(ctx context.Context) {
+	for i := 0; i < audioVerifyWorkers; i++ {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case source := <-s.verifyQueue:
+					s.verify(ctx, &source)
+					s.verifyMu.Lock()
+					delete(s.verifyPending, source.ID)
+					s.verifyMu.Unlock()
+				}
+			}
+		}()
+	}
+}
+
+func (s *audioSourceService) ListSources(songID string) ([]models.SongSource, error) {
+	if _, err := s.songRepo.GetSongByID(songID); err != nil {
+		return nil, err
+	}
+	sources, err := s.repo.ListBySong(songID)
+	if err != nil {
+		return nil, err
+	}
+	return s.rankSources(sources), nil
+}
+
+// rankSources: full sebelum preview, broken paling akhir, lalu terverifikasi, urutan provider
+// di chain, dan bitrate. PlaybackURL sekalian diisi.
+func (s *audioSourceService) rankSources(sources []models.SongSource) []models.SongSource {
+	order := make(map[string]int, len(s.resolvers))
+	for i, resolver := range s.resolvers {
+		order[resolver.Provider()] = i
+	}
+
+	ranked := make([]models.SongSource, len(sources))
+	copy(ranked, sources)
+	for i := range ranked {
+		if resolver := s.resolver(ranked[i].Provider); resolver != nil {
+			ranked[i].PlaybackURL = resolver.PlaybackURL(&ranked[i])
+		}
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if (a.Status == models.SourceStatusBroken) != (b.Status == models.SourceStatusBroken) {
+			return b.Status == models.SourceStatusBroken
+		}
+		if (a.Quality == models.SourceQualityFull) != (b.Quality == models.SourceQualityFull) {
+			return a.Quality == models.SourceQualityFull
+		}
+		if (a.Status == models.SourceStatusVerified) != (b.Status == models.SourceStatusVerified) {
+			return a.Status == models.SourceStatusVerified
+		}
+		if order[a.Provider] != order[b.Provider] {
+			return order[a.Provider] < order[b.Provider]
+		}
+		return a.BitrateKbps > b.BitrateKbps
+	})
+	return ranked
+}
+
+func hasPlayableSource(sources []models.SongSource, provider string) bool {
+	for _, source := range sources {
+		if source.Provider == provider && source.Status != models.SourceStatusBroken {
+			return true
+		}
+	}
+	return false
+}
+
+func hasFullSource(sources []models.SongSource) bool {
+	for _, source := range sources {
+		if source.Quality == models.SourceQualityFull && source.Status != models.SourceStatusBroken {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *audioSourceService) AddExternalSource(songID, rawURL string, durationMs, bitrateKbps int) (*models.SongSource, error) {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, ErrInvalidSourceURL
+	}
+	if _, err := s.songRepo.GetSongByID(songID); err != nil {
+		return nil, err
+	}
+
+	source := &models.SongSource{
+		SongID:      songID,
+		Provider:    models.SourceProviderExternal,
+		Ref:         parsed.String(),
+		Quality:     models.SourceQualityFull,
+		DurationMs:  durationMs,
+		BitrateKbps: bitrateKbps,
+		MimeType:    mime.TypeByExtension(strings.ToLower(filepath.Ext(parsed.Path))),
+	}
+	if err := s.repo.Upsert(source); err != nil {
+		return nil, err
+	}
+	s.verify(context.Background(), source)
+	return source, nil
+}
+
+func (s *audioSourceService) RemoveSource(songID string, id uint) error {
+	source, err := s.repo.GetByID(songID, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(songID, id); err != nil {
+		return err
+	}
+	if source.Provider == models.SourceProviderLocal {
+		releaseBlob(s.repo, s.store, source.Ref)
+	}
+	return nil
+}
+
+func (s *audioSourceService) VerifySources(ctx context.Context, songID string) ([]models.SongSource, error) {
+	sources, err := s.repo.ListBySong(songID)
+	if err != nil {
+		return nil, err
+	}
+	s.verifyAll(ctx, sources)
+	return s.ListSources(songID)
+}
+
+func (s *audioSourceService) verifyAll(ctx context.Context, sources []models.SongSource) {
+	for i := range sources {
+		if ctx.Err() != nil {
+			return
+		}
+		s.verify(ctx, &sources[i])
+	}
+}
+
+func (s *audioSourceService) verify(ctx context.Context, source *models.SongSource) {
+	resolver := s.resolver(source.Provider)
+	if resolver == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, audioVerifyTimeout)
+	defer cancel()
+
+	now := time.Now()
+	source.VerifiedAt = &now
+	if err := resolver.Verify(ctx, source); err != nil {
+		source.Status = models.SourceStatusBroken
+		source.LastError = err.Error()
+	} else {
+		source.Status = models.SourceStatusVerified
+		source.LastError = ""
+	}
+	if err := s.repo.Update(source); err != nil {
+		log.Printf("⚠️ Failed to save verification for source %d: %v", source.ID, err)
+	}
+}
+
+func (s *audioSourceService) OpenStream(ctx context.Context, songID string, id uint) (*AudioStream, error) {
+	var source *models.SongSource
+	if id == 0 {
+		sources, err := s.repo.ListBySong(songID)
+		if err != nil {
+			return nil, err
+		}
+		for _, candidate := range s.rankSources(sources) {
+			if candidate.Provider == models.SourceProviderLocal && candidate.Status != models.SourceStatusBroken {
+				source = &candidate
+				break
+			}
+		}
+		if source == nil {
+			return nil, ErrNoAudioSource
+		}
+	} else {
+		var err error
+		source, err = s.repo.GetByID(songID, id)
+		if err != nil {
+			return nil, err
+		}
+		if source.Provider != models.SourceProviderLocal {
+			return nil, ErrSourceNotLocal
+		}
+	}
+
+	info, err := s.store.Stat(ctx, source.Ref)
+	if err != nil {
+		return nil, err
+	}
+	if source.MimeType != "" {
+		info.ContentType = source.MimeType
+	}
+	// Key berbasis checksum, jadi isi object tidak pernah berubah: checksum jadi ETag kuat
+	if source.Checksum != "" {
+		info.ETag = source.Checksum
+	}
+	return &AudioStream{
+		Body:   storage.NewObjectReader(ctx, s.store, info),
+		Info:   info,
+		Source: source,
+	}, nil
+}
+
+func (s *audioSourceService) VerifyStreamURL(songID string, id uint, expires, sig string) (time.Time, error) {
+	return s.signer.Verify(songID, id, expires, sig)
+}
+
+// ================ RESOLVERS ================
+
+// storedFileResolver melayani file audio yang diupload admin ke BlobStore. Sumbernya
+// didaftarkan saat upload, jadi tidak ada pencarian otomatis.
+type storedFileResolver struct {
+	store  storage.BlobStore
+	signer *streamSigner
+}
+
+func (r *storedFileResolver) Provider() string { return models.SourceProviderLocal }
+
+func (r *storedFileResolver) Resolve(ctx context.Context, song *models.Song, found []models.SongSource) ([]models.SongSource, error) {
+	return nil, nil
+}
+
+func (r *storedFileResolver) CanDiscover(song *models.Song, found []models.SongSource) bool {
+	return false
+}
+
+func (r *storedFileResolver) Verify(ctx context.Context, source *models.SongSource) error {
+	info, err := r.store.Stat(ctx, source.Ref)
+	if err != nil {
+		return err
+	}
+	if info.Size == 0 {
+		return errors.New("stored file is empty")
+	}
+	return nil
+}
+
+// PlaybackURL adalah URL stream bertanda tangan yang bisa langsung dipakai di <audio>.
+func (r *storedFileResolver) PlaybackURL(source *models.SongSource) string {
+	return r.signer.URL(source.SongID, source.ID)
+}
+
+// spotifyPreviewResolver memakai Song.PreviewURL (potongan 30 detik).
+type spotifyPreviewResolver struct {
+	httpClient *http.Client
+}
+
+func (r *spotifyPreviewResolver) Provider() string { return models.SourceProviderSpotifyPreview }
+
+func (r *spotifyPreviewResolver) Resolve(ctx context.Context, song *models.Song, found []models.SongSource) ([]models.SongSource, error) {
+	if song.PreviewURL == "" {
+		return nil, nil
+	}
+	return []models.SongSource{{
+		Ref:        song.PreviewURL,
+		Quality:    models.SourceQualityPreview,
+		DurationMs: spotifyPreviewDurationMs,
+		MimeType:   "audio/mpeg",
+	}}, nil
+}
+
+// CanDiscover: PreviewURL yang sudah tersimpan sebagai sumber (termasuk yang broken)
+// tidak perlu dicari ulang.
+func (r *spotifyPreviewResolver) CanDiscover(song *models.Song, found []models.SongSource) bool {
+	if song.PreviewURL == "" {
+		return false
+	}
+	for _, source := range found {
+		if source.Provider == models.SourceProviderSpotifyPreview && source.Ref == song.PreviewURL {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *spotifyPreviewResolver) Verify(ctx context.Context, source *models.SongSource) error {
+	return verifyAudioURL(ctx, r.httpClient, source.Ref)
+}
+
+func (r *spotifyPreviewResolver) PlaybackURL(source *models.SongSource) string {
+	return source.Ref
+}
+
+// youtubeResolver memakai Song.YoutubeID, atau mencari lewat YouTubeService jika
+// belum ada sumber full dari provider sebelumnya.
+type youtubeResolver struct {
+	youtube    YouTubeService
+	songRepo   repository.SongRepository
+	httpClient *http.Client
+}
+
+func (r *youtubeResolver) Provider() string { return models.SourceProviderYouTube }
+
+// CanDiscover: YoutubeID yang belum rusak bisa langsung dipakai; pencarian hanya jika
+// belum ada sumber full lain.
+func (r *youtubeResolver) CanDiscover(song *models.Song, found []models.SongSource) bool {
+	if song.YoutubeID != "" && !brokenYouTubeRefs(found)[song.YoutubeID] {
+		return true
+	}
+	return !hasFullSource(found)
+}
+
+func (r *youtubeResolver) Resolve(ctx context.Context, song *models.Song, found []models.SongSource) ([]models.SongSource, error) {
+	broken := brokenYouTubeRefs(found)
+
+	videoID, durationMs := song.YoutubeID, song.DurationMs
+	if videoID == "" || broken[videoID] {
+		// Pencarian memakai kuota API: hanya jika belum ada sumber full lain
+		if hasFullSource(found) {
+			return nil, nil
+		}
+		match, err := r.youtube.SearchAudio(song, broken)
+		if err != nil {
+			// Kuota habis atau lagu baru saja gagal dicari: lewati tanpa error
+			if errors.Is(err, ErrYouTubeQuotaExhausted) || errors.Is(err, ErrYouTubeNegativeCached) ||
+				errors.Is(err, ErrYouTubeNotConfigured) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		videoID = match.VideoID
+		if match.DurationMs > 0 {
+			durationMs = match.DurationMs
+		}
+		song.YoutubeID = match.VideoID
+		song.YoutubeConfidence = match.Confidence
+		if err := r.songRepo.UpdateSong(song); err != nil {
+			log.Printf("⚠️ Failed to cache YoutubeID for song %s: %v", song.ID, err)
+		}
+	}
+
+	return []models.SongSource{{
+		Ref:        videoID,
+		Quality:    models.SourceQualityFull,
+		DurationMs: durationMs,
+	}}, nil
+}
+
+func brokenYouTubeRefs(found []models.SongSource) map[string]bool {
+	broken := make(map[string]bool)
+	for _, source := range found {
+		if source.Provider == models.SourceProviderYouTube && source.Status == models.SourceStatusBroken {
+			broken[source.Ref] = true
+		}
+	}
+	return broken
+}
+
+// Verify memakai endpoint oEmbed (tanpa kuota API): video privat, dihapus, atau
+// tidak boleh di-embed dianggap broken.
+func (r *youtubeResolver) Verify(ctx context.Context, source *models.SongSource) error {
+	oembed := "https://www.youtube.com/oembed?format=json&url=" +
+		url.QueryEscape("https://www.youtube.com/watch?v="+source.Ref)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, oembed, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("youtube video unavailable (status %d)", resp.StatusCode)
+	}
+	return nil
+}
+
+func (r *youtubeResolver) PlaybackURL(source *models.SongSource) string {
+	return "https://www.youtube.com/watch?v=" + source.Ref
+}
+
+// externalURLResolver tidak mencari sendiri; sumbernya didaftarkan admin.
+type externalURLResolver struct {
+	httpClient *http.Client
+}
+
+func (r *externalURLResolver) Provider() string { return models.SourceProviderExternal }
+
+func (r *externalURLResolver) Resolve(ctx context.Context, song *models.Song, found []models.SongSource) ([]models.SongSource, error) {
+	return nil, nil
+}
+
+func (r *externalURLResolver) CanDiscover(song *models.Song, found []models.SongSource) bool {
+	return false
+}
+
+func (r *externalURLResolver) Verify(ctx context.Context, source *models.SongSource) error {
+	return verifyAudioURL(ctx, r.httpClient, source.Ref)
+}
+
+func (r *externalURLResolver) PlaybackURL(source *models.SongSource) string {
+	return source.Ref
+}
+
+// verifyAudioURL memeriksa URL dengan HEAD (fallback GET 1 byte jika HEAD ditolak)
+// dan memastikan content type-nya audio.
+func verifyAudioURL(ctx context.Context, client *http.Client, rawURL string) error {
+	resp, err := audioProbe(ctx, client, http.MethodHead, rawURL)
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusForbidden) {
+		resp, err = audioProbe(ctx, client, http.MethodGet, rawURL)
+	}
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	contentType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if contentType != "" && !strings.HasPrefix(contentType, "audio/") &&
+		contentType != "application/octet-stream" && contentType != "application/ogg" {
+		return fmt.Errorf("unexpected content type %s", contentType)
+	}
+	return nil
+}
+
+func audioProbe(ctx context.Context, client *http.Client, method, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if method == http.MethodGet {
+		req.Header.Set("Range", "bytes=0-0")
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	resp.Body.Close()
+	return resp, nil
+}
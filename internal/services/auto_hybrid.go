@@ -118,6 +118,10 @@ func (s *smartHybridService) getPopularSongsFallback(limit int) ([]models.Recomm
             Song:      song,
             Score:     float64(song.Popularity) / 100.0,
             ScoreType: "popular_fallback",
+            Reasons: []models.RecommendationReason{{
+                Type:         models.ReasonPopular,
+                Contribution: float64(song.Popularity) / 100.0,
+            }},
         })
     }
     return recommendations, nil
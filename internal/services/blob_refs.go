@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"back_music/internal/models"
+	"back_music/internal/repository"
+	"back_music/internal/storage"
+)
+
+// blobRefLocks men-serialisasi penautan dan pelepasan object upload dengan key yang sama:
+// upload (Stat/Put lalu Upsert) dan hapus (CountByRef lalu Delete) tidak boleh bersilangan,
+// kalau tidak sumber baru bisa tertaut ke object yang baru saja dihapus.
+var blobRefLocks = &keyedMutex{locks: make(map[string]*keyedLock)}
+
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLock
+}
+
+type keyedLock struct {
+	sync.Mutex
+	waiters int
+}
+
+// Lock mengunci key dan mengembalikan fungsi untuk melepasnya.
+func (m *keyedMutex) Lock(key string) func() {
+	m.mu.Lock()
+	lock, ok := m.locks[key]
+	if !ok {
+		lock = &keyedLock{}
+		m.locks[key] = lock
+	}
+	lock.waiters++
+	m.mu.Unlock()
+
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+		m.mu.Lock()
+		if lock.waiters--; lock.waiters == 0 {
+			delete(m.locks, key)
+		}
+		m.mu.Unlock()
+	}
+}
+
+// releaseBlob menghapus object upload dari BlobStore jika tidak ada lagi sumber "local"
+// yang memakainya (object dipakai bersama oleh sumber dengan checksum yang sama).
+func releaseBlob(repo repository.SongSourceRepository, store storage.BlobStore, ref string) {
+	unlock := blobRefLocks.Lock(ref)
+	defer unlock()
+
+	remaining, err := repo.CountByRef(models.SourceProviderLocal, ref)
+	if err != nil {
+		log.Printf("⚠️ Failed to count references to %s: %v", ref, err)
+		return
+	}
+	if remaining > 0 {
+		return
+	}
+	if err := store.Delete(context.Background(), ref); err != nil {
+		log.Printf("⚠️ Failed to remove audio object %s: %v", ref, err)
+	}
+}
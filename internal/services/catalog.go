@@ -0,0 +1,337 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"back_music/internal/models"
+	"back_music/internal/repository"
+	"back_music/internal/storage"
+)
+
+var ErrInvalidCatalogInput = errors.New("invalid catalog input")
+
+// SongPatch adalah perubahan metadata lagu oleh admin; field nil tidak diubah.
+type SongPatch struct {
+	Title      *string `json:"title"`
+	Artist     *string `json:"artist"`
+	Album      *string `json:"album"`
+	Genre      *string `json:"genre"`
+	YoutubeID  *string `json:"youtube_id"`
+	ImageURL   *string `json:"image_url"`
+	PreviewURL *string `json:"preview_url"`
+	Popularity *int    `json:"popularity"`
+	DurationMs *int    `json:"duration_ms"`
+}
+
+// SongBulkFilter adalah subset filter browse yang boleh dipakai untuk bulk edit.
+type SongBulkFilter struct {
+	Genre         string `json:"genre"`
+	Artist        string `json:"artist"`
+	YearFrom      int    `json:"year_from"`
+	YearTo        int    `json:"year_to"`
+	MinDurationMs int    `json:"min_duration_ms"`
+	MaxDurationMs int    `json:"max_duration_ms"`
+}
+
+// SongBulkSet adalah field yang boleh di-override secara massal.
+type SongBulkSet struct {
+	Genre      *string `json:"genre"`
+	YoutubeID  *string `json:"youtube_id"`
+	ImageURL   *string `json:"image_url"`
+	PreviewURL *string `json:"preview_url"`
+}
+
+type CatalogService interface {
+	CreateSong(actorID uint, spotifyID string, patch SongPatch) (*models.Song, error)
+	UpdateSong(actorID uint, songID string, patch SongPatch) (*models.Song, error)
+	DeleteSong(actorID uint, songID string) error
+	MergeSongs(actorID uint, sourceID, targetID string) error
+	BulkUpdate(actorID uint, filter SongBulkFilter, set SongBulkSet) (int64, error)
+	GetSongStats(songID string) (*repository.SongStats, error)
+	ListAuditLogs(entityType, entityID string, limit int) ([]models.AuditLog, error)
+}
+
+type catalogService struct {
+	catalogRepo repository.CatalogRepository
+	songRepo    repository.SongRepository
+	artistRepo  repository.ArtistRepository
+	auditRepo   repository.AuditLogRepository
+	sourceRepo  repository.SongSourceRepository
+	store       storage.BlobStore
+}
+
+func NewCatalogService(
+	catalogRepo repository.CatalogRepository,
+	songRepo repository.SongRepository,
+	artistRepo repository.ArtistRepository,
+	auditRepo repository.AuditLogRepository,
+	sourceRepo repository.SongSourceRepository,
+	store storage.BlobStore,
+) CatalogService {
+	return &catalogService{
+		catalogRepo: catalogRepo,
+		songRepo:    songRepo,
+		artistRepo:  artistRepo,
+		auditRepo:   auditRepo,
+		sourceRepo:  sourceRepo,
+		store:       store,
+	}
+}
+
+func invalidInput(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidCatalogInput, fmt.Sprintf(format, args...))
+}
+
+// applySongPatch menerapkan patch ke song dan mengembalikan daftar perubahan {field: {from, to}}.
+func applySongPatch(song *models.Song, patch SongPatch) (map[string]interface{}, error) {
+	changes := map[string]interface{}{}
+
+	setString := func(field string, target *string, value *string, required bool) error {
+		if value == nil {
+			return nil
+		}
+		trimmed := strings.TrimSpace(*value)
+		if required && trimmed == "" {
+			return invalidInput("%s cannot be empty", field)
+		}
+		if trimmed != *target {
+			changes[field] = map[string]interface{}{"from": *target, "to": trimmed}
+			*target = trimmed
+		}
+		return nil
+	}
+	setInt := func(field string, target *int, value *int, min, max int) error {
+		if value == nil {
+			return nil
+		}
+		if *value < min || *value > max {
+			return invalidInput("%s must be between %d and %d", field, min, max)
+		}
+		if *value != *target {
+			changes[field] = map[string]interface{}{"from": *target, "to": *value}
+			*target = *value
+		}
+		return nil
+	}
+
+	steps := []error{
+		setString("title", &song.Title, patch.Title, true),
+		setString("artist", &song.Artist, patch.Artist, true),
+		setString("album", &song.Album, patch.Album, false),
+		setString("genre", &song.Genre, patch.Genre, false),
+		setString("youtube_id", &song.YoutubeID, patch.YoutubeID, false),
+		setString("image_url", &song.ImageURL, patch.ImageURL, false),
+		setString("preview_url", &song.PreviewURL, patch.PreviewURL, false),
+		setInt("popularity", &song.Popularity, patch.Popularity, 0, 100),
+		setInt("duration_ms", &song.DurationMs, patch.DurationMs, 0, 24*60*60*1000),
+	}
+	for _, err := range steps {
+		if err != nil {
+			return nil, err
+		}
+	}
+	// YouTube ID yang diset admin dianggap pasti cocok
+	if _, changed := changes["youtube_id"]; changed {
+		song.YoutubeConfidence = 0
+		if song.YoutubeID != "" {
+			song.YoutubeConfidence = 1
+		}
+	}
+	return changes, nil
+}
+
+func (s *catalogService) audit(actorID uint, action, entityID string, details map[string]interface{}) {
+	entry := &models.AuditLog{
+		ActorID:    actorID,
+		Action:     action,
+		EntityType: models.AuditEntitySong,
+		EntityID:   entityID,
+		Details:    details,
+	}
+	if err := s.auditRepo.CreateAuditLog(entry); err != nil {
+		log.Printf("⚠️ Failed to write audit log %s for %s: %v", action, entityID, err)
+	}
+}
+
+// manualSpotifyIDPrefix menandai lagu manual yang tidak punya track di Spotify.
+const manualSpotifyIDPrefix = "manual:"
+
+// CreateSong membuat lagu manual. Tanpa Spotify ID, dibuatkan ID "manual:<uuid>".
+func (s *catalogService) CreateSong(actorID uint, spotifyID string, patch SongPatch) (*models.Song, error) {
+	if patch.Title == nil || patch.Artist == nil {
+		return nil, invalidInput("title and artist are required")
+	}
+
+	spotifyID = strings.TrimSpace(spotifyID)
+	if spotifyID == "" {
+		spotifyID = manualSpotifyIDPrefix + uuid.NewString()
+	} else if _, err := s.songRepo.GetSongBySpotifyID(spotifyID); err == nil {
+		return nil, invalidInput("song with spotify_id %s already exists", spotifyID)
+	} else if !errors.Is(err, repository.ErrSongNotFound) {
+		return nil, err
+	}
+
+	song := &models.Song{SpotifyID: spotifyID}
+	if _, err := applySongPatch(song, patch); err != nil {
+		return nil, err
+	}
+
+	if err := s.songRepo.CreateSong(song); err != nil {
+		return nil, err
+	}
+
+	credits := make([]models.ArtistCredit, 0, 2)
+	for _, name := range SplitArtistNames(song.Artist) {
+		credits = append(credits, models.ArtistCredit{Name: name})
+	}
+	if err := s.artistRepo.LinkSongArtists(song.ID, credits); err != nil {
+		log.Printf("⚠️ Failed to link artists for song %s: %v", song.ID, err)
+	}
+
+	s.audit(actorID, models.AuditSongCreate, song.ID, map[string]interface{}{
+		"spotify_id": song.SpotifyID,
+		"title":      song.Title,
+		"artist":     song.Artist,
+	})
+	return song, nil
+}
+
+func (s *catalogService) UpdateSong(actorID uint, songID string, patch SongPatch) (*models.Song, error) {
+	song, err := s.songRepo.GetSongByID(songID)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := applySongPatch(song, patch)
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 {
+		return song, nil
+	}
+
+	if err := s.songRepo.UpdateSong(song); err != nil {
+		return nil, err
+	}
+
+	if _, changed := changes["artist"]; changed {
+		credits := make([]models.ArtistCredit, 0, 2)
+		for _, name := range SplitArtistNames(song.Artist) {
+			credits = append(credits, models.ArtistCredit{Name: name})
+		}
+		if err := s.artistRepo.ReplaceSongArtists(song.ID, credits); err != nil {
+			log.Printf("⚠️ Failed to link artists for song %s: %v", song.ID, err)
+		}
+	}
+
+	s.audit(actorID, models.AuditSongUpdate, song.ID, changes)
+	return song, nil
+}
+
+func (s *catalogService) DeleteSong(actorID uint, songID string) error {
+	song, err := s.songRepo.GetSongByID(songID)
+	if err != nil {
+		return err
+	}
+	sources, err := s.sourceRepo.ListBySong(songID)
+	if err != nil {
+		return err
+	}
+	if err := s.catalogRepo.DeleteSong(songID); err != nil {
+		return err
+	}
+	// File upload milik lagu ini ikut dihapus jika tidak dipakai lagu lain
+	for _, source := range sources {
+		if source.Provider == models.SourceProviderLocal {
+			releaseBlob(s.sourceRepo, s.store, source.Ref)
+		}
+	}
+
+	s.audit(actorID, models.AuditSongDelete, songID, map[string]interface{}{
+		"spotify_id": song.SpotifyID,
+		"title":      song.Title,
+		"artist":     song.Artist,
+	})
+	return nil
+}
+
+// MergeSongs menggabungkan duplikat: semua like/play/playlist dari source pindah ke target.
+func (s *catalogService) MergeSongs(actorID uint, sourceID, targetID string) error {
+	source, err := s.songRepo.GetSongByID(sourceID)
+	if err != nil {
+		return err
+	}
+	if err := s.catalogRepo.MergeSongs(sourceID, targetID); err != nil {
+		return err
+	}
+
+	s.audit(actorID, models.AuditSongMerge, targetID, map[string]interface{}{
+		"source_id":         sourceID,
+		"source_spotify_id": source.SpotifyID,
+		"source_title":      source.Title,
+	})
+	return nil
+}
+
+func (s *catalogService) BulkUpdate(actorID uint, filter SongBulkFilter, set SongBulkSet) (int64, error) {
+	if filter == (SongBulkFilter{}) {
+		return 0, invalidInput("bulk update requires at least one filter")
+	}
+
+	updates := map[string]interface{}{}
+	fields := []struct {
+		column string
+		value  *string
+	}{
+		{"genre", set.Genre},
+		{"youtube_id", set.YoutubeID},
+		{"image_url", set.ImageURL},
+		{"preview_url", set.PreviewURL},
+	}
+	for _, field := range fields {
+		if field.value != nil {
+			updates[field.column] = strings.TrimSpace(*field.value)
+		}
+	}
+	if len(updates) == 0 {
+		return 0, invalidInput("nothing to update")
+	}
+	if youtubeID, ok := updates["youtube_id"]; ok {
+		updates["youtube_confidence"] = 0
+		if youtubeID != "" {
+			updates["youtube_confidence"] = 1
+		}
+	}
+
+	affected, err := s.catalogRepo.BulkUpdateSongs(repository.SongBrowseFilter{
+		Genre:         filter.Genre,
+		Artist:        filter.Artist,
+		YearFrom:      filter.YearFrom,
+		YearTo:        filter.YearTo,
+		MinDurationMs: filter.MinDurationMs,
+		MaxDurationMs: filter.MaxDurationMs,
+	}, updates)
+	if err != nil {
+		return 0, err
+	}
+
+	s.audit(actorID, models.AuditSongBulkUpdate, "", map[string]interface{}{
+		"filter":   filter,
+		"set":      updates,
+		"affected": affected,
+	})
+	return affected, nil
+}
+
+func (s *catalogService) GetSongStats(songID string) (*repository.SongStats, error) {
+	return s.catalogRepo.GetSongStats(songID)
+}
+
+func (s *catalogService) ListAuditLogs(entityType, entityID string, limit int) ([]models.AuditLog, error) {
+	return s.auditRepo.ListAuditLogs(entityType, entityID, limit)
+}
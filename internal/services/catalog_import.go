@@ -0,0 +1,477 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"back_music/internal/models"
+	"back_music/internal/repository"
+)
+
+const (
+	ImportFormatCSV   = "csv"
+	ImportFormatJSONL = "jsonl"
+)
+
+const (
+	ImportRowCreated = "created"
+	ImportRowUpdated = "updated"
+	ImportRowSkipped = "skipped"
+	ImportRowInvalid = "invalid"
+)
+
+// importProgressEvery: progress job disimpan setiap N baris.
+const importProgressEvery = 50
+
+// importMaxLineSize membatasi panjang satu baris JSONL.
+const importMaxLineSize = 1024 * 1024
+
+var ErrUnsupportedImportFormat = errors.New("unsupported import format (use csv or jsonl)")
+
+// ImportRowResult adalah hasil satu baris file import (baris dihitung dari 1, tanpa header).
+type ImportRowResult struct {
+	Row       int      `json:"row"`
+	SpotifyID string   `json:"spotify_id,omitempty"`
+	SongID    string   `json:"song_id,omitempty"`
+	Status    string   `json:"status"`
+	Errors    []string `json:"errors,omitempty"`
+}
+
+type ImportReport struct {
+	Format  string            `json:"format"`
+	Total   int               `json:"total"`
+	Created int               `json:"created"`
+	Updated int               `json:"updated"`
+	Skipped int               `json:"skipped"`
+	Invalid int               `json:"invalid"`
+	Rows    []ImportRowResult `json:"rows"`
+}
+
+func (r *ImportReport) add(result ImportRowResult) {
+	r.Total++
+	switch result.Status {
+	case ImportRowCreated:
+		r.Created++
+	case ImportRowUpdated:
+		r.Updated++
+	case ImportRowSkipped:
+		r.Skipped++
+	case ImportRowInvalid:
+		r.Invalid++
+	}
+	r.Rows = append(r.Rows, result)
+}
+
+type CatalogImportService interface {
+	// Import menjalankan import secara sinkron (dipakai CLI). progress boleh nil.
+	Import(ctx context.Context, data []byte, format string, progress func(processed, total int)) (*ImportReport, error)
+	// StartImportJob menyimpan job lalu menjalankan import di background.
+	StartImportJob(actorID uint, data []byte, format, filename string) (*models.Job, error)
+}
+
+type catalogImportService struct {
+	songRepo   repository.SongRepository
+	artistRepo repository.ArtistRepository
+	jobRunner  JobRunner
+	auditRepo  repository.AuditLogRepository
+}
+
+func NewCatalogImportService(
+	songRepo repository.SongRepository,
+	artistRepo repository.ArtistRepository,
+	jobRunner JobRunner,
+	auditRepo repository.AuditLogRepository,
+) CatalogImportService {
+	return &catalogImportService{
+		songRepo:   songRepo,
+		artistRepo: artistRepo,
+		jobRunner:  jobRunner,
+		auditRepo:  auditRepo,
+	}
+}
+
+// DetectImportFormat menebak format dari parameter eksplisit atau ekstensi file.
+func DetectImportFormat(format, filename string) (string, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(filename)) {
+		case ".csv":
+			format = ImportFormatCSV
+		case ".jsonl", ".ndjson":
+			format = ImportFormatJSONL
+		}
+	}
+	if format != ImportFormatCSV && format != ImportFormatJSONL {
+		return "", ErrUnsupportedImportFormat
+	}
+	return format, nil
+}
+
+// importRecord adalah satu baris mentah: nama kolom -> nilai string.
+type importRecord map[string]string
+
+// readImportRecords membaca seluruh baris CSV (dengan header) atau JSONL.
+func readImportRecords(data []byte, format string) ([]importRecord, error) {
+	switch format {
+	case ImportFormatCSV:
+		reader := csv.NewReader(bytes.NewReader(data))
+		reader.TrimLeadingSpace = true
+		reader.FieldsPerRecord = -1
+
+		header, err := reader.Read()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV header: %w", err)
+		}
+		for i := range header {
+			header[i] = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff")))
+		}
+
+		var records []importRecord
+		for {
+			row, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				// Baris rusak (mis. quote tidak tertutup) dilaporkan invalid, import berlanjut
+				records = append(records, importRecord{"_error": "invalid CSV row: " + parseErr.Err.Error()})
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to read CSV: %w", err)
+			}
+			record := importRecord{}
+			for i, value := range row {
+				if i < len(header) {
+					record[header[i]] = strings.TrimSpace(value)
+				}
+			}
+			records = append(records, record)
+		}
+		return records, nil
+
+	case ImportFormatJSONL:
+		var records []importRecord
+		reader := bufio.NewReader(bytes.NewReader(data))
+		for {
+			line, tooLong, err := readImportLine(reader)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to read JSONL: %w", err)
+			}
+			if tooLong {
+				records = append(records, importRecord{"_error": fmt.Sprintf("line is longer than %d bytes", importMaxLineSize)})
+				continue
+			}
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			var raw map[string]interface{}
+			record := importRecord{}
+			if err := json.Unmarshal([]byte(line), &raw); err != nil {
+				// Baris rusak tetap dilaporkan sebagai invalid
+				record["_error"] = "invalid JSON: " + err.Error()
+			}
+			for key, value := range raw {
+				if value == nil {
+					continue
+				}
+				switch v := value.(type) {
+				case string:
+					record[strings.ToLower(key)] = strings.TrimSpace(v)
+				case float64:
+					record[strings.ToLower(key)] = strconv.FormatFloat(v, 'f', -1, 64)
+				default:
+					record[strings.ToLower(key)] = fmt.Sprint(v)
+				}
+			}
+			records = append(records, record)
+		}
+		return records, nil
+	}
+	return nil, ErrUnsupportedImportFormat
+}
+
+// readImportLine membaca satu baris JSONL. Baris yang melebihi importMaxLineSize dibaca
+// sampai habis lalu dilaporkan tooLong, supaya baris berikutnya tetap bisa diproses.
+func readImportLine(reader *bufio.Reader) (line string, tooLong bool, err error) {
+	var buf []byte
+	for {
+		chunk, isPrefix, err := reader.ReadLine()
+		if err != nil {
+			if err == io.EOF && (len(buf) > 0 || tooLong) {
+				return string(buf), tooLong, nil
+			}
+			return "", false, err
+		}
+		if !tooLong {
+			if len(buf)+len(chunk) > importMaxLineSize {
+				tooLong, buf = true, nil
+			} else {
+				buf = append(buf, chunk...)
+			}
+		}
+		if !isPrefix {
+			return string(buf), tooLong, nil
+		}
+	}
+}
+
+// importFloatField adalah fitur audio float beserta rentang validnya.
+type importFloatField struct {
+	name     string
+	min, max float64
+	target   func(song *models.Song) *float64
+}
+
+type importIntField struct {
+	name     string
+	min, max int
+	target   func(song *models.Song) *int
+}
+
+var importStringFields = []struct {
+	name   string
+	target func(song *models.Song) *string
+}{
+	{"title", func(s *models.Song) *string { return &s.Title }},
+	{"artist", func(s *models.Song) *string { return &s.Artist }},
+	{"album", func(s *models.Song) *string { return &s.Album }},
+	{"genre", func(s *models.Song) *string { return &s.Genre }},
+	{"preview_url", func(s *models.Song) *string { return &s.PreviewURL }},
+	{"image_url", func(s *models.Song) *string { return &s.ImageURL }},
+	{"youtube_id", func(s *models.Song) *string { return &s.YoutubeID }},
+	{"isrc", func(s *models.Song) *string { return &s.ISRC }},
+}
+
+var importFloatFields = []importFloatField{
+	{"danceability", 0, 1, func(s *models.Song) *float64 { return &s.Danceability }},
+	{"energy", 0, 1, func(s *models.Song) *float64 { return &s.Energy }},
+	{"speechiness", 0, 1, func(s *models.Song) *float64 { return &s.Speechiness }},
+	{"acousticness", 0, 1, func(s *models.Song) *float64 { return &s.Acousticness }},
+	{"instrumentalness", 0, 1, func(s *models.Song) *float64 { return &s.Instrumentalness }},
+	{"liveness", 0, 1, func(s *models.Song) *float64 { return &s.Liveness }},
+	{"valence", 0, 1, func(s *models.Song) *float64 { return &s.Valence }},
+	{"loudness", -60, 5, func(s *models.Song) *float64 { return &s.Loudness }},
+	{"tempo", 0, 300, func(s *models.Song) *float64 { return &s.Tempo }},
+}
+
+var importIntFields = []importIntField{
+	{"key", 0, 11, func(s *models.Song) *int { return &s.Key }},
+	{"mode", 0, 1, func(s *models.Song) *int { return &s.Mode }},
+	{"time_signature", 3, 7, func(s *models.Song) *int { return &s.TimeSignature }},
+	{"popularity", 0, 100, func(s *models.Song) *int { return &s.Popularity }},
+	{"duration_ms", 1, 24 * 60 * 60 * 1000, func(s *models.Song) *int { return &s.DurationMs }},
+}
+
+// applyImportRecord memvalidasi record dan menerapkan kolom yang terisi ke song.
+// Mengembalikan daftar error validasi dan apakah ada field yang berubah.
+func applyImportRecord(record importRecord, song *models.Song) ([]string, bool) {
+	var errs []string
+	changed := false
+
+	for _, field := range importStringFields {
+		value, ok := record[field.name]
+		if !ok || value == "" {
+			continue
+		}
+		if target := field.target(song); *target != value {
+			*target = value
+			changed = true
+		}
+	}
+
+	for _, field := range importFloatFields {
+		raw, ok := record[field.name]
+		if !ok || raw == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %q is not a number", field.name, raw))
+			continue
+		}
+		if value < field.min || value > field.max {
+			errs = append(errs, fmt.Sprintf("%s: %v is outside %v..%v", field.name, value, field.min, field.max))
+			continue
+		}
+		if target := field.target(song); *target != value {
+			*target = value
+			changed = true
+		}
+	}
+
+	for _, field := range importIntFields {
+		raw, ok := record[field.name]
+		if !ok || raw == "" {
+			continue
+		}
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %q is not an integer", field.name, raw))
+			continue
+		}
+		if value < field.min || value > field.max {
+			errs = append(errs, fmt.Sprintf("%s: %d is outside %d..%d", field.name, value, field.min, field.max))
+			continue
+		}
+		if target := field.target(song); *target != value {
+			*target = value
+			changed = true
+		}
+	}
+
+	if strings.TrimSpace(song.Title) == "" {
+		errs = append(errs, "title is required")
+	}
+	if strings.TrimSpace(song.Artist) == "" {
+		errs = append(errs, "artist is required")
+	}
+	return errs, changed
+}
+
+func (s *catalogImportService) importRow(row int, record importRecord) ImportRowResult {
+	spotifyID := record["spotify_id"]
+	result := ImportRowResult{Row: row, SpotifyID: spotifyID}
+
+	if parseErr, ok := record["_error"]; ok {
+		result.Status = ImportRowInvalid
+		result.Errors = []string{parseErr}
+		return result
+	}
+	if spotifyID == "" {
+		result.Status = ImportRowInvalid
+		result.Errors = []string{"spotify_id is required"}
+		return result
+	}
+
+	existing, err := s.songRepo.GetSongBySpotifyID(spotifyID)
+	if err != nil && !errors.Is(err, repository.ErrSongNotFound) {
+		result.Status = ImportRowInvalid
+		result.Errors = []string{"lookup failed: " + err.Error()}
+		return result
+	}
+
+	song := &models.Song{SpotifyID: spotifyID}
+	if existing != nil {
+		song = existing
+	}
+
+	errs, changed := applyImportRecord(record, song)
+	if len(errs) > 0 {
+		result.Status = ImportRowInvalid
+		result.Errors = errs
+		return result
+	}
+
+	if existing == nil {
+		if err := s.songRepo.CreateSong(song); err != nil {
+			result.Status = ImportRowInvalid
+			result.Errors = []string{"create failed: " + err.Error()}
+			return result
+		}
+		credits := make([]models.ArtistCredit, 0, 2)
+		for _, name := range SplitArtistNames(song.Artist) {
+			credits = append(credits, models.ArtistCredit{Name: name})
+		}
+		if err := s.artistRepo.LinkSongArtists(song.ID, credits); err != nil {
+			log.Printf("⚠️ Failed to link artists for imported song %s: %v", song.ID, err)
+		}
+		result.SongID = song.ID
+		result.Status = ImportRowCreated
+		return result
+	}
+
+	result.SongID = song.ID
+	if !changed {
+		result.Status = ImportRowSkipped
+		return result
+	}
+	if err := s.songRepo.UpdateSong(song); err != nil {
+		result.Status = ImportRowInvalid
+		result.Errors = []string{"update failed: " + err.Error()}
+		return result
+	}
+	result.Status = ImportRowUpdated
+	return result
+}
+
+func (s *catalogImportService) Import(ctx context.Context, data []byte, format string, progress func(processed, total int)) (*ImportReport, error) {
+	records, err := readImportRecords(data, format)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ImportReport{Format: format, Rows: make([]ImportRowResult, 0, len(records))}
+	for i, record := range records {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		report.add(s.importRow(i+1, record))
+		if progress != nil && ((i+1)%importProgressEvery == 0 || i+1 == len(records)) {
+			progress(i+1, len(records))
+		}
+	}
+	return report, nil
+}
+
+func (s *catalogImportService) StartImportJob(actorID uint, data []byte, format, filename string) (*models.Job, error) {
+	params := map[string]interface{}{
+		"format":   format,
+		"filename": filename,
+		"bytes":    len(data),
+	}
+	return s.jobRunner.Start(actorID, models.JobTypeCatalogImport, params, func(ctx context.Context, progress JobProgress) (interface{}, error) {
+		processed := 0
+		report, err := s.Import(ctx, data, format, func(done, total int) {
+			progress.SetTotal(total)
+			progress.Advance(done - processed)
+			processed = done
+		})
+		if report == nil {
+			return nil, err
+		}
+
+		for _, row := range report.Rows {
+			if row.Status == ImportRowInvalid {
+				progress.LogError("row %d (%s): %s", row.Row, row.SpotifyID, strings.Join(row.Errors, "; "))
+			}
+		}
+		s.auditImport(actorID, report)
+		return report, err
+	})
+}
+
+func (s *catalogImportService) auditImport(actorID uint, report *ImportReport) {
+	entry := &models.AuditLog{
+		ActorID:    actorID,
+		Action:     models.AuditSongImport,
+		EntityType: models.AuditEntitySong,
+		Details: map[string]interface{}{
+			"total":   report.Total,
+			"created": report.Created,
+			"updated": report.Updated,
+			"skipped": report.Skipped,
+			"invalid": report.Invalid,
+		},
+	}
+	if err := s.auditRepo.CreateAuditLog(entry); err != nil {
+		log.Printf("⚠️ Failed to write audit log for import: %v", err)
+	}
+	log.Printf("📥 Import finished: %d created, %d updated, %d skipped, %d invalid",
+		report.Created, report.Updated, report.Skipped, report.Invalid)
+}
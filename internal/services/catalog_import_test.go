@@ -0,0 +1,217 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"back_music/internal/models"
+	"back_music/internal/repository"
+)
+
+func TestReadImportRecordsCSVContinuesPastBadRows(t *testing.T) {
+	data := strings.Join([]string{
+		"spotify_id,title,artist",
+		"id-1,First,Artist A",
+		`id-2,"Broken "quote",Artist B`,
+		"id-3,Short row",
+		"id-4,Extra,Artist D,unexpected",
+		"id-5,Last,Artist E",
+	}, "\n")
+
+	records, err := readImportRecords([]byte(data), ImportFormatCSV)
+	if err != nil {
+		t.Fatalf("readImportRecords: %v", err)
+	}
+	if len(records) != 5 {
+		t.Fatalf("records = %d, want 5", len(records))
+	}
+	if records[0]["title"] != "First" || records[4]["spotify_id"] != "id-5" {
+		t.Errorf("valid rows not parsed: %v, %v", records[0], records[4])
+	}
+	if !strings.HasPrefix(records[1]["_error"], "invalid CSV row") {
+		t.Errorf("bad quote row = %v, want _error", records[1])
+	}
+	if records[2]["title"] != "Short row" || records[2]["artist"] != "" {
+		t.Errorf("short row = %v", records[2])
+	}
+	if records[3]["artist"] != "Artist D" {
+		t.Errorf("long row = %v", records[3])
+	}
+}
+
+func TestReadImportRecordsJSONLContinuesPastBadLines(t *testing.T) {
+	data := strings.Join([]string{
+		`{"spotify_id":"id-1","title":"First","popularity":42}`,
+		`{"spotify_id":"id-2",`,
+		"",
+		`{"spotify_id":"id-3","title":"` + strings.Repeat("x", importMaxLineSize) + `"}`,
+		`{"spotify_id":"id-4","title":"Last"}`,
+	}, "\n")
+
+	records, err := readImportRecords([]byte(data), ImportFormatJSONL)
+	if err != nil {
+		t.Fatalf("readImportRecords: %v", err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("records = %d, want 4", len(records))
+	}
+	if records[0]["popularity"] != "42" {
+		t.Errorf("first record = %v", records[0])
+	}
+	if !strings.HasPrefix(records[1]["_error"], "invalid JSON") {
+		t.Errorf("broken line = %v, want _error", records[1])
+	}
+	if !strings.Contains(records[2]["_error"], "longer than") {
+		t.Errorf("long line = %v, want _error", records[2])
+	}
+	if records[3]["title"] != "Last" {
+		t.Errorf("last record = %v", records[3])
+	}
+}
+
+// fakeImportSongRepo menyimpan lagu di memori; method lain dari SongRepository tidak
+// dipakai importRow (dan panic jika terpanggil).
+type fakeImportSongRepo struct {
+	repository.SongRepository
+	songs   map[string]*models.Song
+	lookErr error
+	created []string
+	updated []string
+}
+
+func (r *fakeImportSongRepo) GetSongBySpotifyID(spotifyID string) (*models.Song, error) {
+	if r.lookErr != nil {
+		return nil, r.lookErr
+	}
+	song, ok := r.songs[spotifyID]
+	if !ok {
+		return nil, repository.ErrSongNotFound
+	}
+	copied := *song
+	return &copied, nil
+}
+
+func (r *fakeImportSongRepo) CreateSong(song *models.Song) error {
+	song.ID = "song-" + song.SpotifyID
+	r.songs[song.SpotifyID] = song
+	r.created = append(r.created, song.SpotifyID)
+	return nil
+}
+
+func (r *fakeImportSongRepo) UpdateSong(song *models.Song) error {
+	r.songs[song.SpotifyID] = song
+	r.updated = append(r.updated, song.SpotifyID)
+	return nil
+}
+
+type fakeImportArtistRepo struct {
+	repository.ArtistRepository
+	linked map[string][]models.ArtistCredit
+}
+
+func (r *fakeImportArtistRepo) LinkSongArtists(songID string, credits []models.ArtistCredit) error {
+	r.linked[songID] = credits
+	return nil
+}
+
+func TestImportRow(t *testing.T) {
+	newService := func() (*catalogImportService, *fakeImportSongRepo, *fakeImportArtistRepo) {
+		songs := &fakeImportSongRepo{songs: map[string]*models.Song{
+			"existing": {ID: "song-existing", SpotifyID: "existing", Title: "Old Title", Artist: "Artist", Popularity: 40},
+		}}
+		artists := &fakeImportArtistRepo{linked: map[string][]models.ArtistCredit{}}
+		return &catalogImportService{songRepo: songs, artistRepo: artists}, songs, artists
+	}
+
+	tests := []struct {
+		name       string
+		record     importRecord
+		lookErr    error
+		wantStatus string
+		wantError  string
+		wantSongID string
+	}{
+		{
+			name:       "new song is created",
+			record:     importRecord{"spotify_id": "new", "title": "New Song", "artist": "A, B", "popularity": "70"},
+			wantStatus: ImportRowCreated,
+			wantSongID: "song-new",
+		},
+		{
+			name:       "changed song is updated",
+			record:     importRecord{"spotify_id": "existing", "title": "New Title"},
+			wantStatus: ImportRowUpdated,
+			wantSongID: "song-existing",
+		},
+		{
+			name:       "identical song is skipped",
+			record:     importRecord{"spotify_id": "existing", "title": "Old Title", "popularity": "40"},
+			wantStatus: ImportRowSkipped,
+			wantSongID: "song-existing",
+		},
+		{
+			name:       "missing spotify_id",
+			record:     importRecord{"title": "No ID", "artist": "Artist"},
+			wantStatus: ImportRowInvalid,
+			wantError:  "spotify_id is required",
+		},
+		{
+			name:       "new song without artist",
+			record:     importRecord{"spotify_id": "new", "title": "Lonely"},
+			wantStatus: ImportRowInvalid,
+			wantError:  "artist is required",
+		},
+		{
+			name:       "out of range feature",
+			record:     importRecord{"spotify_id": "existing", "energy": "1.5"},
+			wantStatus: ImportRowInvalid,
+			wantError:  "energy",
+		},
+		{
+			name:       "parse error from reader",
+			record:     importRecord{"_error": "invalid JSON: unexpected end"},
+			wantStatus: ImportRowInvalid,
+			wantError:  "invalid JSON",
+		},
+		{
+			name:       "lookup failure",
+			record:     importRecord{"spotify_id": "new", "title": "New Song", "artist": "A"},
+			lookErr:    errors.New("connection refused"),
+			wantStatus: ImportRowInvalid,
+			wantError:  "lookup failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, songs, artists := newService()
+			songs.lookErr = tt.lookErr
+
+			result := s.importRow(1, tt.record)
+			if result.Status != tt.wantStatus {
+				t.Fatalf("status = %s (%v), want %s", result.Status, result.Errors, tt.wantStatus)
+			}
+			if result.SongID != tt.wantSongID {
+				t.Errorf("song id = %q, want %q", result.SongID, tt.wantSongID)
+			}
+			if tt.wantError != "" && (len(result.Errors) == 0 || !strings.Contains(strings.Join(result.Errors, "; "), tt.wantError)) {
+				t.Errorf("errors = %v, want %q", result.Errors, tt.wantError)
+			}
+
+			switch tt.wantStatus {
+			case ImportRowCreated:
+				if len(songs.created) != 1 || len(artists.linked[result.SongID]) != 2 {
+					t.Errorf("created = %v, artist credits = %v", songs.created, artists.linked)
+				}
+			case ImportRowUpdated:
+				if len(songs.updated) != 1 || songs.songs["existing"].Title != "New Title" {
+					t.Errorf("updated = %v, song = %+v", songs.updated, songs.songs["existing"])
+				}
+			default:
+				if len(songs.created)+len(songs.updated) != 0 {
+					t.Errorf("unexpected writes: created %v, updated %v", songs.created, songs.updated)
+				}
+			}
+		})
+	}
+}
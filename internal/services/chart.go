@@ -0,0 +1,333 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"back_music/internal/models"
+	"back_music/internal/repository"
+)
+
+const (
+	chartSize      = 50
+	genreChartSize = 20
+	// Minimal play di window sekarang supaya lagu bisa masuk chart rising
+	risingMinPlays = 3
+)
+
+type ChartService interface {
+	ComputeCharts(snapshotDate time.Time) error
+	GetChart(chartType, period, genre string, snapshotDate time.Time, limit int) ([]models.ChartEntry, time.Time, error)
+	GetChartGenres(period string, snapshotDate time.Time) ([]string, error)
+	StartScheduler(ctx context.Context)
+}
+
+type chartService struct {
+	playEventRepo repository.PlayEventRepository
+	chartRepo     repository.ChartRepository
+
+	mu           sync.Mutex
+	lastComputed time.Time
+}
+
+func NewChartService(playEventRepo repository.PlayEventRepository, chartRepo repository.ChartRepository) ChartService {
+	return &chartService{
+		playEventRepo: playEventRepo,
+		chartRepo:     chartRepo,
+	}
+}
+
+// chartDay memotong waktu ke tanggal (UTC).
+func chartDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// chartWindow mengembalikan [from, to) untuk snapshot: daily = hari itu,
+// weekly = 7 hari yang berakhir di hari snapshot.
+func chartWindow(period string, snapshotDate time.Time) (time.Time, time.Time) {
+	to := snapshotDate.AddDate(0, 0, 1)
+	if period == models.ChartPeriodWeekly {
+		return snapshotDate.AddDate(0, 0, -6), to
+	}
+	return snapshotDate, to
+}
+
+func previousSnapshotDate(period string, snapshotDate time.Time) time.Time {
+	if period == models.ChartPeriodWeekly {
+		return snapshotDate.AddDate(0, 0, -7)
+	}
+	return snapshotDate.AddDate(0, 0, -1)
+}
+
+// ComputeCharts menghitung semua chart (top songs, top artists, per genre, rising)
+// untuk periode daily dan weekly yang berakhir di snapshotDate.
+func (s *chartService) ComputeCharts(snapshotDate time.Time) error {
+	snapshotDate = chartDay(snapshotDate)
+
+	for _, period := range []string{models.ChartPeriodDaily, models.ChartPeriodWeekly} {
+		from, to := chartWindow(period, snapshotDate)
+
+		// Top songs
+		songStats, err := s.playEventRepo.GetTopSongs(from, to, "", chartSize)
+		if err != nil {
+			return err
+		}
+		if err := s.saveSongChart(models.ChartTopSongs, period, "", snapshotDate, songStats); err != nil {
+			return err
+		}
+
+		// Top artists
+		artistStats, err := s.playEventRepo.GetTopArtists(from, to, chartSize)
+		if err != nil {
+			return err
+		}
+		if err := s.saveArtistChart(period, snapshotDate, artistStats); err != nil {
+			return err
+		}
+
+		// Per genre
+		genres, err := s.playEventRepo.GetPlayedGenres(from, to)
+		if err != nil {
+			return err
+		}
+		for _, genre := range genres {
+			stats, err := s.playEventRepo.GetTopSongs(from, to, genre, genreChartSize)
+			if err != nil {
+				return err
+			}
+			if err := s.saveSongChart(models.ChartGenre, period, genre, snapshotDate, stats); err != nil {
+				return err
+			}
+		}
+
+		// Rising: bandingkan dengan window sebelumnya yang sama panjang
+		if err := s.computeRising(period, snapshotDate, from, to); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("📈 Charts computed for %s", snapshotDate.Format("2006-01-02"))
+	return nil
+}
+
+func (s *chartService) computeRising(period string, snapshotDate, from, to time.Time) error {
+	previousFrom := from.Add(-to.Sub(from))
+	velocities, err := s.playEventRepo.GetSongVelocities(previousFrom, from, to)
+	if err != nil {
+		return err
+	}
+
+	type rising struct {
+		stat     repository.SongPlayVelocity
+		velocity float64
+	}
+	candidates := make([]rising, 0, len(velocities))
+	for _, v := range velocities {
+		if v.CurrentPlays < risingMinPlays || v.CurrentPlays <= v.PreviousPlays {
+			continue
+		}
+		base := v.PreviousPlays
+		if base == 0 {
+			base = 1
+		}
+		candidates = append(candidates, rising{
+			stat:     v,
+			velocity: float64(v.CurrentPlays-v.PreviousPlays) / float64(base),
+		})
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].velocity == candidates[j].velocity {
+			return candidates[i].stat.CurrentPlays > candidates[j].stat.CurrentPlays
+		}
+		return candidates[i].velocity > candidates[j].velocity
+	})
+	if len(candidates) > chartSize {
+		candidates = candidates[:chartSize]
+	}
+
+	previousRanks := s.previousRanks(models.ChartRising, period, "", snapshotDate)
+	entries := make([]models.ChartEntry, 0, len(candidates))
+	for i, c := range candidates {
+		songID := c.stat.SongID
+		entry := models.ChartEntry{
+			ChartType:    models.ChartRising,
+			Period:       period,
+			SnapshotDate: snapshotDate,
+			Rank:         i + 1,
+			SongID:       &songID,
+			Artist:       c.stat.Artist,
+			Plays:        c.stat.CurrentPlays,
+			Listeners:    c.stat.Listeners,
+			Velocity:     c.velocity,
+		}
+		applyMovement(&entry, previousRanks[songID])
+		entries = append(entries, entry)
+	}
+
+	return s.chartRepo.ReplaceChart(models.ChartRising, period, "", snapshotDate, entries)
+}
+
+func (s *chartService) saveSongChart(chartType, period, genre string, snapshotDate time.Time, stats []repository.SongPlayStat) error {
+	previousRanks := s.previousRanks(chartType, period, genre, snapshotDate)
+
+	entries := make([]models.ChartEntry, 0, len(stats))
+	for i, stat := range stats {
+		songID := stat.SongID
+		entry := models.ChartEntry{
+			ChartType:    chartType,
+			Period:       period,
+			Genre:        genre,
+			SnapshotDate: snapshotDate,
+			Rank:         i + 1,
+			SongID:       &songID,
+			Artist:       stat.Artist,
+			Plays:        stat.Plays,
+			Listeners:    stat.Listeners,
+		}
+		applyMovement(&entry, previousRanks[songID])
+		entries = append(entries, entry)
+	}
+
+	return s.chartRepo.ReplaceChart(chartType, period, genre, snapshotDate, entries)
+}
+
+func (s *chartService) saveArtistChart(period string, snapshotDate time.Time, stats []repository.ArtistPlayStat) error {
+	previousRanks := s.previousRanks(models.ChartTopArtists, period, "", snapshotDate)
+
+	entries := make([]models.ChartEntry, 0, len(stats))
+	for i, stat := range stats {
+		artistID := stat.ArtistID
+		entry := models.ChartEntry{
+			ChartType:    models.ChartTopArtists,
+			Period:       period,
+			SnapshotDate: snapshotDate,
+			Rank:         i + 1,
+			ArtistID:     &artistID,
+			Artist:       stat.Artist,
+			Plays:        stat.Plays,
+			Listeners:    stat.Listeners,
+		}
+		previousRank, ok := previousRanks[stat.ArtistID]
+		if !ok {
+			// Snapshot sebelum chart memakai artist ID hanya menyimpan nama
+			previousRank = previousRanks[stat.Artist]
+		}
+		applyMovement(&entry, previousRank)
+		entries = append(entries, entry)
+	}
+
+	return s.chartRepo.ReplaceChart(models.ChartTopArtists, period, "", snapshotDate, entries)
+}
+
+// previousRanks memetakan song ID (atau artist ID untuk chart artist; nama artist untuk
+// snapshot lama) ke rank di snapshot sebelumnya.
+func (s *chartService) previousRanks(chartType, period, genre string, snapshotDate time.Time) map[string]int {
+	ranks := make(map[string]int)
+	previous, err := s.chartRepo.GetChart(chartType, period, genre, previousSnapshotDate(period, snapshotDate), chartSize)
+	if err != nil {
+		log.Printf("⚠️ Failed to load previous %s/%s chart: %v", chartType, period, err)
+		return ranks
+	}
+	for _, entry := range previous {
+		key := entry.Artist
+		if entry.SongID != nil {
+			key = *entry.SongID
+		} else if entry.ArtistID != nil {
+			key = *entry.ArtistID
+		}
+		ranks[key] = entry.Rank
+	}
+	return ranks
+}
+
+func applyMovement(entry *models.ChartEntry, previousRank int) {
+	switch {
+	case previousRank == 0:
+		entry.Movement = models.ChartMovementNew
+		return
+	case entry.Rank < previousRank:
+		entry.Movement = models.ChartMovementUp
+	case entry.Rank > previousRank:
+		entry.Movement = models.ChartMovementDown
+	default:
+		entry.Movement = models.ChartMovementSame
+	}
+	entry.PreviousRank = &previousRank
+}
+
+// GetChart mengambil snapshot chart. Kalau snapshotDate zero, dipakai snapshot terbaru.
+func (s *chartService) GetChart(chartType, period, genre string, snapshotDate time.Time, limit int) ([]models.ChartEntry, time.Time, error) {
+	if snapshotDate.IsZero() {
+		latest, err := s.chartRepo.GetLatestSnapshotDate(chartType, period, genre)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		snapshotDate = latest
+	}
+	snapshotDate = chartDay(snapshotDate)
+
+	entries, err := s.chartRepo.GetChart(chartType, period, genre, snapshotDate, limit)
+	if err != nil {
+		return nil, snapshotDate, err
+	}
+	if len(entries) == 0 {
+		return nil, snapshotDate, repository.ErrChartNotFound
+	}
+	return entries, snapshotDate, nil
+}
+
+func (s *chartService) GetChartGenres(period string, snapshotDate time.Time) ([]string, error) {
+	return s.chartRepo.GetChartGenres(period, chartDay(snapshotDate))
+}
+
+// StartScheduler memastikan snapshot untuk hari kemarin (hari yang sudah lengkap)
+// tersedia; dicek tiap jam dan berhenti ketika ctx dibatalkan.
+func (s *chartService) StartScheduler(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+
+		for {
+			s.runIfDue()
+
+			select {
+			case <-ctx.Done():
+				log.Println("🛑 [Charts] Scheduler stopped")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (s *chartService) runIfDue() {
+	yesterday := chartDay(time.Now()).AddDate(0, 0, -1)
+
+	s.mu.Lock()
+	done := s.lastComputed.Equal(yesterday)
+	s.mu.Unlock()
+	if done {
+		return
+	}
+
+	exists, err := s.chartRepo.HasSnapshot(models.ChartPeriodDaily, yesterday)
+	if err != nil {
+		log.Printf("⚠️ [Charts] snapshot check failed: %v", err)
+		return
+	}
+	if !exists {
+		if err := s.ComputeCharts(yesterday); err != nil {
+			log.Printf("⚠️ [Charts] computation failed: %v", err)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	s.lastComputed = yesterday
+	s.mu.Unlock()
+}
@@ -211,10 +211,31 @@ func (s *collaborativeService) GetCollaborativeRecommendations(userID uint, limi
         score = (genreScore * 0.5) + (popularityScore * 0.15) + (diversityBonus * 0.25) + (artistBonus * 0.1)
         
         if score > 0.05 { // Lower threshold to allow more diverse results
+            // Semua hasil collaborative berasal dari selera user lain ("Based on users with
+            // similar tastes"); bagian eksplorasi (diversity + artist baru) dicatat di sini
+            reasons := []models.RecommendationReason{{
+                Type:         models.ReasonLikedBySimilarUsers,
+                Contribution: math.Round((diversityBonus*0.25+artistBonus*0.1)*1000) / 1000,
+            }}
+            if genreScore > 0 {
+                reasons = append(reasons, models.RecommendationReason{
+                    Type:         models.ReasonSameGenre,
+                    Value:        song.Genre,
+                    Contribution: math.Round(genreScore*0.5*1000) / 1000,
+                })
+            }
+            if popularityScore > 0 {
+                reasons = append(reasons, models.RecommendationReason{
+                    Type:         models.ReasonPopular,
+                    Contribution: math.Round(popularityScore*0.15*1000) / 1000,
+                })
+            }
+            
             scores = append(scores, models.RecommendationScore{
                 Song:      song,
                 Score:     score,
                 ScoreType: "collaborative",
+                Reasons:   reasons,
             })
         }
     }
@@ -11,6 +11,14 @@ import (
 	"back_music/internal/repository"
 )
 
+// Komposisi skor content-based, dipakai juga untuk menghitung kontribusi reason
+const (
+    audioSimilarityWeight = 0.7
+    diversityWeight       = 0.3
+    sameArtistBoost       = 0.15
+    sameGenreBoost        = 0.08
+)
+
 type ContentBasedService interface {
     GetContentBasedRecommendations(songID string, limit int) ([]models.RecommendationScore, error)
     CalculateSimilarity(song1, song2 *models.Song) float64
@@ -18,38 +26,24 @@ type ContentBasedService interface {
 }
 
 type contentBasedService struct {
-    songRepo repository.SongRepository
-    config   *config.Config
+    songRepo     repository.SongRepository
+    featureStats FeatureStatsService
+    config       *config.Config
 }
 
-func NewContentBasedService(songRepo repository.SongRepository) ContentBasedService {
+func NewContentBasedService(songRepo repository.SongRepository, featureStats FeatureStatsService) ContentBasedService {
     return &contentBasedService{
-        songRepo: songRepo,
-        config:   config.GlobalConfig,
+        songRepo:     songRepo,
+        featureStats: featureStats,
+        config:       config.GlobalConfig,
     }
 }
 
 func (s *contentBasedService) BuildFeatureVector(song *models.Song) []float64 {
-    // Combine audio features into a vector for similarity calculation
-    // Weights can be adjusted based on importance
-    features := []float64{
-        song.Danceability,
-        song.Energy,
-        float64(song.Key) / 11.0, // Normalize key (0-11) to 0-1
-        (song.Loudness + 60) / 60.0, // Normalize loudness (-60 to 0) to 0-1
-        float64(song.Mode),
-        song.Speechiness,
-        song.Acousticness,
-        song.Instrumentalness,
-        song.Liveness,
-        song.Valence,
-        song.Tempo / 250.0, // Normalize tempo (0-250) to 0-1
-        float64(song.TimeSignature) / 7.0, // Normalize time signature (3-7) to 0-1
-    }
-    
-    // Add popularity (normalized 0-100 ke 0-0.5) supaya
-    // pengaruh popularitas tidak terlalu mendominasi dibanding fitur audio.
-    features = append(features, (float64(song.Popularity)/100.0)*0.5)
+    // Setiap fitur dinormalisasi dengan statistik katalog (z-score / quantile)
+    // lalu dikali bobot per fitur, jadi tidak ada lagi range hardcoded
+    // seperti loudness -60..0 atau tempo /250 yang mendominasi cosine.
+    features := s.featureStats.NormalizeSong(song)
     
     song.FeatureVector = features
     return features
@@ -80,25 +74,28 @@ func (s *contentBasedService) CalculateSimilarity(song1, song2 *models.Song) flo
         return 0
     }
     
-    similarity := dotProduct / (norm1 * norm2)
+    // Vector sudah di-center (mean = 0), jadi cosine bisa negatif. Cosine negatif
+    // (profil audio berlawanan) dianggap tidak mirip sama sekali; cosine positif dipakai
+    // apa adanya supaya SIMILARITY_THRESHOLD tetap berarti ambang cosine yang sama.
+    similarity := math.Max(dotProduct/(norm1*norm2), 0)
     
     // Calculate diversity score to spread results (IMPROVED)
     diversityScore := s.calculateDiversityScore(song1, song2)
     
     // Combine: 70% audio similarity, 30% diversity consideration
-    baseScore := similarity * 0.7
-    diverseScore := diversityScore * 0.3
+    baseScore := similarity * audioSimilarityWeight
+    diverseScore := diversityScore * diversityWeight
     combinedScore := baseScore + diverseScore
     
     // REDUCED boosts to differentiate results more:
     // - Same artist: +0.15 (was 0.3) - avoid clustering same artist
     // - Same genre: +0.08 (was 0.2) - encourage genre exploration
     if strings.EqualFold(song1.Artist, song2.Artist) {
-        combinedScore += 0.15
+        combinedScore += sameArtistBoost
     }
     
     if strings.EqualFold(song1.Genre, song2.Genre) && song1.Genre != "" {
-        combinedScore += 0.08
+        combinedScore += sameGenreBoost
     }
     
     // Ensure similarity doesn't exceed 1.0
@@ -215,6 +212,7 @@ func (s *contentBasedService) GetContentBasedRecommendations(songID string, limi
                 Score:       similarity,
                 ScoreType:   "content",
                 Explanation: explanation,
+                Reasons:     s.generateReasons(targetSong, &song),
             })
         }
     }
@@ -289,4 +287,69 @@ func (s *contentBasedService) generateExplanation(targetSong, recommendedSong *m
     }
     
     return strings.Join(explanations, " • ")
-}
\ No newline at end of file
+}
+
+// generateReasons memecah skor content-based menjadi reason terstruktur:
+// boost artist/genre apa adanya, dan kontribusi tiap fitur audio terhadap cosine.
+func (s *contentBasedService) generateReasons(targetSong, recommendedSong *models.Song) []models.RecommendationReason {
+    reasons := []models.RecommendationReason{}
+    
+    if strings.EqualFold(targetSong.Artist, recommendedSong.Artist) {
+        reasons = append(reasons, models.RecommendationReason{
+            Type:         models.ReasonSameArtist,
+            Value:        targetSong.Artist,
+            Seed:         targetSong.ID,
+            Contribution: sameArtistBoost,
+        })
+    }
+    
+    if strings.EqualFold(targetSong.Genre, recommendedSong.Genre) && targetSong.Genre != "" {
+        reasons = append(reasons, models.RecommendationReason{
+            Type:         models.ReasonSameGenre,
+            Value:        targetSong.Genre,
+            Seed:         targetSong.ID,
+            Contribution: sameGenreBoost,
+        })
+    }
+    
+    v1, v2 := targetSong.FeatureVector, recommendedSong.FeatureVector
+    if len(v1) == 0 || len(v1) != len(v2) {
+        return reasons
+    }
+    
+    var norm1, norm2 float64
+    for i := range v1 {
+        norm1 += v1[i] * v1[i]
+        norm2 += v2[i] * v2[i]
+    }
+    if norm1 == 0 || norm2 == 0 {
+        return reasons
+    }
+    denominator := math.Sqrt(norm1) * math.Sqrt(norm2)
+    
+    // Kontribusi fitur i = bagian dari cosine yang berasal dari dimensi i,
+    // diskalakan sama seperti di CalculateSimilarity ((cos+1)/2 * bobot audio)
+    names := s.featureStats.FeatureNames()
+    featureReasons := make([]models.RecommendationReason, 0, len(v1))
+    for i := range v1 {
+        contribution := v1[i] * v2[i] / denominator / 2 * audioSimilarityWeight
+        if contribution < 0.01 {
+            continue
+        }
+        featureReasons = append(featureReasons, models.RecommendationReason{
+            Type:         models.ReasonSimilarFeature,
+            Feature:      names[i],
+            Seed:         targetSong.ID,
+            Contribution: math.Round(contribution*1000) / 1000,
+        })
+    }
+    
+    sort.Slice(featureReasons, func(i, j int) bool {
+        return featureReasons[i].Contribution > featureReasons[j].Contribution
+    })
+    if len(featureReasons) > 3 {
+        featureReasons = featureReasons[:3]
+    }
+    
+    return append(reasons, featureReasons...)
+}
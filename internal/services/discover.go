@@ -0,0 +1,316 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"back_music/internal/config"
+	"back_music/internal/models"
+	"back_music/internal/repository"
+)
+
+const (
+	discoverCollaborativeWeight = 0.4
+	discoverContentWeight       = 0.4
+	discoverNoveltyWeight       = 0.2
+
+	// Batasi jumlah lagu per artist supaya playlist tidak didominasi satu artist
+	discoverMaxPerArtist = 2
+	discoverSeedCount    = 3
+)
+
+type DiscoverService interface {
+	GenerateForUser(userID uint, snapshotDate time.Time) (*models.Playlist, error)
+	GenerateWeekly(snapshotDate time.Time) (int, error)
+	StartScheduler(ctx context.Context)
+}
+
+type discoverService struct {
+	contentService       ContentBasedService
+	collaborativeService CollaborativeService
+	songRepo             repository.SongRepository
+	userRepo             repository.UserRepository
+	playlistRepo         repository.PlaylistRepository
+	config               *config.Config
+
+	mu          sync.Mutex
+	lastRunWeek time.Time
+}
+
+func NewDiscoverService(
+	content ContentBasedService,
+	collaborative CollaborativeService,
+	songRepo repository.SongRepository,
+	userRepo repository.UserRepository,
+	playlistRepo repository.PlaylistRepository,
+) DiscoverService {
+	return &discoverService{
+		contentService:       content,
+		collaborativeService: collaborative,
+		songRepo:             songRepo,
+		userRepo:             userRepo,
+		playlistRepo:         playlistRepo,
+		config:               config.GlobalConfig,
+	}
+}
+
+// DiscoverSnapshotDate mengembalikan hari Senin (UTC) dari minggu t.
+func DiscoverSnapshotDate(t time.Time) time.Time {
+	t = t.UTC()
+	offset := (int(t.Weekday()) + 6) % 7 // Senin = 0
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, time.UTC)
+}
+
+type discoverCandidate struct {
+	song   models.Song
+	score  float64
+	source string
+	best   float64 // kontribusi terbesar, untuk menentukan source
+}
+
+func (c *discoverCandidate) add(score float64, source string) {
+	c.score += score
+	if score > c.best {
+		c.best = score
+		c.source = source
+	}
+}
+
+// GenerateForUser membuat playlist Discover untuk satu user: lagu yang belum pernah
+// diputar / di-like, gabungan sinyal collaborative, content dan novelty.
+func (s *discoverService) GenerateForUser(userID uint, snapshotDate time.Time) (*models.Playlist, error) {
+	snapshotDate = DiscoverSnapshotDate(snapshotDate)
+	size := s.config.DiscoverPlaylistSize
+
+	user, err := s.userRepo.FindUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool)
+	knownArtists := make(map[string]bool)
+	for _, like := range user.Likes {
+		known[like.SongID] = true
+	}
+	for _, play := range user.Plays {
+		known[play.SongID] = true
+	}
+
+	if len(known) > 0 {
+		ids := make([]string, 0, len(known))
+		for id := range known {
+			ids = append(ids, id)
+		}
+		if knownSongs, err := s.songRepo.GetSongsByIDs(ids); err == nil {
+			for _, song := range knownSongs {
+				knownArtists[strings.ToLower(song.Artist)] = true
+			}
+		}
+	}
+
+	candidates := make(map[string]*discoverCandidate)
+	addCandidate := func(song models.Song, score float64, source string) {
+		if known[song.ID] {
+			return
+		}
+		c, ok := candidates[song.ID]
+		if !ok {
+			c = &discoverCandidate{song: song}
+			candidates[song.ID] = c
+		}
+		c.add(score, source)
+	}
+
+	// Collaborative
+	if recs, err := s.collaborativeService.GetCollaborativeRecommendations(userID, size*2); err == nil {
+		for _, rec := range recs {
+			addCandidate(rec.Song, rec.Score*discoverCollaborativeWeight, "collaborative")
+		}
+	} else {
+		log.Printf("⚠️ [Discover] collaborative failed for user %d: %v", userID, err)
+	}
+
+	// Content-based dari beberapa seed teratas
+	for _, seedID := range s.pickSeeds(user) {
+		recs, err := s.contentService.GetContentBasedRecommendations(seedID, size)
+		if err != nil {
+			continue
+		}
+		for _, rec := range recs {
+			addCandidate(rec.Song, rec.Score*discoverContentWeight, "content")
+		}
+	}
+
+	// Novelty: lagu acak dari artist yang belum pernah didengar, prioritaskan yang kurang populer
+	if randomSongs, err := s.songRepo.GetRandomSongs(size * 3); err == nil {
+		for _, song := range randomSongs {
+			if knownArtists[strings.ToLower(song.Artist)] {
+				continue
+			}
+			novelty := 1 - float64(song.Popularity)/200.0
+			addCandidate(song, novelty*discoverNoveltyWeight, "novelty")
+		}
+	}
+
+	ranked := make([]*discoverCandidate, 0, len(candidates))
+	for _, c := range candidates {
+		ranked = append(ranked, c)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		return ranked[i].score > ranked[j].score
+	})
+
+	now := time.Now()
+	items := make([]models.PlaylistItem, 0, size)
+	perArtist := make(map[string]int)
+	for _, c := range ranked {
+		if len(items) >= size {
+			break
+		}
+		artist := strings.ToLower(c.song.Artist)
+		if perArtist[artist] >= discoverMaxPerArtist {
+			continue
+		}
+		perArtist[artist]++
+
+		items = append(items, models.PlaylistItem{
+			SongID:   c.song.ID,
+			Position: len(items) + 1,
+			Score:    c.score,
+			Source:   c.source,
+			AddedAt:  now,
+		})
+	}
+
+	if len(items) == 0 {
+		return nil, fmt.Errorf("no discover candidates for user %d", userID)
+	}
+
+	playlist := &models.Playlist{
+		UserID:       userID,
+		Name:         "Discover Weekly",
+		Description:  fmt.Sprintf("Lagu baru untukmu, minggu %s", snapshotDate.Format("2 Jan 2006")),
+		Kind:         models.PlaylistKindDiscoverWeekly,
+		SystemOwned:  true,
+		SnapshotDate: &snapshotDate,
+		Items:        items,
+	}
+	created, err := s.playlistRepo.CreateSnapshotPlaylist(playlist)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		// Scheduler (atau request lain) sudah membuat snapshot minggu ini lebih dulu
+		return s.playlistRepo.GetSnapshotPlaylist(userID, models.PlaylistKindDiscoverWeekly, snapshotDate)
+	}
+
+	log.Printf("🎧 [Discover] Generated %d songs for user %d (week %s)",
+		len(items), userID, snapshotDate.Format("2006-01-02"))
+	return s.playlistRepo.GetPlaylistByID(playlist.ID)
+}
+
+// pickSeeds memilih seed content-based: like terbaru dulu, lalu lagu paling sering diputar.
+func (s *discoverService) pickSeeds(user *models.User) []string {
+	likes := append([]models.UserLike(nil), user.Likes...)
+	sort.Slice(likes, func(i, j int) bool {
+		return likes[i].CreatedAt.After(likes[j].CreatedAt)
+	})
+	plays := append([]models.UserPlay(nil), user.Plays...)
+	sort.Slice(plays, func(i, j int) bool {
+		return plays[i].PlayCount > plays[j].PlayCount
+	})
+
+	seeds := make([]string, 0, discoverSeedCount)
+	seen := make(map[string]bool)
+	add := func(id string) {
+		if len(seeds) < discoverSeedCount && !seen[id] {
+			seen[id] = true
+			seeds = append(seeds, id)
+		}
+	}
+	for _, like := range likes {
+		add(like.SongID)
+	}
+	for _, play := range plays {
+		add(play.SongID)
+	}
+	return seeds
+}
+
+// GenerateWeekly membuat playlist untuk semua user aktif yang belum punya snapshot minggu ini.
+func (s *discoverService) GenerateWeekly(snapshotDate time.Time) (int, error) {
+	snapshotDate = DiscoverSnapshotDate(snapshotDate)
+	since := snapshotDate.AddDate(0, 0, -s.config.DiscoverActiveDays)
+
+	userIDs, err := s.userRepo.GetActiveUserIDs(since)
+	if err != nil {
+		return 0, err
+	}
+
+	log.Printf("🗓️ [Discover] Generating week %s for %d active users",
+		snapshotDate.Format("2006-01-02"), len(userIDs))
+
+	generated := 0
+	for _, userID := range userIDs {
+		exists, err := s.playlistRepo.HasSnapshot(userID, models.PlaylistKindDiscoverWeekly, snapshotDate)
+		if err != nil {
+			log.Printf("⚠️ [Discover] snapshot check failed for user %d: %v", userID, err)
+			continue
+		}
+		if exists {
+			continue
+		}
+		if _, err := s.GenerateForUser(userID, snapshotDate); err != nil {
+			log.Printf("⚠️ [Discover] user %d: %v", userID, err)
+			continue
+		}
+		generated++
+	}
+
+	return generated, nil
+}
+
+// StartScheduler mengecek tiap jam apakah playlist minggu ini sudah dibuat.
+// Berhenti ketika ctx dibatalkan (graceful shutdown).
+func (s *discoverService) StartScheduler(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+
+		for {
+			s.runIfDue()
+
+			select {
+			case <-ctx.Done():
+				log.Println("🛑 [Discover] Scheduler stopped")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (s *discoverService) runIfDue() {
+	week := DiscoverSnapshotDate(time.Now())
+
+	s.mu.Lock()
+	if s.lastRunWeek.Equal(week) {
+		s.mu.Unlock()
+		return
+	}
+	s.mu.Unlock()
+
+	if _, err := s.GenerateWeekly(week); err != nil {
+		log.Printf("⚠️ [Discover] weekly generation failed: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	s.lastRunWeek = week
+	s.mu.Unlock()
+}
@@ -0,0 +1,484 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"back_music/internal/models"
+	"back_music/internal/repository"
+)
+
+// duplicateDurationTolerance: rekaman yang sama biasanya hanya beda beberapa detik
+// (fade, silence di awal/akhir).
+const duplicateDurationTolerance = 3000
+
+var ErrSurvivorNotInCluster = errors.New("survivor song is not part of the cluster")
+
+var (
+	// "(Remastered 2011)", "[Single Version]", "(Radio Edit)"
+	versionParenPattern = regexp.MustCompile(`(?i)\s*[(\[][^)\]]*\b(remaster(ed)?|version|edit|mono|stereo|single|deluxe)\b[^)\]]*[)\]]`)
+	// "Judul - Remastered 2011", "Judul - Single Version"
+	versionDashPattern = regexp.MustCompile(`(?i)\s+-\s+[^-]*\b(remaster(ed)?|version|edit|mono|stereo|single|deluxe)\b.*$`)
+)
+
+// DuplicateScanResult adalah ringkasan satu kali scan duplikat.
+type DuplicateScanResult struct {
+	SongsScanned int `json:"songs_scanned"`
+	Clusters     int `json:"clusters"`
+	NewClusters  int `json:"new_clusters"`
+	Reopened     int `json:"reopened"`
+	Resolved     int `json:"resolved"`
+}
+
+type DuplicateService interface {
+	Scan() (*DuplicateScanResult, error)
+	ListClusters(status string, limit, offset int) ([]models.DuplicateCluster, int64, error)
+	MergeCluster(actorID uint, clusterID, survivorID string) (*models.DuplicateCluster, error)
+	DismissCluster(actorID uint, clusterID string) (*models.DuplicateCluster, error)
+}
+
+type duplicateService struct {
+	duplicateRepo  repository.DuplicateRepository
+	songRepo       repository.SongRepository
+	catalogService CatalogService
+}
+
+func NewDuplicateService(
+	duplicateRepo repository.DuplicateRepository,
+	songRepo repository.SongRepository,
+	catalogService CatalogService,
+) DuplicateService {
+	return &duplicateService{
+		duplicateRepo:  duplicateRepo,
+		songRepo:       songRepo,
+		catalogService: catalogService,
+	}
+}
+
+// NormalizeRecordingTitle membuang penanda versi (remaster, single version, edit)
+// supaya judul dari rilis yang berbeda bisa dibandingkan.
+func NormalizeRecordingTitle(title string) string {
+	title = versionParenPattern.ReplaceAllString(title, "")
+	title = versionDashPattern.ReplaceAllString(title, "")
+	return strings.Join(normalizeSuggestText(title), " ")
+}
+
+// normalizeRecordingArtist memakai artist utama saja ("A, B" -> "a").
+func normalizeRecordingArtist(artist string) string {
+	names := SplitArtistNames(artist)
+	if len(names) == 0 {
+		return ""
+	}
+	return strings.Join(normalizeSuggestText(names[0]), " ")
+}
+
+type duplicateCandidate struct {
+	matchKey string
+	// family: prefix match key untuk artist + judul yang sama. Bucket durasi bisa bergeser
+	// saat anggota cluster berubah, jadi cluster lama dicari lewat family + anggota yang sama.
+	family  string
+	reason  string
+	songIDs []string
+}
+
+// findDuplicateCandidates mengelompokkan lagu berdasarkan ISRC, lalu berdasarkan
+// artist + judul ter-normalisasi dengan durasi yang berdekatan.
+func findDuplicateCandidates(songs []models.Song) []duplicateCandidate {
+	var candidates []duplicateCandidate
+
+	byISRC := make(map[string][]string)
+	isrcGroup := make(map[string]string)
+	for _, song := range songs {
+		isrc := strings.ToUpper(strings.TrimSpace(song.ISRC))
+		if isrc == "" {
+			continue
+		}
+		byISRC[isrc] = append(byISRC[isrc], song.ID)
+		isrcGroup[song.ID] = isrc
+	}
+	for isrc, ids := range byISRC {
+		if len(ids) > 1 {
+			candidates = append(candidates, duplicateCandidate{
+				matchKey: "isrc:" + isrc,
+				reason:   models.DuplicateReasonISRC,
+				songIDs:  ids,
+			})
+		}
+	}
+
+	byMetadata := make(map[string][]models.Song)
+	usedKeys := make(map[string]bool)
+	for _, song := range songs {
+		artist := normalizeRecordingArtist(song.Artist)
+		title := NormalizeRecordingTitle(song.Title)
+		if artist == "" || title == "" {
+			continue
+		}
+		key := artist + "|" + title
+		byMetadata[key] = append(byMetadata[key], song)
+	}
+
+	for key, group := range byMetadata {
+		if len(group) < 2 {
+			continue
+		}
+		sort.Slice(group, func(i, j int) bool { return group[i].DurationMs < group[j].DurationMs })
+
+		// Pecah menjadi run dengan selisih durasi berurutan <= toleransi
+		run := []models.Song{group[0]}
+		flush := func() {
+			if len(run) < 2 || coveredBySameISRC(run, isrcGroup) {
+				return
+			}
+			ids := make([]string, len(run))
+			for i, song := range run {
+				ids[i] = song.ID
+			}
+			// Bucket dari durasi median, bukan anggota terpendek, supaya tidak bergeser
+			// setiap ada rekaman yang sedikit lebih pendek
+			family := "meta:" + key + "|"
+			matchKey := fmt.Sprintf("%s%d", family, run[len(run)/2].DurationMs/10000)
+			// Dua run terpisah bisa punya median di bucket yang sama
+			for n := 2; usedKeys[matchKey]; n++ {
+				matchKey = fmt.Sprintf("%s%d.%d", family, run[len(run)/2].DurationMs/10000, n)
+			}
+			usedKeys[matchKey] = true
+			candidates = append(candidates, duplicateCandidate{
+				matchKey: matchKey,
+				family:   family,
+				reason:   models.DuplicateReasonMetadata,
+				songIDs:  ids,
+			})
+		}
+		for _, song := range group[1:] {
+			if song.DurationMs-run[len(run)-1].DurationMs > duplicateDurationTolerance {
+				flush()
+				run = run[:0:0]
+			}
+			run = append(run, song)
+		}
+		flush()
+	}
+
+	return candidates
+}
+
+// coveredBySameISRC: kelompok metadata yang seluruhnya sudah satu ISRC tidak perlu dilaporkan dua kali.
+func coveredBySameISRC(songs []models.Song, isrcGroup map[string]string) bool {
+	first, ok := isrcGroup[songs[0].ID]
+	if !ok {
+		return false
+	}
+	for _, song := range songs[1:] {
+		if isrcGroup[song.ID] != first {
+			return false
+		}
+	}
+	return true
+}
+
+func containsAll(set []string, ids []string) bool {
+	lookup := make(map[string]bool, len(set))
+	for _, id := range set {
+		lookup[id] = true
+	}
+	for _, id := range ids {
+		if !lookup[id] {
+			return false
+		}
+	}
+	return true
+}
+
+// Scan mencari kandidat duplikat di seluruh katalog dan memperbarui antrian review.
+// Cluster yang sudah di-dismiss hanya dibuka lagi jika ada lagu baru di dalamnya; cluster
+// pending yang tinggal berisi kurang dari 2 lagu ditutup sebagai resolved.
+func (s *duplicateService) Scan() (*DuplicateScanResult, error) {
+	songs, err := s.songRepo.GetAllSongs()
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := findDuplicateCandidates(songs)
+	result := &DuplicateScanResult{SongsScanned: len(songs), Clusters: len(candidates)}
+	// Satu cluster lama hanya dipakai satu kandidat (run yang terpecah membuat cluster baru)
+	claimed := make(map[string]bool)
+
+	for _, candidate := range candidates {
+		sort.Strings(candidate.songIDs)
+
+		cluster, err := s.findExistingCluster(candidate, claimed)
+		if err != nil {
+			return nil, err
+		}
+		if cluster != nil {
+			claimed[cluster.ID] = true
+		}
+
+		switch {
+		case cluster == nil:
+			cluster = &models.DuplicateCluster{
+				MatchKey: candidate.matchKey,
+				Reason:   candidate.reason,
+				Status:   models.DuplicateStatusPending,
+			}
+			result.NewClusters++
+		case cluster.Status == models.DuplicateStatusPending:
+		case containsAll(cluster.SongIDs, candidate.songIDs):
+			// Sudah di-review dan tidak ada lagu baru
+			continue
+		default:
+			cluster.Status = models.DuplicateStatusPending
+			cluster.SurvivorID, cluster.ResolvedBy, cluster.ResolvedAt = nil, nil, nil
+			result.Reopened++
+		}
+
+		cluster.SongIDs = candidate.songIDs
+		if err := s.duplicateRepo.SaveCluster(cluster); err != nil {
+			return nil, err
+		}
+	}
+
+	resolved, err := s.resolveStaleClusters(songs, claimed)
+	if err != nil {
+		return nil, err
+	}
+	result.Resolved = resolved
+
+	log.Printf("🧬 Duplicate scan: %d songs, %d clusters (%d new, %d reopened, %d resolved)",
+		result.SongsScanned, result.Clusters, result.NewClusters, result.Reopened, result.Resolved)
+	return result, nil
+}
+
+// resolveStaleClusters menutup cluster pending yang tidak lagi punya 2 lagu (anggotanya
+// dihapus atau di-merge lewat /admin/songs/:id/merge). Cluster yang masih dipakai
+// kandidat scan ini (claimed) tidak disentuh.
+func (s *duplicateService) resolveStaleClusters(songs []models.Song, claimed map[string]bool) (int, error) {
+	pending, err := s.duplicateRepo.ListClustersByStatus(models.DuplicateStatusPending)
+	if err != nil {
+		return 0, err
+	}
+	existing := make(map[string]bool, len(songs))
+	for _, song := range songs {
+		existing[song.ID] = true
+	}
+
+	resolved := 0
+	now := time.Now()
+	for i := range pending {
+		cluster := &pending[i]
+		if claimed[cluster.ID] {
+			continue
+		}
+		remaining := make([]string, 0, len(cluster.SongIDs))
+		for _, id := range cluster.SongIDs {
+			if existing[id] {
+				remaining = append(remaining, id)
+			}
+		}
+		if len(remaining) >= 2 {
+			continue
+		}
+
+		cluster.Status = models.DuplicateStatusResolved
+		cluster.SongIDs = remaining
+		cluster.ResolvedAt = &now
+		if len(remaining) == 1 {
+			cluster.SurvivorID = &remaining[0]
+		}
+		if err := s.duplicateRepo.SaveCluster(cluster); err != nil {
+			return resolved, err
+		}
+		resolved++
+	}
+	return resolved, nil
+}
+
+// findExistingCluster mencari cluster yang sudah ada untuk kandidat: lewat match key,
+// lalu lewat cluster se-family yang anggotanya beririsan (bucket durasi bergeser).
+// Cluster yang ditemukan lewat irisan diberi match key baru.
+func (s *duplicateService) findExistingCluster(candidate duplicateCandidate, claimed map[string]bool) (*models.DuplicateCluster, error) {
+	cluster, err := s.duplicateRepo.GetClusterByMatchKey(candidate.matchKey)
+	if err == nil {
+		return cluster, nil
+	}
+	if !errors.Is(err, repository.ErrDuplicateClusterNotFound) {
+		return nil, err
+	}
+	if candidate.family == "" {
+		return nil, nil
+	}
+
+	family, err := s.duplicateRepo.ListClustersByMatchKeyPrefix(candidate.family)
+	if err != nil {
+		return nil, err
+	}
+	var best *models.DuplicateCluster
+	bestOverlap := 0
+	for i := range family {
+		// Prefix LIKE juga cocok dengan judul yang lebih panjang ("a|song|" vs "a|song|2|")
+		if claimed[family[i].ID] || !isBucketKey(family[i].MatchKey, candidate.family) {
+			continue
+		}
+		if overlap := countOverlap(family[i].SongIDs, candidate.songIDs); overlap > bestOverlap {
+			best, bestOverlap = &family[i], overlap
+		}
+	}
+	if best != nil {
+		best.MatchKey = candidate.matchKey
+	}
+	return best, nil
+}
+
+func isBucketKey(matchKey, family string) bool {
+	bucket, ok := strings.CutPrefix(matchKey, family)
+	if !ok || bucket == "" {
+		return false
+	}
+	for _, c := range bucket {
+		if (c < '0' || c > '9') && c != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+func countOverlap(set []string, ids []string) int {
+	lookup := make(map[string]bool, len(set))
+	for _, id := range set {
+		lookup[id] = true
+	}
+	count := 0
+	for _, id := range ids {
+		if lookup[id] {
+			count++
+		}
+	}
+	return count
+}
+
+// rankClusterSongs mengurutkan lagu dengan kandidat survivor di depan:
+// popularity tertinggi, lalu yang sudah punya YouTube ID, lalu yang paling lama ada.
+func rankClusterSongs(songs []models.Song) {
+	sort.SliceStable(songs, func(i, j int) bool {
+		if songs[i].Popularity != songs[j].Popularity {
+			return songs[i].Popularity > songs[j].Popularity
+		}
+		if (songs[i].YoutubeID != "") != (songs[j].YoutubeID != "") {
+			return songs[i].YoutubeID != ""
+		}
+		return songs[i].CreatedAt.Before(songs[j].CreatedAt)
+	})
+}
+
+func (s *duplicateService) loadClusterSongs(cluster *models.DuplicateCluster) error {
+	songs, err := s.songRepo.GetSongsByIDs(cluster.SongIDs)
+	if err != nil {
+		return err
+	}
+	rankClusterSongs(songs)
+	cluster.Songs = songs
+	return nil
+}
+
+func (s *duplicateService) ListClusters(status string, limit, offset int) ([]models.DuplicateCluster, int64, error) {
+	clusters, total, err := s.duplicateRepo.ListClusters(status, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range clusters {
+		if clusters[i].Status != models.DuplicateStatusPending {
+			continue
+		}
+		if err := s.loadClusterSongs(&clusters[i]); err != nil {
+			return nil, 0, err
+		}
+	}
+	return clusters, total, nil
+}
+
+// MergeCluster menggabungkan semua lagu di cluster ke survivor (default: kandidat teratas).
+// Survivor dan setiap lagu yang selesai di-merge langsung dicatat di cluster, jadi merge
+// yang gagal di tengah bisa diulang: lagu yang sudah di-merge tidak ada lagi dan survivor
+// default tetap sama.
+func (s *duplicateService) MergeCluster(actorID uint, clusterID, survivorID string) (*models.DuplicateCluster, error) {
+	cluster, err := s.duplicateRepo.GetClusterByID(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.loadClusterSongs(cluster); err != nil {
+		return nil, err
+	}
+	if len(cluster.Songs) == 0 {
+		return nil, repository.ErrSongNotFound
+	}
+
+	if survivorID == "" && cluster.SurvivorID != nil {
+		survivorID = *cluster.SurvivorID
+	}
+	if survivorID == "" {
+		survivorID = cluster.Songs[0].ID
+	}
+	found := false
+	for _, song := range cluster.Songs {
+		if song.ID == survivorID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, ErrSurvivorNotInCluster
+	}
+
+	cluster.SurvivorID = &survivorID
+	if err := s.duplicateRepo.SaveCluster(cluster); err != nil {
+		return nil, err
+	}
+	for _, song := range cluster.Songs {
+		if song.ID == survivorID {
+			continue
+		}
+		if err := s.catalogService.MergeSongs(actorID, song.ID, survivorID); err != nil &&
+			!errors.Is(err, repository.ErrSongNotFound) {
+			return nil, err
+		}
+		cluster.SongIDs = slices.DeleteFunc(cluster.SongIDs, func(id string) bool { return id == song.ID })
+		if err := s.duplicateRepo.SaveCluster(cluster); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	cluster.Status = models.DuplicateStatusMerged
+	cluster.SurvivorID = &survivorID
+	cluster.ResolvedBy = &actorID
+	cluster.ResolvedAt = &now
+	cluster.SongIDs = []string{survivorID}
+	cluster.Songs = nil
+	if err := s.duplicateRepo.SaveCluster(cluster); err != nil {
+		return nil, err
+	}
+	return cluster, nil
+}
+
+func (s *duplicateService) DismissCluster(actorID uint, clusterID string) (*models.DuplicateCluster, error) {
+	cluster, err := s.duplicateRepo.GetClusterByID(clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	cluster.Status = models.DuplicateStatusDismissed
+	cluster.ResolvedBy = &actorID
+	cluster.ResolvedAt = &now
+	if err := s.duplicateRepo.SaveCluster(cluster); err != nil {
+		return nil, err
+	}
+	return cluster, nil
+}
@@ -0,0 +1,347 @@
+package services
+
+import (
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"back_music/internal/config"
+	"back_music/internal/models"
+	"back_music/internal/repository"
+)
+
+const (
+	NormalizationZScore   = "zscore"
+	NormalizationQuantile = "quantile"
+
+	// z-score di-clip supaya outlier (misal loudness -50 dB) tidak mendominasi cosine
+	zScoreClip = 3.0
+	// p0, p5, ..., p100
+	quantileSteps = 20
+
+	// Saat statistik gagal dimuat (mis. DB down), percobaan berikutnya ditunda dengan
+	// backoff supaya NormalizeSong tidak query + log ulang untuk setiap lagu
+	featureStatsRetryMin = 5 * time.Second
+	featureStatsRetryMax = 5 * time.Minute
+)
+
+// audioFeature mendefinisikan satu dimensi di feature vector content-based.
+type audioFeature struct {
+	Name          string
+	DefaultWeight float64
+	Value         func(song *models.Song) float64
+}
+
+// similarityFeatures adalah urutan dimensi feature vector. Fitur diskrit
+// (key, mode, time signature) dan popularity diberi bobot default lebih kecil
+// supaya tidak mengalahkan fitur audio kontinu.
+var similarityFeatures = []audioFeature{
+	{"danceability", 1.0, func(s *models.Song) float64 { return s.Danceability }},
+	{"energy", 1.0, func(s *models.Song) float64 { return s.Energy }},
+	{"key", 0.3, func(s *models.Song) float64 { return float64(s.Key) }},
+	{"loudness", 0.8, func(s *models.Song) float64 { return s.Loudness }},
+	{"mode", 0.5, func(s *models.Song) float64 { return float64(s.Mode) }},
+	{"speechiness", 0.8, func(s *models.Song) float64 { return s.Speechiness }},
+	{"acousticness", 1.0, func(s *models.Song) float64 { return s.Acousticness }},
+	{"instrumentalness", 0.8, func(s *models.Song) float64 { return s.Instrumentalness }},
+	{"liveness", 0.5, func(s *models.Song) float64 { return s.Liveness }},
+	{"valence", 1.0, func(s *models.Song) float64 { return s.Valence }},
+	{"tempo", 0.8, func(s *models.Song) float64 { return s.Tempo }},
+	{"time_signature", 0.3, func(s *models.Song) float64 { return float64(s.TimeSignature) }},
+	{"popularity", 0.5, func(s *models.Song) float64 { return float64(s.Popularity) }},
+}
+
+type FeatureStatsService interface {
+	RefreshStats() ([]models.FeatureStat, error)
+	GetStats() (map[string]models.FeatureStat, error)
+	NormalizeSong(song *models.Song) []float64
+	FeatureNames() []string
+}
+
+type featureStatsService struct {
+	statRepo repository.FeatureStatRepository
+	songRepo repository.SongRepository
+	config   *config.Config
+
+	mu         sync.RWMutex
+	stats      map[string]models.FeatureStat
+	computedAt time.Time
+	refreshing bool
+
+	// loadMu men-serialisasi load(); loadErr di-cache sampai loadRetryAt
+	loadMu      sync.Mutex
+	loadErr     error
+	loadRetryAt time.Time
+	loadBackoff time.Duration
+}
+
+func NewFeatureStatsService(statRepo repository.FeatureStatRepository, songRepo repository.SongRepository) FeatureStatsService {
+	return &featureStatsService{
+		statRepo: statRepo,
+		songRepo: songRepo,
+		config:   config.GlobalConfig,
+	}
+}
+
+func (s *featureStatsService) FeatureNames() []string {
+	names := make([]string, len(similarityFeatures))
+	for i, f := range similarityFeatures {
+		names[i] = f.Name
+	}
+	return names
+}
+
+// RefreshStats menghitung ulang mean/std/quantile tiap fitur dari seluruh katalog
+// lalu menyimpannya ke tabel feature_stats.
+func (s *featureStatsService) RefreshStats() ([]models.FeatureStat, error) {
+	songs, err := s.songRepo.GetAllSongs()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	stats := make([]models.FeatureStat, 0, len(similarityFeatures))
+	values := make([]float64, len(songs))
+
+	for _, feature := range similarityFeatures {
+		for i := range songs {
+			values[i] = feature.Value(&songs[i])
+		}
+		stat := computeFeatureStat(feature.Name, values)
+		stat.ComputedAt = now
+		stats = append(stats, stat)
+	}
+
+	if err := s.statRepo.SaveFeatureStats(stats); err != nil {
+		return nil, err
+	}
+
+	s.setStats(stats, now)
+	log.Printf("📐 Feature stats refreshed from %d songs", len(songs))
+	return stats, nil
+}
+
+func (s *featureStatsService) GetStats() (map[string]models.FeatureStat, error) {
+	s.mu.RLock()
+	stats := s.stats
+	s.mu.RUnlock()
+
+	if stats == nil {
+		var err error
+		if stats, err = s.loadWithBackoff(); err != nil {
+			return nil, err
+		}
+	}
+
+	s.refreshIfStale()
+	return stats, nil
+}
+
+// loadWithBackoff memuat statistik sekali untuk semua caller bersamaan. Kegagalan
+// di-cache dan di-log sekali per percobaan; selama backoff caller langsung mendapat
+// error yang sama.
+func (s *featureStatsService) loadWithBackoff() (map[string]models.FeatureStat, error) {
+	s.loadMu.Lock()
+	defer s.loadMu.Unlock()
+
+	// Caller lain mungkin sudah berhasil memuat selagi menunggu lock
+	s.mu.RLock()
+	stats := s.stats
+	s.mu.RUnlock()
+	if stats != nil {
+		return stats, nil
+	}
+	if s.loadErr != nil && time.Now().Before(s.loadRetryAt) {
+		return nil, s.loadErr
+	}
+
+	if err := s.load(); err != nil {
+		s.loadBackoff = min(max(s.loadBackoff*2, featureStatsRetryMin), featureStatsRetryMax)
+		s.loadErr = err
+		s.loadRetryAt = time.Now().Add(s.loadBackoff)
+		log.Printf("⚠️ Feature stats unavailable, using fixed ranges (retry in %s): %v", s.loadBackoff, err)
+		return nil, err
+	}
+	s.loadErr, s.loadBackoff = nil, 0
+
+	s.mu.RLock()
+	stats = s.stats
+	s.mu.RUnlock()
+	return stats, nil
+}
+
+// NormalizeSong mengembalikan feature vector yang sudah dinormalisasi dan diberi bobot.
+// Kalau statistik belum tersedia, fitur dinormalisasi dengan range tetap (perilaku lama).
+func (s *featureStatsService) NormalizeSong(song *models.Song) []float64 {
+	// Error sudah di-log oleh GetStats; stats nil berarti range tetap
+	stats, _ := s.GetStats()
+
+	vector := make([]float64, len(similarityFeatures))
+	for i, feature := range similarityFeatures {
+		raw := feature.Value(song)
+		stat, ok := stats[feature.Name]
+
+		var normalized float64
+		switch {
+		case !ok || stat.SampleSize == 0:
+			normalized = fixedRangeNormalize(feature.Name, raw)
+		case s.config.FeatureNormalization == NormalizationQuantile:
+			// Geser CDF ke [-1, 1] supaya nilai median = 0 seperti z-score
+			normalized = 2*quantileRank(stat.Quantiles, raw) - 1
+		default:
+			normalized = zScore(stat, raw)
+		}
+
+		vector[i] = normalized * s.weight(feature)
+	}
+	return vector
+}
+
+func (s *featureStatsService) weight(feature audioFeature) float64 {
+	if w, ok := s.config.FeatureWeights[feature.Name]; ok {
+		return w
+	}
+	return feature.DefaultWeight
+}
+
+func (s *featureStatsService) load() error {
+	stored, err := s.statRepo.GetFeatureStats()
+	if err != nil {
+		return err
+	}
+	if len(stored) == 0 {
+		_, err := s.RefreshStats()
+		return err
+	}
+
+	computedAt := stored[0].ComputedAt
+	s.setStats(stored, computedAt)
+	return nil
+}
+
+func (s *featureStatsService) setStats(stats []models.FeatureStat, computedAt time.Time) {
+	byName := make(map[string]models.FeatureStat, len(stats))
+	for _, stat := range stats {
+		byName[stat.Feature] = stat
+	}
+
+	s.mu.Lock()
+	s.stats = byName
+	s.computedAt = computedAt
+	s.mu.Unlock()
+}
+
+// refreshIfStale menghitung ulang statistik di background kalau sudah lewat TTL,
+// tanpa menahan request yang sedang berjalan.
+func (s *featureStatsService) refreshIfStale() {
+	if s.config.FeatureStatsTTL <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	if s.refreshing || time.Since(s.computedAt) < s.config.FeatureStatsTTL {
+		s.mu.Unlock()
+		return
+	}
+	s.refreshing = true
+	s.mu.Unlock()
+
+	go func() {
+		defer func() {
+			s.mu.Lock()
+			s.refreshing = false
+			s.mu.Unlock()
+		}()
+		if _, err := s.RefreshStats(); err != nil {
+			log.Printf("⚠️ Failed to refresh feature stats: %v", err)
+		}
+	}()
+}
+
+func computeFeatureStat(name string, values []float64) models.FeatureStat {
+	stat := models.FeatureStat{Feature: name, SampleSize: len(values)}
+	if len(values) == 0 {
+		return stat
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	var sum float64
+	for _, v := range sorted {
+		sum += v
+	}
+	stat.Mean = sum / float64(len(sorted))
+
+	var variance float64
+	for _, v := range sorted {
+		variance += (v - stat.Mean) * (v - stat.Mean)
+	}
+	stat.StdDev = math.Sqrt(variance / float64(len(sorted)))
+	stat.Min = sorted[0]
+	stat.Max = sorted[len(sorted)-1]
+
+	stat.Quantiles = make([]float64, quantileSteps+1)
+	for i := 0; i <= quantileSteps; i++ {
+		pos := float64(i) / quantileSteps * float64(len(sorted)-1)
+		lower := int(math.Floor(pos))
+		upper := int(math.Ceil(pos))
+		frac := pos - float64(lower)
+		stat.Quantiles[i] = sorted[lower] + (sorted[upper]-sorted[lower])*frac
+	}
+
+	return stat
+}
+
+func zScore(stat models.FeatureStat, value float64) float64 {
+	if stat.StdDev == 0 {
+		return 0
+	}
+	z := (value - stat.Mean) / stat.StdDev
+	return math.Max(-zScoreClip, math.Min(zScoreClip, z))
+}
+
+// quantileRank menginterpolasi posisi value di tabel quantile, hasilnya 0-1.
+func quantileRank(quantiles []float64, value float64) float64 {
+	n := len(quantiles)
+	if n < 2 {
+		return 0.5
+	}
+	if value <= quantiles[0] {
+		return 0
+	}
+	if value >= quantiles[n-1] {
+		return 1
+	}
+
+	idx := sort.SearchFloat64s(quantiles, value)
+	lo, hi := quantiles[idx-1], quantiles[idx]
+	frac := 0.0
+	if hi > lo {
+		frac = (value - lo) / (hi - lo)
+	}
+	return (float64(idx-1) + frac) / float64(n-1)
+}
+
+// fixedRangeNormalize adalah fallback ketika katalog masih kosong.
+// Hasilnya di-center ke [-0.5, 0.5] agar sebanding dengan z-score.
+func fixedRangeNormalize(name string, value float64) float64 {
+	var scaled float64
+	switch name {
+	case "key":
+		scaled = value / 11.0
+	case "loudness":
+		scaled = (value + 60) / 60.0
+	case "tempo":
+		scaled = value / 250.0
+	case "time_signature":
+		scaled = value / 7.0
+	case "popularity":
+		scaled = value / 100.0
+	default:
+		scaled = value
+	}
+	return scaled - 0.5
+}
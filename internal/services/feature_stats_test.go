@@ -0,0 +1,64 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"back_music/internal/config"
+	"back_music/internal/models"
+)
+
+type fakeFeatureStatRepo struct {
+	calls int
+	err   error
+	stats []models.FeatureStat
+}
+
+func (r *fakeFeatureStatRepo) GetFeatureStats() ([]models.FeatureStat, error) {
+	r.calls++
+	return r.stats, r.err
+}
+
+func (r *fakeFeatureStatRepo) SaveFeatureStats(stats []models.FeatureStat) error {
+	return nil
+}
+
+func TestFeatureStatsCachesLoadFailure(t *testing.T) {
+	repo := &fakeFeatureStatRepo{err: errors.New("database is down")}
+	s := &featureStatsService{statRepo: repo, config: &config.Config{}}
+
+	song := &models.Song{Danceability: 0.5, Tempo: 120}
+	for i := 0; i < 100; i++ {
+		if vector := s.NormalizeSong(song); len(vector) != len(similarityFeatures) {
+			t.Fatalf("vector length = %d", len(vector))
+		}
+	}
+	if repo.calls != 1 {
+		t.Fatalf("GetFeatureStats calls = %d, want 1 during backoff", repo.calls)
+	}
+
+	// Setelah backoff lewat, load dicoba lagi dan backoff berikutnya lebih panjang
+	s.loadRetryAt = time.Now().Add(-time.Second)
+	if _, err := s.GetStats(); err == nil {
+		t.Fatal("GetStats succeeded, want cached failure")
+	}
+	if repo.calls != 2 {
+		t.Errorf("GetFeatureStats calls = %d, want 2 after backoff", repo.calls)
+	}
+	if s.loadBackoff != 2*featureStatsRetryMin {
+		t.Errorf("backoff = %s, want %s", s.loadBackoff, 2*featureStatsRetryMin)
+	}
+
+	// Begitu DB pulih, statistik dipakai dan error tidak lagi di-cache
+	repo.err = nil
+	repo.stats = []models.FeatureStat{{Feature: "tempo", Mean: 120, StdDev: 30, SampleSize: 10, ComputedAt: time.Now()}}
+	s.loadRetryAt = time.Now().Add(-time.Second)
+	stats, err := s.GetStats()
+	if err != nil {
+		t.Fatalf("GetStats: %v", err)
+	}
+	if stats["tempo"].Mean != 120 || s.loadErr != nil {
+		t.Errorf("stats = %v, loadErr = %v", stats, s.loadErr)
+	}
+}
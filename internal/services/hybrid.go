@@ -1,6 +1,7 @@
 package services
 
 import (
+	"math"
 	"sort"
 
 	"back_music/internal/config"
@@ -51,6 +52,7 @@ func (s *hybridService) GetHybridRecommendations(userID uint, songID string, lim
         combined := combinedScores[rec.Song.ID]
         combined.Song = rec.Song
         combined.Score += rec.Score * s.config.ContentWeight
+        combined.Reasons = mergeReasons(combined.Reasons, rec.Reasons, s.config.ContentWeight)
         combined.ScoreType = "hybrid"
         combinedScores[rec.Song.ID] = combined
     }
@@ -60,6 +62,7 @@ func (s *hybridService) GetHybridRecommendations(userID uint, songID string, lim
         combined := combinedScores[rec.Song.ID]
         combined.Song = rec.Song
         combined.Score += rec.Score * s.config.CollaborativeWeight
+        combined.Reasons = mergeReasons(combined.Reasons, rec.Reasons, s.config.CollaborativeWeight)
         combined.ScoreType = "hybrid"
         combinedScores[rec.Song.ID] = combined
     }
@@ -93,4 +96,25 @@ func (s *hybridService) GetHybridRecommendations(userID uint, songID string, lim
     }
     
     return finalScores, nil
-}
\ No newline at end of file
+}
+
+// mergeReasons menggabungkan reason dari satu sumber rekomendasi dengan bobot hybrid-nya.
+// Reason yang sama (type + feature + value) dijumlahkan kontribusinya.
+func mergeReasons(existing, incoming []models.RecommendationReason, weight float64) []models.RecommendationReason {
+    for _, reason := range incoming {
+        reason.Contribution = math.Round(reason.Contribution*weight*1000) / 1000
+        
+        merged := false
+        for i := range existing {
+            if existing[i].Type == reason.Type && existing[i].Feature == reason.Feature && existing[i].Value == reason.Value {
+                existing[i].Contribution += reason.Contribution
+                merged = true
+                break
+            }
+        }
+        if !merged {
+            existing = append(existing, reason)
+        }
+    }
+    return existing
+}
@@ -0,0 +1,253 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"back_music/internal/models"
+	"back_music/internal/repository"
+)
+
+const (
+	// jobProgressFlushInterval membatasi seberapa sering progress ditulis ke DB.
+	jobProgressFlushInterval = 2 * time.Second
+	// maxJobErrorLog: error log dipotong supaya row job tidak membengkak.
+	maxJobErrorLog = 200
+)
+
+var (
+	ErrJobNotCancelable = errors.New("job already finished")
+	ErrJobAlreadyActive = errors.New("a job of this type is already queued or running")
+)
+
+// JobProgress dipakai pekerjaan background untuk melaporkan progress dan error per item.
+type JobProgress interface {
+	SetTotal(total int)
+	Advance(n int)
+	LogError(format string, args ...interface{})
+}
+
+// JobFunc adalah isi job. Hasil yang dikembalikan disimpan di Job.Result.
+// ctx dibatalkan saat admin meng-cancel job atau server shutdown.
+type JobFunc func(ctx context.Context, progress JobProgress) (interface{}, error)
+
+type JobRunner interface {
+	Start(actorID uint, jobType string, params map[string]interface{}, fn JobFunc) (*models.Job, error)
+	// StartExclusive seperti Start, tapi menolak dengan ErrJobAlreadyActive jika actor yang
+	// sama masih punya job bertipe sama yang queued/running.
+	StartExclusive(actorID uint, jobType string, params map[string]interface{}, fn JobFunc) (*models.Job, error)
+	Get(id string) (*models.Job, error)
+	List(jobType, status string, limit, offset int) ([]models.Job, int64, error)
+	Cancel(id string) (*models.Job, error)
+	// RecoverInterrupted menandai job yang tertinggal dari proses sebelumnya sebagai failed.
+	RecoverInterrupted()
+	// Shutdown membatalkan semua job yang sedang berjalan.
+	Shutdown()
+}
+
+type jobRunner struct {
+	jobRepo repository.JobRepository
+
+	rootCtx    context.Context
+	stopAll    context.CancelFunc
+	mu         sync.Mutex
+	cancelFunc map[string]context.CancelFunc
+	// startMu menyerialkan cek + create di StartExclusive
+	startMu sync.Mutex
+}
+
+func NewJobRunner(jobRepo repository.JobRepository) JobRunner {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &jobRunner{
+		jobRepo:    jobRepo,
+		rootCtx:    ctx,
+		stopAll:    cancel,
+		cancelFunc: make(map[string]context.CancelFunc),
+	}
+}
+
+func (r *jobRunner) Start(actorID uint, jobType string, params map[string]interface{}, fn JobFunc) (*models.Job, error) {
+	job := &models.Job{
+		Type:      jobType,
+		Status:    models.JobStatusQueued,
+		CreatedBy: actorID,
+		Params:    params,
+	}
+	if err := r.jobRepo.CreateJob(job); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(r.rootCtx)
+	r.mu.Lock()
+	r.cancelFunc[job.ID] = cancel
+	r.mu.Unlock()
+
+	go r.run(ctx, *job, fn)
+	return job, nil
+}
+
+func (r *jobRunner) StartExclusive(actorID uint, jobType string, params map[string]interface{}, fn JobFunc) (*models.Job, error) {
+	r.startMu.Lock()
+	defer r.startMu.Unlock()
+
+	if _, err := r.jobRepo.FindActiveJob(jobType, actorID); err == nil {
+		return nil, ErrJobAlreadyActive
+	} else if !errors.Is(err, repository.ErrJobNotFound) {
+		return nil, err
+	}
+	return r.Start(actorID, jobType, params, fn)
+}
+
+// jobHandle menyimpan state job yang sedang berjalan; hanya goroutine job yang menulis ke DB.
+type jobHandle struct {
+	runner    *jobRunner
+	mu        sync.Mutex
+	job       models.Job
+	lastFlush time.Time
+}
+
+func (h *jobHandle) SetTotal(total int) {
+	h.mu.Lock()
+	h.job.Total = total
+	h.mu.Unlock()
+	h.flush(false)
+}
+
+func (h *jobHandle) Advance(n int) {
+	h.mu.Lock()
+	h.job.Processed += n
+	h.mu.Unlock()
+	h.flush(false)
+}
+
+func (h *jobHandle) LogError(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	h.mu.Lock()
+	if len(h.job.ErrorLog) < maxJobErrorLog {
+		h.job.ErrorLog = append(h.job.ErrorLog, message)
+	} else if len(h.job.ErrorLog) == maxJobErrorLog {
+		h.job.ErrorLog = append(h.job.ErrorLog, "... (error log truncated)")
+	}
+	h.mu.Unlock()
+}
+
+func (h *jobHandle) flush(force bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !force && time.Since(h.lastFlush) < jobProgressFlushInterval {
+		return
+	}
+	h.lastFlush = time.Now()
+	if err := h.runner.jobRepo.UpdateJob(&h.job); err != nil {
+		log.Printf("⚠️ Failed to update job %s: %v", h.job.ID, err)
+	}
+}
+
+func (r *jobRunner) run(ctx context.Context, job models.Job, fn JobFunc) {
+	defer func() {
+		r.mu.Lock()
+		if cancel, ok := r.cancelFunc[job.ID]; ok {
+			cancel()
+			delete(r.cancelFunc, job.ID)
+		}
+		r.mu.Unlock()
+	}()
+
+	started := time.Now()
+	job.Status = models.JobStatusRunning
+	job.StartedAt = &started
+	handle := &jobHandle{runner: r, job: job}
+	handle.flush(true)
+
+	log.Printf("⚙️ Job %s (%s) started", job.ID, job.Type)
+	result, err := r.execute(ctx, fn, handle)
+
+	handle.mu.Lock()
+	finished := time.Now()
+	handle.job.FinishedAt = &finished
+	handle.job.Result = result
+	switch {
+	case err == nil:
+		handle.job.Status = models.JobStatusSucceeded
+	case r.rootCtx.Err() != nil:
+		handle.job.Status = models.JobStatusFailed
+		handle.job.Error = "interrupted by server shutdown"
+	case errors.Is(err, context.Canceled):
+		handle.job.Status = models.JobStatusCanceled
+		handle.job.Error = "canceled by admin"
+	default:
+		handle.job.Status = models.JobStatusFailed
+		handle.job.Error = err.Error()
+	}
+	status := handle.job.Status
+	handle.mu.Unlock()
+	handle.flush(true)
+
+	log.Printf("⚙️ Job %s (%s) %s in %s", job.ID, job.Type, status, time.Since(started).Round(time.Millisecond))
+}
+
+// execute menjalankan fn dan mengubah panic menjadi error supaya job tidak "running" selamanya.
+func (r *jobRunner) execute(ctx context.Context, fn JobFunc, progress JobProgress) (result interface{}, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("job panicked: %v", recovered)
+		}
+	}()
+	return fn(ctx, progress)
+}
+
+func (r *jobRunner) Get(id string) (*models.Job, error) {
+	return r.jobRepo.GetJobByID(id)
+}
+
+func (r *jobRunner) List(jobType, status string, limit, offset int) ([]models.Job, int64, error) {
+	return r.jobRepo.ListJobs(jobType, status, limit, offset)
+}
+
+// Cancel membatalkan job. Status final (canceled) ditulis oleh goroutine job setelah berhenti.
+func (r *jobRunner) Cancel(id string) (*models.Job, error) {
+	job, err := r.jobRepo.GetJobByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Finished() {
+		return job, ErrJobNotCancelable
+	}
+
+	r.mu.Lock()
+	cancel, running := r.cancelFunc[id]
+	r.mu.Unlock()
+	if running {
+		cancel()
+		return job, nil
+	}
+
+	// Job tidak dijalankan proses ini (mis. sisa sebelum restart)
+	finished := time.Now()
+	job.Status = models.JobStatusCanceled
+	job.Error = "canceled by admin"
+	job.FinishedAt = &finished
+	if err := r.jobRepo.UpdateJob(job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func (r *jobRunner) RecoverInterrupted() {
+	count, err := r.jobRepo.FailUnfinishedJobs("interrupted by server restart")
+	if err != nil {
+		log.Println("⚠️ Failed to recover interrupted jobs:", err)
+		return
+	}
+	if count > 0 {
+		log.Printf("⚙️ Marked %d interrupted jobs as failed", count)
+	}
+}
+
+func (r *jobRunner) Shutdown() {
+	r.stopAll()
+}
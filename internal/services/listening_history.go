@@ -0,0 +1,692 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"back_music/internal/models"
+	"back_music/internal/repository"
+	"back_music/internal/spotify"
+)
+
+const (
+	HistoryFormatSpotify = "spotify"
+	HistoryFormatLastFM  = "lastfm"
+)
+
+const (
+	// minHistoryPlayMs: Spotify sendiri baru menghitung stream setelah 30 detik.
+	minHistoryPlayMs = 30 * 1000
+	// maxHistorySpotifyLookups membatasi pencarian Spotify per upload (judul+artist tanpa ID).
+	maxHistorySpotifyLookups = 300
+	// maxHistoryUnmatchedReported: contoh lagu yang tidak cocok di hasil job.
+	maxHistoryUnmatchedReported = 20
+	historyBackfillBatch        = 1000
+	historySpotifyBatch         = 50
+	// Batas ukuran isi zip setelah didekompresi (per file dan total), melindungi dari zip bomb.
+	// Satu file Extended Streaming History biasanya < 20 MB.
+	maxHistoryZipFileBytes  = 100 << 20
+	maxHistoryZipTotalBytes = 500 << 20
+)
+
+var (
+	ErrUnsupportedHistoryFormat = errors.New("unsupported history format (use spotify or lastfm)")
+	ErrInvalidHistoryFile       = errors.New("invalid history file")
+	ErrHistoryTooLarge          = errors.New("history archive is too large when uncompressed")
+)
+
+// historyEntry adalah satu pemutaran dari file riwayat eksternal.
+type historyEntry struct {
+	PlayedAt   time.Time
+	DurationMs int
+	SpotifyID  string // kosong untuk Last.fm dan riwayat Spotify format lama
+	Title      string
+	Artist     string
+}
+
+// trackKey mengelompokkan pemutaran lagu yang sama supaya tiap lagu hanya dicocokkan sekali.
+func (e historyEntry) trackKey() string {
+	if e.SpotifyID != "" {
+		return "spotify:" + e.SpotifyID
+	}
+	return "meta:" + normalizeRecordingArtist(e.Artist) + "|" + NormalizeRecordingTitle(e.Title)
+}
+
+// HistoryImportResult adalah hasil job import riwayat dengar.
+type HistoryImportResult struct {
+	Format        string     `json:"format"`
+	Entries       int        `json:"entries"`
+	Skipped       int        `json:"skipped"` // podcast, diputar < 30 detik, baris rusak
+	Tracks        int        `json:"tracks"`
+	Matched       int        `json:"matched"`
+	SongsCreated  int        `json:"songs_created"`
+	EventsAdded   int        `json:"events_added"`
+	Duplicates    int        `json:"duplicates"`
+	FirstPlayedAt *time.Time `json:"first_played_at,omitempty"`
+	LastPlayedAt  *time.Time `json:"last_played_at,omitempty"`
+	Unmatched     []string   `json:"unmatched,omitempty"`
+}
+
+type ListeningHistoryService interface {
+	// StartImport mem-parse file lalu menjalankan pencocokan dan backfill sebagai job.
+	StartImport(userID uint, data []byte, format, filename string) (*models.Job, error)
+	GetImportJob(userID uint, jobID string) (*models.Job, error)
+}
+
+type listeningHistoryService struct {
+	client         *spotify.Client
+	songRepo       repository.SongRepository
+	playEventRepo  repository.PlayEventRepository
+	spotifyService SpotifyService
+	jobRunner      JobRunner
+}
+
+func NewListeningHistoryService(
+	client *spotify.Client,
+	songRepo repository.SongRepository,
+	playEventRepo repository.PlayEventRepository,
+	spotifyService SpotifyService,
+	jobRunner JobRunner,
+) ListeningHistoryService {
+	return &listeningHistoryService{
+		client:         client,
+		songRepo:       songRepo,
+		playEventRepo:  playEventRepo,
+		spotifyService: spotifyService,
+		jobRunner:      jobRunner,
+	}
+}
+
+// DetectHistoryFormat menebak format dari parameter eksplisit atau ekstensi file:
+// .json/.zip = Spotify (Extended Streaming History atau Account data), .csv = Last.fm.
+func DetectHistoryFormat(format, filename string) (string, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(filename)) {
+		case ".json", ".zip":
+			format = HistoryFormatSpotify
+		case ".csv":
+			format = HistoryFormatLastFM
+		}
+	}
+	if format != HistoryFormatSpotify && format != HistoryFormatLastFM {
+		return "", ErrUnsupportedHistoryFormat
+	}
+	return format, nil
+}
+
+func (s *listeningHistoryService) StartImport(userID uint, data []byte, format, filename string) (*models.Job, error) {
+	var (
+		entries []historyEntry
+		skipped int
+		err     error
+	)
+	switch format {
+	case HistoryFormatSpotify:
+		entries, skipped, err = parseSpotifyHistory(data)
+	case HistoryFormatLastFM:
+		entries, skipped, err = parseLastFMScrobbles(data)
+	default:
+		return nil, ErrUnsupportedHistoryFormat
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidHistoryFile, err)
+	}
+
+	params := map[string]interface{}{
+		"format":   format,
+		"filename": filename,
+		"bytes":    len(data),
+		"entries":  len(entries),
+	}
+	return s.jobRunner.Start(userID, models.JobTypeHistoryImport, params, func(ctx context.Context, progress JobProgress) (interface{}, error) {
+		result := &HistoryImportResult{Format: format, Entries: len(entries) + skipped, Skipped: skipped}
+		return result, s.importEntries(ctx, userID, format, entries, result, progress)
+	})
+}
+
+// GetImportJob hanya mengembalikan job import riwayat milik user sendiri.
+func (s *listeningHistoryService) GetImportJob(userID uint, jobID string) (*models.Job, error) {
+	job, err := s.jobRunner.Get(jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Type != models.JobTypeHistoryImport || job.CreatedBy != userID {
+		return nil, repository.ErrJobNotFound
+	}
+	return job, nil
+}
+
+func (s *listeningHistoryService) importEntries(ctx context.Context, userID uint, format string, entries []historyEntry, result *HistoryImportResult, progress JobProgress) error {
+	// Kelompokkan per lagu; tiap lagu dicocokkan sekali
+	tracks := make(map[string]historyEntry)
+	plays := make(map[string]int)
+	for _, entry := range entries {
+		key := entry.trackKey()
+		if _, ok := tracks[key]; !ok {
+			tracks[key] = entry
+		}
+		plays[key]++
+	}
+	result.Tracks = len(tracks)
+	progress.SetTotal(len(tracks) + 1)
+
+	resolver := &historyResolver{
+		service:  s,
+		progress: progress,
+		result:   result,
+		songIDs:  make(map[string]string, len(tracks)),
+	}
+	if err := resolver.resolve(ctx, tracks); err != nil {
+		return err
+	}
+
+	// Lagu yang paling sering diputar tapi tidak ditemukan, untuk ditampilkan ke user
+	var unmatched []string
+	for key := range tracks {
+		if resolver.songIDs[key] == "" {
+			unmatched = append(unmatched, key)
+		}
+	}
+	sort.Slice(unmatched, func(i, j int) bool { return plays[unmatched[i]] > plays[unmatched[j]] })
+	for _, key := range unmatched {
+		if len(result.Unmatched) >= maxHistoryUnmatchedReported {
+			break
+		}
+		entry := tracks[key]
+		result.Unmatched = append(result.Unmatched, fmt.Sprintf("%s - %s (%d plays)", entry.Artist, entry.Title, plays[key]))
+	}
+
+	source := models.PlaySourceSpotifyHistory
+	if format == HistoryFormatLastFM {
+		source = models.PlaySourceLastFM
+	}
+
+	events := make([]models.PlayEvent, 0, len(entries))
+	for _, entry := range entries {
+		songID := resolver.songIDs[entry.trackKey()]
+		if songID == "" {
+			continue
+		}
+		playedAt := entry.PlayedAt
+		if result.FirstPlayedAt == nil || playedAt.Before(*result.FirstPlayedAt) {
+			result.FirstPlayedAt = &playedAt
+		}
+		if result.LastPlayedAt == nil || playedAt.After(*result.LastPlayedAt) {
+			result.LastPlayedAt = &playedAt
+		}
+		events = append(events, models.PlayEvent{
+			UserID:     userID,
+			SongID:     songID,
+			PlayedAt:   playedAt,
+			Source:     source,
+			DurationMs: entry.DurationMs,
+		})
+	}
+
+	// Urut waktu supaya tiap batch mencakup rentang waktu sempit (query dedupe per batch
+	// hanya membaca play event di rentang itu)
+	sort.Slice(events, func(i, j int) bool { return events[i].PlayedAt.Before(events[j].PlayedAt) })
+	for start := 0; start < len(events); start += historyBackfillBatch {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		end := min(start+historyBackfillBatch, len(events))
+		added, err := s.playEventRepo.BackfillPlayEvents(userID, events[start:end])
+		if err != nil {
+			return fmt.Errorf("failed to save play events: %w", err)
+		}
+		result.EventsAdded += added
+		result.Duplicates += end - start - added
+	}
+	progress.Advance(1)
+
+	log.Printf("📜 Listening history imported for user %d (%s): %d/%d tracks matched, %d plays added",
+		userID, format, result.Matched, result.Tracks, result.EventsAdded)
+	return nil
+}
+
+// historyResolver mencocokkan lagu dari riwayat ke katalog: lewat Spotify ID jika ada,
+// selain itu judul + artist. Lagu yang belum ada dibuat dari data Spotify.
+type historyResolver struct {
+	service  *listeningHistoryService
+	progress JobProgress
+	result   *HistoryImportResult
+	songIDs  map[string]string // trackKey -> song ID
+
+	lookups         int
+	spotifyDisabled bool
+}
+
+func (r *historyResolver) resolve(ctx context.Context, tracks map[string]historyEntry) error {
+	var spotifyKeys, metaKeys []string
+	for key, entry := range tracks {
+		if entry.SpotifyID != "" {
+			spotifyKeys = append(spotifyKeys, key)
+		} else {
+			metaKeys = append(metaKeys, key)
+		}
+	}
+	sort.Strings(spotifyKeys)
+	sort.Strings(metaKeys)
+
+	for start := 0; start < len(spotifyKeys); start += historySpotifyBatch {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		end := min(start+historySpotifyBatch, len(spotifyKeys))
+		r.resolveSpotifyBatch(ctx, spotifyKeys[start:end], tracks)
+		r.progress.Advance(end - start)
+	}
+
+	for _, key := range metaKeys {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		r.resolveByMetadata(ctx, key, tracks[key])
+		r.progress.Advance(1)
+	}
+	return nil
+}
+
+func (r *historyResolver) resolveSpotifyBatch(ctx context.Context, keys []string, tracks map[string]historyEntry) {
+	ids := make([]string, 0, len(keys))
+	keyByID := make(map[string]string, len(keys))
+	for _, key := range keys {
+		id := tracks[key].SpotifyID
+		ids = append(ids, id)
+		keyByID[id] = key
+	}
+
+	songs, err := r.service.songRepo.GetSongsBySpotifyIDs(ids)
+	if err != nil {
+		r.progress.LogError("catalog lookup failed: %v", err)
+	}
+	for _, song := range songs {
+		r.matched(keyByID[song.SpotifyID], song.ID)
+	}
+
+	var missing []string
+	for _, id := range ids {
+		if r.songIDs[keyByID[id]] == "" {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+
+	if !r.spotifyDisabled {
+		fetched, err := r.service.client.GetTracks(ctx, missing, "")
+		if err != nil {
+			r.spotifyFailed(err)
+		} else if len(fetched) > 0 {
+			songIDs, created, err := r.service.spotifyService.ImportTracks(fetched)
+			if err != nil {
+				r.progress.LogError("failed to create songs from Spotify: %v", err)
+			}
+			r.result.SongsCreated += created
+			for spotifyID, songID := range songIDs {
+				if key, ok := keyByID[spotifyID]; ok {
+					r.matched(key, songID)
+				}
+			}
+		}
+	}
+
+	// Track yang sudah di-relink Spotify punya ID lain; coba judul + artist
+	for _, id := range missing {
+		key := keyByID[id]
+		if r.songIDs[key] == "" {
+			r.resolveByMetadata(ctx, key, tracks[key])
+		}
+	}
+}
+
+func (r *historyResolver) resolveByMetadata(ctx context.Context, key string, entry historyEntry) {
+	title := NormalizeRecordingTitle(entry.Title)
+	artist := normalizeRecordingArtist(entry.Artist)
+	if title == "" || artist == "" {
+		return
+	}
+
+	candidates, err := r.service.songRepo.FindSongsByTitlePrefix(historyTitlePrefix(entry.Title), 20)
+	if err != nil {
+		r.progress.LogError("catalog lookup failed for %s - %s: %v", entry.Artist, entry.Title, err)
+	}
+	for _, song := range candidates {
+		if NormalizeRecordingTitle(song.Title) == title && historyArtistMatches(SplitArtistNames(song.Artist), artist) {
+			r.matched(key, song.ID)
+			return
+		}
+	}
+
+	if r.spotifyDisabled || r.lookups >= maxHistorySpotifyLookups {
+		return
+	}
+	r.lookups++
+
+	query := fmt.Sprintf("track:%q artist:%q", entry.Title, entry.Artist)
+	results, err := r.service.client.SearchTracks(ctx, query, "", 5)
+	if err != nil {
+		r.spotifyFailed(err)
+		return
+	}
+	for _, track := range results {
+		if NormalizeRecordingTitle(track.Name) != title || !historyArtistMatches(track.ArtistNames(), artist) {
+			continue
+		}
+		songIDs, created, err := r.service.spotifyService.ImportTracks([]spotify.Track{track})
+		if err != nil {
+			r.progress.LogError("failed to create %s - %s: %v", entry.Artist, entry.Title, err)
+			return
+		}
+		r.result.SongsCreated += created
+		if songID := songIDs[track.ID]; songID != "" {
+			r.matched(key, songID)
+		}
+		return
+	}
+}
+
+func (r *historyResolver) matched(key, songID string) {
+	if key == "" || songID == "" || r.songIDs[key] != "" {
+		return
+	}
+	r.songIDs[key] = songID
+	r.result.Matched++
+}
+
+// spotifyFailed mematikan lookup Spotify untuk sisa job jika kena rate limit atau
+// kredensial belum diset; pencocokan dari katalog tetap jalan.
+func (r *historyResolver) spotifyFailed(err error) {
+	if spotify.IsRateLimited(err) || errors.Is(err, spotify.ErrMissingCredentials) {
+		r.spotifyDisabled = true
+		r.progress.LogError("Spotify lookups disabled for this import: %v", err)
+		return
+	}
+	r.progress.LogError("Spotify lookup failed: %v", err)
+}
+
+// historyTitlePrefix memotong judul sebelum keterangan versi ("Song - Remastered", "Song (Live)").
+func historyTitlePrefix(title string) string {
+	for _, sep := range []string{" - ", " (", " ["} {
+		if i := strings.Index(title, sep); i > 0 {
+			title = title[:i]
+		}
+	}
+	return strings.TrimSpace(title)
+}
+
+// historyArtistMatches: artist utama riwayat harus termasuk salah satu artist lagu.
+func historyArtistMatches(names []string, normalizedArtist string) bool {
+	for _, name := range names {
+		if strings.Join(normalizeSuggestText(name), " ") == normalizedArtist {
+			return true
+		}
+	}
+	return false
+}
+
+// spotifyHistoryRecord mencakup dua format export Spotify: Extended Streaming History
+// (ts, ms_played, spotify_track_uri, ...) dan Account data lama (endTime, msPlayed, ...).
+type spotifyHistoryRecord struct {
+	TS         string `json:"ts"`
+	MsPlayed   int    `json:"ms_played"`
+	TrackName  string `json:"master_metadata_track_name"`
+	ArtistName string `json:"master_metadata_album_artist_name"`
+	TrackURI   string `json:"spotify_track_uri"`
+
+	EndTime          string `json:"endTime"`
+	LegacyMsPlayed   int    `json:"msPlayed"`
+	LegacyTrackName  string `json:"trackName"`
+	LegacyArtistName string `json:"artistName"`
+}
+
+// parseSpotifyHistory menerima satu file JSON atau zip export Spotify (semua
+// Streaming_History*.json di dalamnya dibaca; video dan podcast dilewati).
+func parseSpotifyHistory(data []byte) ([]historyEntry, int, error) {
+	if !bytes.HasPrefix(data, []byte("PK")) {
+		return decodeSpotifyHistory(bytes.NewReader(data))
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid zip file: %w", err)
+	}
+
+	var entries []historyEntry
+	skipped, files := 0, 0
+	remaining := int64(maxHistoryZipTotalBytes)
+	for _, file := range archive.File {
+		name := filepath.Base(file.Name)
+		if !strings.HasPrefix(name, "Streaming_History") && !strings.HasPrefix(name, "StreamingHistory") {
+			continue
+		}
+		if !strings.HasSuffix(strings.ToLower(name), ".json") || strings.Contains(name, "Video") {
+			continue
+		}
+		if file.UncompressedSize64 > maxHistoryZipFileBytes || int64(file.UncompressedSize64) > remaining {
+			return nil, 0, fmt.Errorf("%s: %w", name, ErrHistoryTooLarge)
+		}
+		reader, err := file.Open()
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		// Ukuran di header zip bisa dipalsukan, jadi byte yang benar-benar dibaca ikut dibatasi
+		capped := &cappedReader{r: reader, remaining: min(int64(maxHistoryZipFileBytes), remaining)}
+		limit := capped.remaining
+		fileEntries, fileSkipped, err := decodeSpotifyHistory(capped)
+		reader.Close()
+		if capped.exceeded {
+			return nil, 0, fmt.Errorf("%s: %w", name, ErrHistoryTooLarge)
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("%s: %w", name, err)
+		}
+		remaining -= limit - capped.remaining
+		entries = append(entries, fileEntries...)
+		skipped += fileSkipped
+		files++
+	}
+	if files == 0 {
+		return nil, 0, errors.New("zip file does not contain Spotify streaming history")
+	}
+	return entries, skipped, nil
+}
+
+// cappedReader berhenti dengan ErrHistoryTooLarge setelah remaining byte terbaca.
+type cappedReader struct {
+	r         io.Reader
+	remaining int64
+	exceeded  bool
+}
+
+func (c *cappedReader) Read(p []byte) (int, error) {
+	if c.remaining <= 0 {
+		// Cek apakah memang masih ada data, supaya file yang pas di batas tetap diterima
+		var probe [1]byte
+		if n, _ := c.r.Read(probe[:]); n > 0 {
+			c.exceeded = true
+			return 0, ErrHistoryTooLarge
+		}
+		return 0, io.EOF
+	}
+	if int64(len(p)) > c.remaining {
+		p = p[:c.remaining]
+	}
+	n, err := c.r.Read(p)
+	c.remaining -= int64(n)
+	return n, err
+}
+
+func decodeSpotifyHistory(r io.Reader) ([]historyEntry, int, error) {
+	decoder := json.NewDecoder(r)
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		return nil, 0, errors.New("invalid Spotify history: expected a JSON array")
+	}
+
+	var entries []historyEntry
+	skipped := 0
+	for decoder.More() {
+		var record spotifyHistoryRecord
+		if err := decoder.Decode(&record); err != nil {
+			return nil, 0, fmt.Errorf("invalid Spotify history: %w", err)
+		}
+		entry, ok := record.entry()
+		if !ok {
+			skipped++
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, skipped, nil
+}
+
+func (r spotifyHistoryRecord) entry() (historyEntry, bool) {
+	var (
+		endedAt time.Time
+		err     error
+		entry   historyEntry
+	)
+	if r.TS != "" {
+		endedAt, err = time.Parse(time.RFC3339, r.TS)
+		entry = historyEntry{
+			DurationMs: r.MsPlayed,
+			Title:      r.TrackName,
+			Artist:     r.ArtistName,
+			SpotifyID:  strings.TrimPrefix(r.TrackURI, "spotify:track:"),
+		}
+		// Podcast / audiobook tidak punya track URI
+		if !strings.HasPrefix(r.TrackURI, "spotify:track:") {
+			return entry, false
+		}
+	} else {
+		endedAt, err = time.Parse("2006-01-02 15:04", r.EndTime)
+		entry = historyEntry{
+			DurationMs: r.LegacyMsPlayed,
+			Title:      r.LegacyTrackName,
+			Artist:     r.LegacyArtistName,
+		}
+	}
+	if err != nil || entry.Title == "" || entry.Artist == "" || entry.DurationMs < minHistoryPlayMs {
+		return entry, false
+	}
+	// Timestamp Spotify adalah waktu selesai diputar
+	entry.PlayedAt = endedAt.Add(-time.Duration(entry.DurationMs) * time.Millisecond).UTC()
+	return entry, true
+}
+
+// lastFMTimeLayouts adalah format tanggal yang dipakai tool export Last.fm populer.
+var lastFMTimeLayouts = []string{
+	"02 Jan 2006 15:04",
+	"2 Jan 2006 15:04",
+	"02 Jan 2006, 15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05Z07:00",
+	"2006-01-02 15:04",
+}
+
+// parseLastFMScrobbles membaca export CSV Last.fm. Tanpa header diasumsikan
+// artist,album,track,date; dengan header kolom dicari dari namanya
+// (artist, track, uts / utc_time / date / timestamp).
+func parseLastFMScrobbles(data []byte) ([]historyEntry, int, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\ufeff"))))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read CSV: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, 0, errors.New("CSV file is empty")
+	}
+
+	artistCol, trackCol, timeCol := 0, 2, 3
+	if header := lastFMHeader(rows[0]); header != nil {
+		artistCol, trackCol, timeCol = header["artist"], header["track"], header["time"]
+		rows = rows[1:]
+	}
+
+	entries := make([]historyEntry, 0, len(rows))
+	skipped := 0
+	for _, row := range rows {
+		if len(row) <= max(artistCol, trackCol, timeCol) {
+			skipped++
+			continue
+		}
+		playedAt, ok := parseLastFMTime(row[timeCol])
+		entry := historyEntry{
+			PlayedAt: playedAt,
+			Artist:   strings.TrimSpace(row[artistCol]),
+			Title:    strings.TrimSpace(row[trackCol]),
+		}
+		if !ok || entry.Artist == "" || entry.Title == "" {
+			skipped++
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, skipped, nil
+}
+
+// lastFMHeader mengembalikan indeks kolom jika baris pertama adalah header.
+func lastFMHeader(row []string) map[string]int {
+	columns := map[string]int{}
+	for i, name := range row {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "artist", "artist_name":
+			columns["artist"] = i
+		case "track", "track_name", "title", "name":
+			columns["track"] = i
+		case "uts", "timestamp", "date", "utc_time", "played_at":
+			// uts (unix) lebih diutamakan dibanding teks tanggal
+			if _, ok := columns["time"]; !ok || strings.EqualFold(name, "uts") {
+				columns["time"] = i
+			}
+		}
+	}
+	if len(columns) < 3 {
+		return nil
+	}
+	return columns
+}
+
+func parseLastFMTime(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, false
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		// Scrobble "now playing" tercatat dengan timestamp 0
+		if seconds <= 0 {
+			return time.Time{}, false
+		}
+		if seconds > 1e12 {
+			seconds /= 1000
+		}
+		return time.Unix(seconds, 0).UTC(), true
+	}
+	for _, layout := range lastFMTimeLayouts {
+		if at, err := time.Parse(layout, value); err == nil {
+			if at.Year() <= 1970 {
+				return time.Time{}, false
+			}
+			return at.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
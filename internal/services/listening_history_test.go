@@ -0,0 +1,286 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"hash/crc32"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSpotifyHistoryRecords(t *testing.T) {
+	tests := []struct {
+		name      string
+		record    string
+		wantEntry *historyEntry
+	}{
+		{
+			name: "extended streaming history",
+			record: `{"ts":"2024-03-01T12:03:00Z","ms_played":180000,"master_metadata_track_name":"Song",
+				"master_metadata_album_artist_name":"Artist","spotify_track_uri":"spotify:track:4uLU6hMCjMI75M1A2tKUQC"}`,
+			wantEntry: &historyEntry{
+				PlayedAt:   time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+				DurationMs: 180000,
+				SpotifyID:  "4uLU6hMCjMI75M1A2tKUQC",
+				Title:      "Song",
+				Artist:     "Artist",
+			},
+		},
+		{
+			name:   "legacy account data",
+			record: `{"endTime":"2020-05-01 10:05","msPlayed":120000,"trackName":"Old Song","artistName":"Band"}`,
+			wantEntry: &historyEntry{
+				PlayedAt:   time.Date(2020, 5, 1, 10, 3, 0, 0, time.UTC),
+				DurationMs: 120000,
+				Title:      "Old Song",
+				Artist:     "Band",
+			},
+		},
+		{
+			name: "podcast episode is skipped",
+			record: `{"ts":"2024-03-01T12:30:00Z","ms_played":600000,"master_metadata_track_name":null,
+				"episode_name":"Episode 1","spotify_episode_uri":"spotify:episode:abc"}`,
+		},
+		{
+			name: "short play is skipped",
+			record: `{"ts":"2024-03-01T12:03:00Z","ms_played":5000,"master_metadata_track_name":"Song",
+				"master_metadata_album_artist_name":"Artist","spotify_track_uri":"spotify:track:abc"}`,
+		},
+		{
+			name:   "bad timestamp is skipped",
+			record: `{"endTime":"yesterday","msPlayed":120000,"trackName":"Old Song","artistName":"Band"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, skipped, err := parseSpotifyHistory([]byte("[" + tt.record + "]"))
+			if err != nil {
+				t.Fatalf("parseSpotifyHistory: %v", err)
+			}
+			if tt.wantEntry == nil {
+				if len(entries) != 0 || skipped != 1 {
+					t.Errorf("entries = %+v, skipped = %d, want 1 skipped", entries, skipped)
+				}
+				return
+			}
+			if len(entries) != 1 || skipped != 0 {
+				t.Fatalf("entries = %+v, skipped = %d, want 1 entry", entries, skipped)
+			}
+			if entries[0] != *tt.wantEntry {
+				t.Errorf("entry = %+v, want %+v", entries[0], *tt.wantEntry)
+			}
+		})
+	}
+}
+
+func TestParseSpotifyHistoryRejectsNonArray(t *testing.T) {
+	if _, _, err := parseSpotifyHistory([]byte(`{"ts":"2024-03-01T12:03:00Z"}`)); err == nil {
+		t.Fatal("parseSpotifyHistory accepted a JSON object")
+	}
+}
+
+type zipTestFile struct {
+	name string
+	data string
+	// declaredSize, jika > 0, ditulis sebagai ukuran uncompressed di header zip
+	declaredSize uint64
+}
+
+func buildTestZip(t *testing.T, files []zipTestFile) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, file := range files {
+		size := uint64(len(file.data))
+		if file.declaredSize > 0 {
+			size = file.declaredSize
+		}
+		w, err := archive.CreateRaw(&zip.FileHeader{
+			Name:               file.name,
+			Method:             zip.Store,
+			CRC32:              crc32.ChecksumIEEE([]byte(file.data)),
+			CompressedSize64:   uint64(len(file.data)),
+			UncompressedSize64: size,
+		})
+		if err != nil {
+			t.Fatalf("CreateRaw: %v", err)
+		}
+		if _, err := io.WriteString(w, file.data); err != nil {
+			t.Fatalf("write %s: %v", file.name, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("close zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestParseSpotifyHistoryZip(t *testing.T) {
+	audio := `[{"ts":"2024-03-01T12:03:00Z","ms_played":180000,"master_metadata_track_name":"Song",
+		"master_metadata_album_artist_name":"Artist","spotify_track_uri":"spotify:track:abc"}]`
+	legacy := `[{"endTime":"2020-05-01 10:05","msPlayed":120000,"trackName":"Old Song","artistName":"Band"}]`
+
+	tests := []struct {
+		name        string
+		files       []zipTestFile
+		wantEntries int
+		wantErr     bool
+	}{
+		{
+			name: "history files are read, other files ignored",
+			files: []zipTestFile{
+				{name: "Spotify Extended Streaming History/Streaming_History_Audio_2024.json", data: audio},
+				{name: "Spotify Extended Streaming History/Streaming_History_Video_2024.json", data: audio},
+				{name: "MyData/StreamingHistory0.json", data: legacy},
+				{name: "MyData/Userdata.json", data: `{"username":"me"}`},
+			},
+			wantEntries: 2,
+		},
+		{
+			name:    "no history files",
+			files:   []zipTestFile{{name: "MyData/Userdata.json", data: `{}`}},
+			wantErr: true,
+		},
+		{
+			name: "declared size smaller than contents",
+			files: []zipTestFile{{
+				name:         "Streaming_History_Audio_2024.json",
+				data:         "[" + strings.Repeat(strings.Trim(audio, "[]")+",", 50) + strings.Trim(audio, "[]") + "]",
+				declaredSize: 16,
+			}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, _, err := parseSpotifyHistory(buildTestZip(t, tt.files))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseSpotifyHistory = %d entries, want error", len(entries))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseSpotifyHistory: %v", err)
+			}
+			if len(entries) != tt.wantEntries {
+				t.Errorf("entries = %d, want %d", len(entries), tt.wantEntries)
+			}
+		})
+	}
+}
+
+func TestCappedReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		limit   int64
+		wantErr error
+	}{
+		{name: "under the limit", data: "0123456789", limit: 20},
+		{name: "exactly at the limit", data: "0123456789", limit: 10},
+		{name: "over the limit", data: "0123456789", limit: 9, wantErr: ErrHistoryTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			capped := &cappedReader{r: strings.NewReader(tt.data), remaining: tt.limit}
+			data, err := io.ReadAll(capped)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ReadAll error = %v, want %v", err, tt.wantErr)
+			}
+			if capped.exceeded != (tt.wantErr != nil) {
+				t.Errorf("exceeded = %v", capped.exceeded)
+			}
+			if tt.wantErr == nil && string(data) != tt.data {
+				t.Errorf("data = %q, want %q", data, tt.data)
+			}
+		})
+	}
+}
+
+func TestParseLastFMScrobbles(t *testing.T) {
+	tests := []struct {
+		name        string
+		csv         string
+		wantEntries []historyEntry
+		wantSkipped int
+	}{
+		{
+			name: "header-less export is artist,album,track,date",
+			csv:  "Artist,Album,Track,01 Mar 2024 12:00\nOther,,Second,2 Mar 2024 09:30\n",
+			wantEntries: []historyEntry{
+				{PlayedAt: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), Artist: "Artist", Title: "Track"},
+				{PlayedAt: time.Date(2024, 3, 2, 9, 30, 0, 0, time.UTC), Artist: "Other", Title: "Second"},
+			},
+		},
+		{
+			name: "header prefers uts over the text date",
+			csv: "\ufeffutc_time,uts,artist,artist_mbid,album,track\n" +
+				"01 Mar 2024 12:00,1709294460,Artist,,Album,Track\n",
+			wantEntries: []historyEntry{
+				{PlayedAt: time.Unix(1709294460, 0).UTC(), Artist: "Artist", Title: "Track"},
+			},
+		},
+		{
+			name: "now playing, short and undated rows are skipped",
+			csv: "artist,track,timestamp\n" +
+				"Artist,Now Playing,0\n" +
+				"Artist,Short\n" +
+				"Artist,Undated,someday\n" +
+				"Artist,Millis,1709294460000\n",
+			wantEntries: []historyEntry{
+				{PlayedAt: time.Unix(1709294460, 0).UTC(), Artist: "Artist", Title: "Millis"},
+			},
+			wantSkipped: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, skipped, err := parseLastFMScrobbles([]byte(tt.csv))
+			if err != nil {
+				t.Fatalf("parseLastFMScrobbles: %v", err)
+			}
+			if skipped != tt.wantSkipped {
+				t.Errorf("skipped = %d, want %d", skipped, tt.wantSkipped)
+			}
+			if len(entries) != len(tt.wantEntries) {
+				t.Fatalf("entries = %+v, want %+v", entries, tt.wantEntries)
+			}
+			for i := range entries {
+				if entries[i] != tt.wantEntries[i] {
+					t.Errorf("entry %d = %+v, want %+v", i, entries[i], tt.wantEntries[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseLastFMTime(t *testing.T) {
+	tests := []struct {
+		value  string
+		want   time.Time
+		wantOK bool
+	}{
+		{value: "1709294460", want: time.Unix(1709294460, 0).UTC(), wantOK: true},
+		{value: "01 Mar 2024 12:00", want: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), wantOK: true},
+		{value: "1 Mar 2024 12:00", want: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), wantOK: true},
+		{value: "01 Mar 2024, 12:00", want: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), wantOK: true},
+		{value: "2024-03-01 12:00:05", want: time.Date(2024, 3, 1, 12, 0, 5, 0, time.UTC), wantOK: true},
+		{value: "2024-03-01T13:00:00+01:00", want: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), wantOK: true},
+		{value: "01 Jan 1970 00:00"},
+		{value: "0"},
+		{value: ""},
+		{value: "March 1st"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, ok := parseLastFMTime(tt.value)
+			if ok != tt.wantOK || !got.Equal(tt.want) {
+				t.Errorf("parseLastFMTime(%q) = %s, %v, want %s, %v", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,499 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"back_music/internal/config"
+	"back_music/internal/encryption"
+	"back_music/internal/listenbrainz"
+	"back_music/internal/models"
+	"back_music/internal/repository"
+)
+
+const (
+	scrobbleWorkerInterval = 30 * time.Second
+	scrobbleBatchSize      = 500
+	scrobbleMaxRounds      = 10
+	// scrobbleMaxAttempts: setelah ini listen ditandai failed (sekitar 2 hari dengan backoff).
+	scrobbleMaxAttempts = 12
+	scrobbleBaseBackoff = time.Minute
+	scrobbleMaxBackoff  = 6 * time.Hour
+	scrobbleTimeout     = 15 * time.Second
+	// scrobbleSubmissionClient muncul di additional_info setiap listen.
+	scrobbleSubmissionClient = "back_music"
+
+	// Aturan submit ListenBrainz/Last.fm: play dihitung setelah didengar setengah durasi
+	// lagu atau 4 menit (mana yang lebih pendek); lagu tanpa durasi cukup 30 detik.
+	scrobbleMaxRequiredMs = 4 * 60 * 1000
+	scrobbleMinListenMs   = 30 * 1000
+)
+
+var (
+	ErrScrobbleUnavailable = errors.New("scrobbling is not available (token encryption disabled)")
+	ErrInvalidScrobbleURL  = errors.New("invalid scrobble api url")
+	ErrListenTooShort      = errors.New("play is too short to count as a listen")
+)
+
+// ScrobbleAccountStatus adalah akun scrobble beserta isi antriannya.
+type ScrobbleAccountStatus struct {
+	Account *models.ScrobbleAccount        `json:"account"`
+	Queue   *repository.ScrobbleQueueStats `json:"queue"`
+}
+
+type ScrobbleService interface {
+	// Connect memvalidasi token ke server lalu menyimpan akun. apiURL kosong = server default.
+	Connect(ctx context.Context, userID uint, apiURL, token string) (*models.ScrobbleAccount, error)
+	GetAccount(userID uint) (*ScrobbleAccountStatus, error)
+	Disconnect(userID uint) error
+	// NowPlaying mengirim status "playing now" di background (best effort, tidak diantrikan).
+	NowPlaying(userID uint, song *models.Song) error
+	// RecordListen mengantrikan satu listen selesai (listenedMs = lama didengar);
+	// dikirim oleh worker. Play yang terlalu pendek ditolak dengan ErrListenTooShort.
+	RecordListen(userID uint, songID string, listenedAt time.Time, listenedMs int) error
+	RetryFailed(userID uint) (int64, error)
+	// StartWorker mengirim antrian secara berkala sampai ctx selesai.
+	StartWorker(ctx context.Context)
+}
+
+type scrobbleService struct {
+	repo     repository.ScrobbleRepository
+	songRepo repository.SongRepository
+	cipher   *encryption.Cipher
+
+	defaultURL   string
+	defaultHost  string
+	allowedHosts []string
+	// publicClient dipakai untuk server pilihan user: hanya boleh dial ke IP publik,
+	// termasuk setelah redirect atau DNS yang berubah.
+	publicClient *http.Client
+	// wake membangunkan worker saat ada listen baru supaya tidak menunggu ticker.
+	wake chan struct{}
+}
+
+func NewScrobbleService(
+	repo repository.ScrobbleRepository,
+	songRepo repository.SongRepository,
+	playEventRepo repository.PlayEventRepository,
+) ScrobbleService {
+	cfg := config.GlobalConfig
+	s := &scrobbleService{
+		repo:         repo,
+		songRepo:     songRepo,
+		cipher:       newTokenCipher(),
+		defaultURL:   cfg.ListenBrainzAPIURL,
+		allowedHosts: cfg.ListenBrainzAllowedHosts,
+		publicClient: newPublicHTTPClient(scrobbleTimeout),
+		wake:         make(chan struct{}, 1),
+	}
+	if parsed, err := url.Parse(cfg.ListenBrainzAPIURL); err == nil {
+		s.defaultHost = strings.ToLower(parsed.Hostname())
+	}
+
+	// Setiap play dari app (bukan import riwayat) dikirim sebagai listen
+	playEventRepo.OnPlayRecorded(func(event models.PlayEvent) {
+		if event.Source != "" && event.Source != models.PlaySourceApp {
+			return
+		}
+		if err := s.RecordListen(event.UserID, event.SongID, event.PlayedAt, event.DurationMs); err != nil &&
+			!errors.Is(err, repository.ErrScrobbleAccountNotFound) && !errors.Is(err, ErrListenTooShort) {
+			log.Printf("⚠️ Failed to queue scrobble for user %d: %v", event.UserID, err)
+		}
+	})
+	return s
+}
+
+// trustedHost: host yang dipilih operator (host LISTENBRAINZ_API_URL atau yang ditulis
+// eksplisit di LISTENBRAINZ_ALLOWED_HOSTS) boleh berada di jaringan privat, mis. stand-in lokal.
+func (s *scrobbleService) trustedHost(host string) bool {
+	host = strings.ToLower(host)
+	return host == s.defaultHost || slices.Contains(s.allowedHosts, host)
+}
+
+// normalizeScrobbleURL memvalidasi URL server. Host lain selain yang dipercaya hanya boleh
+// jika LISTENBRAINZ_ALLOWED_HOSTS berisi "*", dan harus resolve ke IP publik.
+func (s *scrobbleService) normalizeScrobbleURL(ctx context.Context, raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		raw = s.defaultURL
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" || parsed.User != nil {
+		return "", ErrInvalidScrobbleURL
+	}
+	host := parsed.Hostname()
+	if s.trustedHost(host) {
+		return strings.TrimRight(parsed.String(), "/"), nil
+	}
+	if !slices.Contains(s.allowedHosts, "*") {
+		return "", fmt.Errorf("%w: host %s is not allowed", ErrInvalidScrobbleURL, host)
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return "", fmt.Errorf("%w: cannot resolve host %s", ErrInvalidScrobbleURL, host)
+	}
+	for _, addr := range addrs {
+		if !isPublicIP(addr.IP) {
+			return "", fmt.Errorf("%w: host %s resolves to a non-public address", ErrInvalidScrobbleURL, host)
+		}
+	}
+	return strings.TrimRight(parsed.String(), "/"), nil
+}
+
+// newClient membuat client ListenBrainz; server yang tidak dipercaya memakai publicClient.
+func (s *scrobbleService) newClient(apiURL, token string) *listenbrainz.Client {
+	var httpClient *http.Client
+	if parsed, err := url.Parse(apiURL); err != nil || !s.trustedHost(parsed.Hostname()) {
+		httpClient = s.publicClient
+	}
+	return listenbrainz.NewClient(apiURL, token, httpClient)
+}
+
+func isPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified())
+}
+
+// newPublicHTTPClient menolak koneksi ke IP non-publik saat dial, jadi redirect atau DNS
+// rebinding ke alamat internal tetap terblokir.
+func newPublicHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+				return fmt.Errorf("%w: refusing to connect to %s", ErrInvalidScrobbleURL, host)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// scrobbleQualifies menerapkan durasi minimal sebuah play dihitung sebagai listen.
+func scrobbleQualifies(listenedMs, trackMs int) bool {
+	required := scrobbleMinListenMs
+	if trackMs > 0 {
+		required = min(trackMs/2, scrobbleMaxRequiredMs)
+	}
+	return listenedMs > 0 && listenedMs >= required
+}
+
+func (s *scrobbleService) Connect(ctx context.Context, userID uint, apiURL, token string) (*models.ScrobbleAccount, error) {
+	if s.cipher == nil {
+		return nil, ErrScrobbleUnavailable
+	}
+	ctx, cancel := context.WithTimeout(ctx, scrobbleTimeout)
+	defer cancel()
+	apiURL, err := s.normalizeScrobbleURL(ctx, apiURL)
+	if err != nil {
+		return nil, err
+	}
+	username, err := s.newClient(apiURL, token).ValidateToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := s.cipher.Encrypt(token)
+	if err != nil {
+		return nil, err
+	}
+	account := &models.ScrobbleAccount{
+		UserID:         userID,
+		APIURL:         apiURL,
+		Username:       username,
+		TokenEncrypted: encrypted,
+		Enabled:        true,
+	}
+	if err := s.repo.UpsertAccount(account); err != nil {
+		return nil, err
+	}
+	log.Printf("🎧 User %d connected scrobbling to %s as %s", userID, apiURL, username)
+
+	// Listen yang tertahan karena token lama ditolak bisa langsung dikirim
+	s.notify()
+	return s.repo.GetAccount(userID)
+}
+
+func (s *scrobbleService) GetAccount(userID uint) (*ScrobbleAccountStatus, error) {
+	account, err := s.repo.GetAccount(userID)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := s.repo.QueueStats(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &ScrobbleAccountStatus{Account: account, Queue: stats}, nil
+}
+
+func (s *scrobbleService) Disconnect(userID uint) error {
+	return s.repo.DeleteAccount(userID)
+}
+
+func (s *scrobbleService) RetryFailed(userID uint) (int64, error) {
+	if _, err := s.repo.GetAccount(userID); err != nil {
+		return 0, err
+	}
+	count, err := s.repo.RetryFailed(userID)
+	if err == nil && count > 0 {
+		s.notify()
+	}
+	return count, err
+}
+
+func (s *scrobbleService) NowPlaying(userID uint, song *models.Song) error {
+	account, err := s.repo.GetAccount(userID)
+	if err != nil {
+		return err
+	}
+	if !account.Enabled {
+		return nil
+	}
+	client, err := s.clientFor(account)
+	if err != nil {
+		return err
+	}
+
+	// Dikirim di background: playback tidak perlu menunggu server scrobble
+	listen := listenbrainz.Listen{TrackMetadata: trackMetadataFromSong(song)}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), scrobbleTimeout)
+		defer cancel()
+		if err := client.SubmitListens(ctx, listenbrainz.ListenTypePlayingNow, []listenbrainz.Listen{listen}); err != nil {
+			log.Printf("⚠️ Now playing submit failed for user %d: %v", userID, err)
+		}
+	}()
+	return nil
+}
+
+// RecordListen tetap mengantrikan walau akun nonaktif (token ditolak), supaya
+// listen terkirim setelah user connect ulang.
+func (s *scrobbleService) RecordListen(userID uint, songID string, listenedAt time.Time, listenedMs int) error {
+	if _, err := s.repo.GetAccount(userID); err != nil {
+		return err
+	}
+	song, err := s.songRepo.GetSongByID(songID)
+	if err != nil {
+		return err
+	}
+	if !scrobbleQualifies(listenedMs, song.DurationMs) {
+		return ErrListenTooShort
+	}
+
+	item := &models.ScrobbleQueueItem{
+		UserID:      userID,
+		SongID:      song.ID,
+		ListenedAt:  listenedAt,
+		ArtistName:  song.Artist,
+		TrackName:   song.Title,
+		ReleaseName: song.Album,
+		DurationMs:  song.DurationMs,
+		SpotifyID:   song.SpotifyID,
+		ISRC:        song.ISRC,
+	}
+	if err := s.repo.Enqueue(item); err != nil {
+		return err
+	}
+	s.notify()
+	return nil
+}
+
+func (s *scrobbleService) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *scrobbleService) StartWorker(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(scrobbleWorkerInterval)
+		defer ticker.Stop()
+		for {
+			s.flush(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-s.wake:
+			}
+		}
+	}()
+}
+
+// flush mengirim listen yang sudah waktunya, dikelompokkan per user.
+func (s *scrobbleService) flush(ctx context.Context) {
+	// Dibatasi per putaran supaya item yang gagal dijadwalkan ulang tidak membuat loop tanpa akhir
+	for round := 0; round < scrobbleMaxRounds && ctx.Err() == nil; round++ {
+		items, err := s.repo.DueItems(time.Now(), scrobbleBatchSize)
+		if err != nil {
+			log.Println("⚠️ Failed to load scrobble queue:", err)
+			return
+		}
+		if len(items) == 0 {
+			return
+		}
+
+		byUser := make(map[uint][]models.ScrobbleQueueItem)
+		var order []uint
+		for _, item := range items {
+			if _, ok := byUser[item.UserID]; !ok {
+				order = append(order, item.UserID)
+			}
+			byUser[item.UserID] = append(byUser[item.UserID], item)
+		}
+		for _, userID := range order {
+			if ctx.Err() != nil {
+				return
+			}
+			s.submitUser(ctx, userID, byUser[userID])
+		}
+
+		// Batch tidak penuh berarti antrian yang jatuh tempo sudah habis
+		if len(items) < scrobbleBatchSize {
+			return
+		}
+	}
+}
+
+func (s *scrobbleService) submitUser(ctx context.Context, userID uint, items []models.ScrobbleQueueItem) {
+	ids := make([]uint, len(items))
+	listens := make([]listenbrainz.Listen, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+		listens[i] = listenFromQueueItem(item)
+	}
+
+	account, err := s.repo.GetAccount(userID)
+	if err != nil {
+		return
+	}
+	client, err := s.clientFor(account)
+	if err == nil {
+		submitCtx, cancel := context.WithTimeout(ctx, scrobbleTimeout)
+		err = client.SubmitListens(submitCtx, listenbrainz.ListenTypeSingle, listens)
+		cancel()
+	}
+
+	now := time.Now()
+	if err == nil {
+		if err := s.repo.DeleteItems(ids); err != nil {
+			log.Printf("⚠️ Failed to remove submitted scrobbles for user %d: %v", userID, err)
+		}
+		if err := s.repo.MarkSubmitted(userID, account.TokenEncrypted, now); err != nil {
+			log.Printf("⚠️ Failed to update scrobble account for user %d: %v", userID, err)
+		}
+		return
+	}
+
+	if errors.Is(err, listenbrainz.ErrInvalidToken) {
+		// Token dicabut: berhenti mengirim sampai user connect ulang; antrian disimpan
+		if err := s.repo.DisableAccount(userID, account.TokenEncrypted, err.Error()); err != nil {
+			log.Printf("⚠️ Failed to disable scrobble account for user %d: %v", userID, err)
+		}
+		log.Printf("⚠️ Scrobbling disabled for user %d: token rejected by %s", userID, account.APIURL)
+		return
+	}
+
+	if !listenbrainz.Retryable(err) && len(items) > 1 {
+		// Satu listen yang ditolak (4xx) membatalkan seluruh batch; kirim satu per satu
+		// supaya hanya listen itu yang ditandai failed
+		for _, item := range items {
+			s.submitUser(ctx, userID, []models.ScrobbleQueueItem{item})
+		}
+		return
+	}
+
+	attempts := 0
+	for _, item := range items {
+		attempts = max(attempts, item.Attempts)
+	}
+	retryAt := now.Add(scrobbleBackoff(attempts + 1))
+	maxAttempts := scrobbleMaxAttempts
+	var apiErr *listenbrainz.APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		retryAt = now.Add(apiErr.RetryAfter)
+	}
+	if !listenbrainz.Retryable(err) {
+		// Payload ditolak (4xx): mengulang tidak akan membantu
+		maxAttempts = 0
+	}
+	if err := s.repo.MarkAttemptFailed(ids, err.Error(), retryAt, maxAttempts); err != nil {
+		log.Printf("⚠️ Failed to reschedule scrobbles for user %d: %v", userID, err)
+	}
+	if err := s.repo.MarkSubmitError(userID, account.TokenEncrypted, err.Error()); err != nil {
+		log.Printf("⚠️ Failed to update scrobble account for user %d: %v", userID, err)
+	}
+	log.Printf("⚠️ Scrobble submit failed for user %d (%d listens), retry at %s: %v",
+		userID, len(items), retryAt.Format(time.RFC3339), err)
+}
+
+func (s *scrobbleService) clientFor(account *models.ScrobbleAccount) (*listenbrainz.Client, error) {
+	if s.cipher == nil {
+		return nil, ErrScrobbleUnavailable
+	}
+	token, err := s.cipher.Decrypt(account.TokenEncrypted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt scrobble token: %w", err)
+	}
+	return s.newClient(account.APIURL, token), nil
+}
+
+// scrobbleBackoff: exponential mulai 1 menit, dibatasi 6 jam.
+func scrobbleBackoff(attempt int) time.Duration {
+	wait := scrobbleBaseBackoff << (attempt - 1)
+	if wait > scrobbleMaxBackoff || wait <= 0 {
+		wait = scrobbleMaxBackoff
+	}
+	return wait
+}
+
+func trackMetadataFromSong(song *models.Song) listenbrainz.TrackMetadata {
+	return trackMetadata(song.Artist, song.Title, song.Album, song.DurationMs, song.SpotifyID, song.ISRC)
+}
+
+func listenFromQueueItem(item models.ScrobbleQueueItem) listenbrainz.Listen {
+	return listenbrainz.Listen{
+		ListenedAt:    item.ListenedAt.Unix(),
+		TrackMetadata: trackMetadata(item.ArtistName, item.TrackName, item.ReleaseName, item.DurationMs, item.SpotifyID, item.ISRC),
+	}
+}
+
+func trackMetadata(artist, title, release string, durationMs int, spotifyID, isrc string) listenbrainz.TrackMetadata {
+	info := map[string]interface{}{
+		"submission_client": scrobbleSubmissionClient,
+		"media_player":      scrobbleSubmissionClient,
+	}
+	if durationMs > 0 {
+		info["duration_ms"] = durationMs
+	}
+	if spotifyID != "" && !strings.HasPrefix(spotifyID, manualSpotifyIDPrefix) {
+		info["spotify_id"] = "https://open.spotify.com/track/" + spotifyID
+	}
+	if isrc != "" {
+		info["isrc"] = isrc
+	}
+	return listenbrainz.TrackMetadata{
+		ArtistName:     artist,
+		TrackName:      title,
+		ReleaseName:    release,
+		AdditionalInfo: info,
+	}
+}
@@ -0,0 +1,545 @@
+package services
+
+import (
+	"context"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+
+	"back_music/internal/models"
+	"back_music/internal/repository"
+)
+
+const (
+	SuggestionSong   = "song"
+	SuggestionArtist = "artist"
+	SuggestionAlbum  = "album"
+	SuggestionGenre  = "genre"
+)
+
+const (
+	// suggestMaxPrefixLen membatasi panjang prefix yang di-index; query lebih panjang
+	// dicari lewat prefix ini lalu difilter.
+	suggestMaxPrefixLen = 8
+	// suggestMaxCandidates membatasi jumlah entry yang di-scoring per query.
+	suggestMaxCandidates  = 200
+	suggestAffinityWeight = 0.5
+	suggestAffinityTTL    = 10 * time.Minute
+	suggestRebuildEvery   = 30 * time.Minute
+	// suggestReindexLimit: perubahan katalog yang lebih besar dari ini memicu rebuild penuh
+	// di background, bukan re-index per lagu.
+	suggestReindexLimit = 500
+)
+
+// suggestTypeLimits adalah jumlah maksimum saran per tipe, supaya hasilnya tetap campuran.
+var suggestTypeLimits = map[string]int{
+	SuggestionSong:   5,
+	SuggestionArtist: 3,
+	SuggestionAlbum:  3,
+	SuggestionGenre:  2,
+}
+
+// suggestTypeBoost sedikit mendahulukan artist/genre yang cocok dibanding lagu dengan skor setara.
+var suggestTypeBoost = map[string]float64{
+	SuggestionSong:   0,
+	SuggestionArtist: 0.1,
+	SuggestionAlbum:  0,
+	SuggestionGenre:  0.05,
+}
+
+// SearchSuggestion adalah satu item typeahead.
+type SearchSuggestion struct {
+	Type     string  `json:"type"`
+	ID       string  `json:"id,omitempty"`
+	Text     string  `json:"text"`
+	Subtitle string  `json:"subtitle,omitempty"`
+	ImageURL string  `json:"image_url,omitempty"`
+	Score    float64 `json:"score"`
+}
+
+type SearchSuggestService interface {
+	Suggest(query string, userID uint, limit int) []SearchSuggestion
+	Rebuild() error
+	IndexSong(song models.Song)
+	RemoveSong(songID string)
+	StartRefresher(ctx context.Context)
+}
+
+type suggestEntry struct {
+	key        string
+	suggestion SearchSuggestion
+	tokens     []string
+	popularity float64 // 0..1
+	artistIDs  []string
+	genre      string
+}
+
+type userAffinityCache struct {
+	affinity  *repository.UserAffinity
+	fetchedAt time.Time
+}
+
+type searchSuggestService struct {
+	songRepo   repository.SongRepository
+	artistRepo repository.ArtistRepository
+	albumRepo  repository.AlbumRepository
+	userRepo   repository.UserRepository
+
+	mu       sync.RWMutex
+	entries  map[string]*suggestEntry
+	prefixes map[string]map[string]*suggestEntry
+	// songArtists memetakan song ID ke artist ID, dipakai saat IndexSong
+	songArtists map[string][]string
+
+	affinityMu sync.Mutex
+	affinities map[uint]userAffinityCache
+}
+
+func NewSearchSuggestService(
+	songRepo repository.SongRepository,
+	artistRepo repository.ArtistRepository,
+	albumRepo repository.AlbumRepository,
+	userRepo repository.UserRepository,
+	catalogRepo repository.CatalogRepository,
+) SearchSuggestService {
+	s := &searchSuggestService{
+		songRepo:    songRepo,
+		artistRepo:  artistRepo,
+		albumRepo:   albumRepo,
+		userRepo:    userRepo,
+		entries:     make(map[string]*suggestEntry),
+		prefixes:    make(map[string]map[string]*suggestEntry),
+		songArtists: make(map[string][]string),
+		affinities:  make(map[uint]userAffinityCache),
+	}
+	songRepo.OnSongSaved(s.IndexSong)
+	catalogRepo.OnSongsChanged(s.songsChanged)
+	return s
+}
+
+// normalizeSuggestText: huruf kecil, tanpa aksen, hanya huruf/angka per kata.
+func normalizeSuggestText(text string) []string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(strings.ToLower(text)) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return strings.FieldsFunc(b.String(), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func indexPrefix(token string) string {
+	runes := []rune(token)
+	if len(runes) > suggestMaxPrefixLen {
+		runes = runes[:suggestMaxPrefixLen]
+	}
+	return string(runes)
+}
+
+// Rebuild membangun ulang seluruh index dari katalog.
+func (s *searchSuggestService) Rebuild() error {
+	start := time.Now()
+
+	songs, err := s.songRepo.GetAllSongs()
+	if err != nil {
+		return err
+	}
+	artists, err := s.artistRepo.GetAllArtists()
+	if err != nil {
+		return err
+	}
+	links, err := s.artistRepo.GetSongArtistLinks()
+	if err != nil {
+		return err
+	}
+	albums, err := s.albumRepo.GetAllAlbums()
+	if err != nil {
+		return err
+	}
+
+	songArtists := make(map[string][]string)
+	for _, link := range links {
+		songArtists[link.SongID] = append(songArtists[link.SongID], link.ArtistID)
+	}
+
+	entries := make(map[string]*suggestEntry)
+	artistPopularity := make(map[string]int)
+	albumPopularity := make(map[string]int)
+	genrePopularity := make(map[string]int)
+	genreNames := make(map[string]string)
+
+	for _, song := range songs {
+		entry := songSuggestEntry(song, songArtists[song.ID])
+		entries[entry.key] = entry
+
+		for _, artistID := range songArtists[song.ID] {
+			artistPopularity[artistID] = max(artistPopularity[artistID], song.Popularity)
+		}
+		if song.AlbumID != nil {
+			albumPopularity[*song.AlbumID] = max(albumPopularity[*song.AlbumID], song.Popularity)
+		}
+		if genre := strings.TrimSpace(song.Genre); genre != "" {
+			key := strings.ToLower(genre)
+			genrePopularity[key]++
+			genreNames[key] = genre
+		}
+	}
+
+	for _, artist := range artists {
+		entry := &suggestEntry{
+			key: SuggestionArtist + ":" + artist.ID,
+			suggestion: SearchSuggestion{
+				Type:     SuggestionArtist,
+				ID:       artist.ID,
+				Text:     artist.Name,
+				ImageURL: artist.ImageURL,
+			},
+			tokens:     normalizeSuggestText(artist.Name),
+			popularity: float64(artistPopularity[artist.ID]) / 100,
+			artistIDs:  []string{artist.ID},
+		}
+		entries[entry.key] = entry
+	}
+
+	for _, album := range albums {
+		entry := &suggestEntry{
+			key: SuggestionAlbum + ":" + album.ID,
+			suggestion: SearchSuggestion{
+				Type:     SuggestionAlbum,
+				ID:       album.ID,
+				Text:     album.Title,
+				Subtitle: album.ReleaseDate,
+			},
+			tokens:     normalizeSuggestText(album.Title),
+			popularity: float64(albumPopularity[album.ID]) / 100,
+		}
+		if len(album.Images) > 0 {
+			entry.suggestion.ImageURL = album.Images[0].URL
+		}
+		entries[entry.key] = entry
+	}
+
+	maxGenreCount := 1
+	for _, count := range genrePopularity {
+		maxGenreCount = max(maxGenreCount, count)
+	}
+	for key, count := range genrePopularity {
+		entry := genreSuggestEntry(genreNames[key])
+		entry.popularity = float64(count) / float64(maxGenreCount)
+		entries[entry.key] = entry
+	}
+
+	prefixes := make(map[string]map[string]*suggestEntry)
+	for _, entry := range entries {
+		addEntryPrefixes(prefixes, entry)
+	}
+
+	s.mu.Lock()
+	s.entries = entries
+	s.prefixes = prefixes
+	s.songArtists = songArtists
+	s.mu.Unlock()
+
+	log.Printf("🔎 Search suggest index built: %d entries in %v", len(entries), time.Since(start))
+	return nil
+}
+
+func songSuggestEntry(song models.Song, artistIDs []string) *suggestEntry {
+	return &suggestEntry{
+		key: SuggestionSong + ":" + song.ID,
+		suggestion: SearchSuggestion{
+			Type:     SuggestionSong,
+			ID:       song.ID,
+			Text:     song.Title,
+			Subtitle: song.Artist,
+			ImageURL: song.ImageURL,
+		},
+		// Judul dulu supaya kecocokan awal judul bisa dideteksi, lalu nama artist
+		tokens:     append(normalizeSuggestText(song.Title), normalizeSuggestText(song.Artist)...),
+		popularity: float64(song.Popularity) / 100,
+		artistIDs:  artistIDs,
+		genre:      song.Genre,
+	}
+}
+
+func genreSuggestEntry(genre string) *suggestEntry {
+	return &suggestEntry{
+		key: SuggestionGenre + ":" + strings.ToLower(genre),
+		suggestion: SearchSuggestion{
+			Type: SuggestionGenre,
+			Text: genre,
+		},
+		tokens: normalizeSuggestText(genre),
+		genre:  genre,
+	}
+}
+
+func addEntryPrefixes(prefixes map[string]map[string]*suggestEntry, entry *suggestEntry) {
+	for _, token := range entry.tokens {
+		runes := []rune(indexPrefix(token))
+		for i := 1; i <= len(runes); i++ {
+			prefix := string(runes[:i])
+			bucket, ok := prefixes[prefix]
+			if !ok {
+				bucket = make(map[string]*suggestEntry)
+				prefixes[prefix] = bucket
+			}
+			bucket[entry.key] = entry
+		}
+	}
+}
+
+func removeEntryPrefixes(prefixes map[string]map[string]*suggestEntry, entry *suggestEntry) {
+	for _, token := range entry.tokens {
+		runes := []rune(indexPrefix(token))
+		for i := 1; i <= len(runes); i++ {
+			prefix := string(runes[:i])
+			if bucket, ok := prefixes[prefix]; ok {
+				delete(bucket, entry.key)
+				if len(bucket) == 0 {
+					delete(prefixes, prefix)
+				}
+			}
+		}
+	}
+}
+
+// IndexSong memperbarui entry lagu (dan genre-nya) tanpa rebuild penuh.
+// Dipanggil lewat SongRepository.OnSongSaved.
+func (s *searchSuggestService) IndexSong(song models.Song) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := songSuggestEntry(song, s.songArtists[song.ID])
+	if old, ok := s.entries[entry.key]; ok {
+		removeEntryPrefixes(s.prefixes, old)
+	}
+	s.entries[entry.key] = entry
+	addEntryPrefixes(s.prefixes, entry)
+
+	if genre := strings.TrimSpace(song.Genre); genre != "" {
+		genreEntry := genreSuggestEntry(genre)
+		if _, ok := s.entries[genreEntry.key]; !ok {
+			s.entries[genreEntry.key] = genreEntry
+			addEntryPrefixes(s.prefixes, genreEntry)
+		}
+	}
+}
+
+// RemoveSong membuang entry lagu yang sudah dihapus (atau di-merge ke lagu lain).
+func (s *searchSuggestService) RemoveSong(songID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := SuggestionSong + ":" + songID
+	if old, ok := s.entries[key]; ok {
+		removeEntryPrefixes(s.prefixes, old)
+		delete(s.entries, key)
+	}
+	delete(s.songArtists, songID)
+}
+
+// songsChanged dipanggil lewat CatalogRepository.OnSongsChanged setelah delete, merge
+// atau bulk edit, supaya lagu yang terhapus tidak lagi muncul sebagai saran.
+func (s *searchSuggestService) songsChanged(savedIDs, deletedIDs []string) {
+	for _, songID := range deletedIDs {
+		s.RemoveSong(songID)
+	}
+	if len(savedIDs) == 0 {
+		return
+	}
+	if len(savedIDs) > suggestReindexLimit {
+		go func() {
+			if err := s.Rebuild(); err != nil {
+				log.Println("⚠️ Search suggest index build failed:", err)
+			}
+		}()
+		return
+	}
+
+	songs, err := s.songRepo.GetSongsByIDs(savedIDs)
+	if err != nil {
+		log.Printf("⚠️ Failed to re-index %d songs for search suggest: %v", len(savedIDs), err)
+		return
+	}
+	for _, song := range songs {
+		// Merge (ada lagu terhapus) memindahkan relasi artist ke lagu target; bulk edit tidak
+		if len(deletedIDs) > 0 {
+			if artists, err := s.artistRepo.GetArtistsForSong(song.ID); err == nil {
+				artistIDs := make([]string, len(artists))
+				for i, artist := range artists {
+					artistIDs[i] = artist.ID
+				}
+				s.mu.Lock()
+				s.songArtists[song.ID] = artistIDs
+				s.mu.Unlock()
+			}
+		}
+		s.IndexSong(song)
+	}
+}
+
+// matchesTokens: setiap token query harus menjadi prefix dari salah satu kata entry.
+func matchesTokens(entryTokens, queryTokens []string) bool {
+	for _, q := range queryTokens {
+		found := false
+		for _, t := range entryTokens {
+			if strings.HasPrefix(t, q) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Suggest mengembalikan saran campuran untuk prefix query, diurutkan berdasarkan
+// popularitas dan (jika login) afinitas user terhadap artist/genre.
+func (s *searchSuggestService) Suggest(query string, userID uint, limit int) []SearchSuggestion {
+	queryTokens := normalizeSuggestText(query)
+	if len(queryTokens) == 0 {
+		return []SearchSuggestion{}
+	}
+
+	// Bucket dari token query paling selektif (terpanjang)
+	lookup := queryTokens[0]
+	for _, token := range queryTokens[1:] {
+		if len([]rune(token)) > len([]rune(lookup)) {
+			lookup = token
+		}
+	}
+
+	s.mu.RLock()
+	var candidates []suggestEntry
+	for _, entry := range s.prefixes[indexPrefix(lookup)] {
+		if matchesTokens(entry.tokens, queryTokens) {
+			candidates = append(candidates, *entry)
+		}
+	}
+	s.mu.RUnlock()
+
+	if len(candidates) > suggestMaxCandidates {
+		sort.Slice(candidates, func(i, j int) bool {
+			return candidates[i].popularity > candidates[j].popularity
+		})
+		candidates = candidates[:suggestMaxCandidates]
+	}
+
+	affinity := s.getAffinity(userID)
+	scored := make([]SearchSuggestion, 0, len(candidates))
+	for _, entry := range candidates {
+		suggestion := entry.suggestion
+		suggestion.Score = entry.popularity + suggestTypeBoost[entry.suggestion.Type] +
+			suggestAffinityWeight*entryAffinity(entry, affinity)
+		// Kecocokan dari awal judul lebih relevan
+		if strings.HasPrefix(strings.Join(entry.tokens, " "), strings.Join(queryTokens, " ")) {
+			suggestion.Score += 0.2
+		}
+		scored = append(scored, suggestion)
+	}
+
+	sort.SliceStable(scored, func(i, j int) bool {
+		return scored[i].Score > scored[j].Score
+	})
+
+	results := make([]SearchSuggestion, 0, limit)
+	perType := make(map[string]int)
+	for _, suggestion := range scored {
+		if len(results) >= limit {
+			break
+		}
+		if perType[suggestion.Type] >= suggestTypeLimits[suggestion.Type] {
+			continue
+		}
+		perType[suggestion.Type]++
+		suggestion.Score = math.Round(suggestion.Score*1000) / 1000
+		results = append(results, suggestion)
+	}
+	return results
+}
+
+// entryAffinity mengembalikan 0..1 berdasarkan bobot artist/genre user.
+func entryAffinity(entry suggestEntry, affinity *repository.UserAffinity) float64 {
+	if affinity == nil {
+		return 0
+	}
+	var weight float64
+	for _, artistID := range entry.artistIDs {
+		weight = math.Max(weight, affinity.Artists[artistID])
+	}
+	if entry.genre != "" {
+		weight = math.Max(weight, affinity.Genres[entry.genre]/2)
+	}
+	// 1 - e^(-w/3): satu like sudah memberi ~0.63
+	return 1 - math.Exp(-weight/3)
+}
+
+func (s *searchSuggestService) getAffinity(userID uint) *repository.UserAffinity {
+	if userID == 0 {
+		return nil
+	}
+
+	s.affinityMu.Lock()
+	cached, ok := s.affinities[userID]
+	s.affinityMu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < suggestAffinityTTL {
+		return cached.affinity
+	}
+
+	affinity, err := s.userRepo.GetUserAffinity(userID)
+	if err != nil {
+		log.Printf("⚠️ Failed to load affinity for user %d: %v", userID, err)
+		return cached.affinity
+	}
+
+	s.affinityMu.Lock()
+	s.affinities[userID] = userAffinityCache{affinity: affinity, fetchedAt: time.Now()}
+	s.affinityMu.Unlock()
+	return affinity
+}
+
+// StartRefresher membangun index sekali di background lalu rebuild berkala
+// (untuk artist/album baru dan cache afinitas yang kedaluwarsa).
+func (s *searchSuggestService) StartRefresher(ctx context.Context) {
+	go func() {
+		if err := s.Rebuild(); err != nil {
+			log.Println("⚠️ Search suggest index build failed:", err)
+		}
+
+		ticker := time.NewTicker(suggestRebuildEvery)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.Rebuild(); err != nil {
+					log.Println("⚠️ Search suggest index build failed:", err)
+				}
+				s.pruneAffinities()
+			}
+		}
+	}()
+}
+
+func (s *searchSuggestService) pruneAffinities() {
+	s.affinityMu.Lock()
+	defer s.affinityMu.Unlock()
+	for userID, cached := range s.affinities {
+		if time.Since(cached.fetchedAt) >= suggestAffinityTTL {
+			delete(s.affinities, userID)
+		}
+	}
+}
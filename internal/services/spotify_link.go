@@ -0,0 +1,422 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"back_music/internal/config"
+	"back_music/internal/encryption"
+	"back_music/internal/models"
+	"back_music/internal/repository"
+	"back_music/internal/spotify"
+)
+
+const (
+	// spotifyAuthStateTTL: berapa lama user boleh berada di halaman consent Spotify.
+	spotifyAuthStateTTL = 10 * time.Minute
+
+	spotifySavedTracksPageSize    = 50
+	spotifyPlaylistTracksPageSize = 100
+	spotifyMaxSavedTracks         = 2000
+	spotifyMaxPlaylists           = 50
+	spotifyMaxPlaylistTracks      = 500
+	spotifyTopTracksLimit         = 50
+	spotifyTopTracksExternalID    = "top_tracks:medium_term"
+)
+
+var ErrSpotifyLinkUnavailable = errors.New("spotify account linking is not configured")
+
+// SpotifyLibraryImportResult adalah hasil job import library Spotify user.
+type SpotifyLibraryImportResult struct {
+	SavedTracks    int `json:"saved_tracks"`
+	LikesAdded     int `json:"likes_added"`
+	TopTracks      int `json:"top_tracks"`
+	Playlists      int `json:"playlists"`
+	PlaylistTracks int `json:"playlist_tracks"`
+	SongsCreated   int `json:"songs_created"`
+}
+
+type SpotifyLinkService interface {
+	// StartLink membuat state + PKCE verifier dan mengembalikan URL consent Spotify.
+	StartLink(userID uint) (string, error)
+	// CompleteLink dipanggil dari callback: tukar code, simpan akun, lalu mulai import library.
+	CompleteLink(ctx context.Context, state, code string) (*models.SpotifyAccount, *models.Job, error)
+	GetAccount(userID uint) (*models.SpotifyAccount, error)
+	Unlink(userID uint) error
+	StartLibraryImport(userID uint) (*models.Job, error)
+	GetImportJob(userID uint, jobID string) (*models.Job, error)
+}
+
+type spotifyLinkService struct {
+	client         *spotify.Client
+	cipher         *encryption.Cipher
+	redirectURI    string
+	accountRepo    repository.SpotifyAccountRepository
+	songRepo       repository.SongRepository
+	playlistRepo   repository.PlaylistRepository
+	spotifyService SpotifyService
+	jobRunner      JobRunner
+}
+
+func NewSpotifyLinkService(
+	client *spotify.Client,
+	accountRepo repository.SpotifyAccountRepository,
+	songRepo repository.SongRepository,
+	playlistRepo repository.PlaylistRepository,
+	spotifyService SpotifyService,
+	jobRunner JobRunner,
+) SpotifyLinkService {
+	cfg := config.GlobalConfig
+	return &spotifyLinkService{
+		client:         client,
+		cipher:         newTokenCipher(),
+		redirectURI:    cfg.RedirectURI,
+		accountRepo:    accountRepo,
+		songRepo:       songRepo,
+		playlistRepo:   playlistRepo,
+		spotifyService: spotifyService,
+		jobRunner:      jobRunner,
+	}
+}
+
+func randomState() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func (s *spotifyLinkService) StartLink(userID uint) (string, error) {
+	if s.cipher == nil || config.GlobalConfig.SpotifyClientID == "" {
+		return "", ErrSpotifyLinkUnavailable
+	}
+
+	verifier, challenge, err := spotify.NewPKCEVerifier()
+	if err != nil {
+		return "", err
+	}
+	state, err := randomState()
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.accountRepo.SaveAuthState(&models.SpotifyAuthState{
+		State:        state,
+		UserID:       userID,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(spotifyAuthStateTTL),
+	}); err != nil {
+		return "", err
+	}
+
+	return s.client.AuthorizeURL(s.redirectURI, state, challenge, spotify.UserScopes), nil
+}
+
+func (s *spotifyLinkService) CompleteLink(ctx context.Context, state, code string) (*models.SpotifyAccount, *models.Job, error) {
+	authState, err := s.accountRepo.ConsumeAuthState(state)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	token, err := s.client.ExchangeCode(ctx, code, authState.CodeVerifier, s.redirectURI)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to exchange spotify code: %w", err)
+	}
+	if token.RefreshToken == "" {
+		return nil, nil, errors.New("spotify did not return a refresh token")
+	}
+
+	profile, err := s.client.CurrentUser(ctx, token.AccessToken)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch spotify profile: %w", err)
+	}
+
+	encrypted, err := s.cipher.Encrypt(token.RefreshToken)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	account := &models.SpotifyAccount{
+		UserID:                authState.UserID,
+		SpotifyUserID:         profile.ID,
+		DisplayName:           profile.DisplayName,
+		Scopes:                token.Scope,
+		RefreshTokenEncrypted: encrypted,
+		LinkedAt:              time.Now(),
+	}
+	if err := s.accountRepo.Upsert(account); err != nil {
+		return nil, nil, err
+	}
+	log.Printf("🔗 User %d linked Spotify account %s", account.UserID, account.SpotifyUserID)
+
+	// Import langsung supaya rekomendasi tidak mulai dari nol
+	job, err := s.StartLibraryImport(account.UserID)
+	if err != nil {
+		log.Printf("⚠️ Failed to start Spotify library import for user %d: %v", account.UserID, err)
+	}
+	return account, job, nil
+}
+
+func (s *spotifyLinkService) GetAccount(userID uint) (*models.SpotifyAccount, error) {
+	return s.accountRepo.GetByUserID(userID)
+}
+
+func (s *spotifyLinkService) Unlink(userID uint) error {
+	return s.accountRepo.Delete(userID)
+}
+
+func (s *spotifyLinkService) StartLibraryImport(userID uint) (*models.Job, error) {
+	if s.cipher == nil {
+		return nil, ErrSpotifyLinkUnavailable
+	}
+	if _, err := s.accountRepo.GetByUserID(userID); err != nil {
+		return nil, err
+	}
+
+	// Satu import per user: job paralel saling berebut rotasi refresh token
+	params := map[string]interface{}{"user_id": userID}
+	return s.jobRunner.StartExclusive(userID, models.JobTypeSpotifyLibrary, params, func(ctx context.Context, progress JobProgress) (interface{}, error) {
+		return s.importLibrary(ctx, userID, progress)
+	})
+}
+
+// GetImportJob hanya mengembalikan job import library milik user sendiri.
+func (s *spotifyLinkService) GetImportJob(userID uint, jobID string) (*models.Job, error) {
+	job, err := s.jobRunner.Get(jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Type != models.JobTypeSpotifyLibrary || job.CreatedBy != userID {
+		return nil, repository.ErrJobNotFound
+	}
+	return job, nil
+}
+
+// userAccessToken mendekripsi refresh token dan menukarnya dengan access token baru.
+// Jika Spotify merotasi refresh token, token baru wajib tersimpan: token lama sudah tidak
+// berlaku, jadi gagal menyimpan dikembalikan sebagai error.
+func (s *spotifyLinkService) userAccessToken(ctx context.Context, account *models.SpotifyAccount) (string, error) {
+	refreshToken, err := s.cipher.Decrypt(account.RefreshTokenEncrypted)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt spotify refresh token: %w", err)
+	}
+
+	token, err := s.client.RefreshUserToken(ctx, refreshToken)
+	if err != nil {
+		return "", fmt.Errorf("failed to refresh spotify user token: %w", err)
+	}
+
+	if token.RefreshToken != "" && token.RefreshToken != refreshToken {
+		encrypted, err := s.cipher.Encrypt(token.RefreshToken)
+		if err != nil {
+			return "", err
+		}
+		if err := s.accountRepo.UpdateRefreshToken(account.UserID, account.RefreshTokenEncrypted, encrypted); err != nil {
+			return "", fmt.Errorf("failed to store rotated spotify refresh token: %w", err)
+		}
+		account.RefreshTokenEncrypted = encrypted
+	}
+	return token.AccessToken, nil
+}
+
+// importLibrary: saved tracks -> UserLike, top tracks dan playlist user -> playlist spotify_import.
+// Progress dihitung per langkah (saved tracks, top tracks, tiap playlist).
+func (s *spotifyLinkService) importLibrary(ctx context.Context, userID uint, progress JobProgress) (*SpotifyLibraryImportResult, error) {
+	account, err := s.accountRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	token, err := s.userAccessToken(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SpotifyLibraryImportResult{}
+
+	playlists, err := s.fetchPlaylists(ctx, token)
+	if err != nil {
+		progress.LogError("playlists: %v", err)
+	}
+	progress.SetTotal(2 + len(playlists))
+
+	// 1. Saved tracks -> likes
+	saved, err := s.fetchSavedTracks(ctx, token)
+	if err != nil {
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+		progress.LogError("saved tracks: %v", err)
+	}
+	if len(saved) > 0 {
+		songIDs, created, err := s.spotifyService.ImportTracks(saved)
+		if err != nil {
+			return result, err
+		}
+		result.SavedTracks = len(saved)
+		result.SongsCreated += created
+
+		ids := make([]string, 0, len(songIDs))
+		for _, track := range saved {
+			if id := songIDs[track.ID]; id != "" {
+				ids = append(ids, id)
+			}
+		}
+		added, err := s.songRepo.AddLikes(userID, ids)
+		if err != nil {
+			return result, err
+		}
+		result.LikesAdded = added
+	}
+	progress.Advance(1)
+
+	// 2. Top tracks -> playlist
+	top, err := s.client.TopTracks(ctx, token, "medium_term", spotifyTopTracksLimit)
+	if err != nil {
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+		progress.LogError("top tracks: %v", err)
+	} else if len(top) > 0 {
+		created, err := s.savePlaylist(userID, spotifyTopTracksExternalID, "Top Tracks (Spotify)",
+			"Lagu yang paling sering kamu dengarkan di Spotify", top)
+		if err != nil {
+			progress.LogError("top tracks playlist: %v", err)
+		}
+		result.TopTracks = len(top)
+		result.SongsCreated += created
+	}
+	progress.Advance(1)
+
+	// 3. Playlist user
+	for _, playlist := range playlists {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		tracks, err := s.fetchPlaylistTracks(ctx, token, playlist.ID)
+		if err != nil {
+			progress.LogError("playlist %s (%s): %v", playlist.Name, playlist.ID, err)
+			progress.Advance(1)
+			continue
+		}
+		created, err := s.savePlaylist(userID, playlist.ID, playlist.Name, playlist.Description, tracks)
+		if err != nil {
+			progress.LogError("playlist %s (%s): %v", playlist.Name, playlist.ID, err)
+		} else {
+			result.Playlists++
+			result.PlaylistTracks += len(tracks)
+		}
+		result.SongsCreated += created
+		progress.Advance(1)
+	}
+
+	if err := s.accountRepo.MarkImported(userID, time.Now()); err != nil {
+		log.Printf("⚠️ Failed to update Spotify account for user %d: %v", userID, err)
+	}
+
+	log.Printf("🔗 Spotify library imported for user %d: %d likes added, %d playlists, %d new songs",
+		userID, result.LikesAdded, result.Playlists, result.SongsCreated)
+	return result, nil
+}
+
+func (s *spotifyLinkService) fetchSavedTracks(ctx context.Context, token string) ([]spotify.Track, error) {
+	var tracks []spotify.Track
+	for offset := 0; offset < spotifyMaxSavedTracks; offset += spotifySavedTracksPageSize {
+		page, err := s.client.SavedTracks(ctx, token, offset, spotifySavedTracksPageSize)
+		if err != nil {
+			return tracks, err
+		}
+		for _, item := range page.Items {
+			tracks = append(tracks, item.Track)
+		}
+		if page.Next == "" {
+			break
+		}
+	}
+	return tracks, nil
+}
+
+func (s *spotifyLinkService) fetchPlaylists(ctx context.Context, token string) ([]spotify.SimplifiedPlaylist, error) {
+	var playlists []spotify.SimplifiedPlaylist
+	for offset := 0; offset < spotifyMaxPlaylists; offset += 50 {
+		page, err := s.client.UserPlaylists(ctx, token, offset, 50)
+		if err != nil {
+			return playlists, err
+		}
+		for _, playlist := range page.Items {
+			if playlist.ID != "" && len(playlists) < spotifyMaxPlaylists {
+				playlists = append(playlists, playlist)
+			}
+		}
+		if page.Next == "" {
+			break
+		}
+	}
+	return playlists, nil
+}
+
+func (s *spotifyLinkService) fetchPlaylistTracks(ctx context.Context, token, playlistID string) ([]spotify.Track, error) {
+	var tracks []spotify.Track
+	for offset := 0; offset < spotifyMaxPlaylistTracks; offset += spotifyPlaylistTracksPageSize {
+		page, err := s.client.PlaylistTracks(ctx, token, playlistID, offset, spotifyPlaylistTracksPageSize)
+		if err != nil {
+			return tracks, err
+		}
+		for _, item := range page.Items {
+			// Lagu lokal / yang sudah dihapus dari Spotify dilewati
+			if item.IsLocal || item.Track == nil || item.Track.ID == "" {
+				continue
+			}
+			tracks = append(tracks, *item.Track)
+		}
+		if page.Next == "" {
+			break
+		}
+	}
+	return tracks, nil
+}
+
+// savePlaylist menyimpan playlist Spotify sebagai playlist spotify_import (import ulang mengganti isinya).
+func (s *spotifyLinkService) savePlaylist(userID uint, externalID, name, description string, tracks []spotify.Track) (int, error) {
+	songIDs, created, err := s.spotifyService.ImportTracks(tracks)
+	if err != nil {
+		return created, err
+	}
+
+	now := time.Now()
+	items := make([]models.PlaylistItem, 0, len(tracks))
+	seen := make(map[string]bool, len(tracks))
+	for _, track := range tracks {
+		songID := songIDs[track.ID]
+		if songID == "" || seen[songID] {
+			continue
+		}
+		seen[songID] = true
+		items = append(items, models.PlaylistItem{
+			SongID:   songID,
+			Position: len(items) + 1,
+			Source:   "spotify",
+			AddedAt:  now,
+		})
+	}
+
+	if name == "" {
+		name = "Spotify Playlist"
+	}
+	playlist := &models.Playlist{
+		UserID:      userID,
+		Name:        name,
+		Description: description,
+		Kind:        models.PlaylistKindSpotifyImport,
+		SystemOwned: true,
+		ExternalID:  externalID,
+		Items:       items,
+	}
+	return created, s.playlistRepo.UpsertExternalPlaylist(playlist)
+}
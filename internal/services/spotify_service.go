@@ -1,14 +1,11 @@
 package services
 
 import (
-	"encoding/base64"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"io"
 	"log"
 	"math/rand"
-	"net/http"
-	"net/url"
 	"sort"
 	"strings"
 	"time"
@@ -16,6 +13,7 @@ import (
 	"back_music/internal/config"
 	"back_music/internal/models"
 	"back_music/internal/repository"
+	"back_music/internal/spotify"
 )
 
 type SpotifyService interface {
@@ -23,81 +21,62 @@ type SpotifyService interface {
     SearchTracks(query string, limit int) ([]models.Song, error)
     GetAudioFeatures(trackID string) (*models.AudioFeatures, error)
     GetMultipleAudioFeatures(trackIDs []string) (map[string]models.AudioFeatures, error)
-    SeedSongsFromSpotify(limit int) error
+    SeedSongsFromSpotify(ctx context.Context, profile string, limit int, progress JobProgress) (*SeedResult, error)
     GetRecommendations(seedTracks []string, limit int) ([]models.Song, error)
-     GetPopularIndonesianSongs(limit int) ([]models.Song, error) 
+    GetPopularSongs(profile string, limit int) ([]models.Song, error)
+    GetSeedProfile(name string) (*config.SeedProfile, error)
+    ListSeedProfiles() []config.SeedProfile
+    TokenStats() spotify.TokenStats
+    // ImportTracks menyimpan track Spotify yang belum ada di katalog.
+    // Mengembalikan map spotify_id -> song ID dan jumlah lagu baru.
+    ImportTracks(tracks []spotify.Track) (map[string]string, int, error)
 }
 
+var ErrUnknownSeedProfile = errors.New("unknown seed profile")
+
 type spotifyService struct {
-    clientID     string
-    clientSecret string
-    accessToken  string
-    tokenExpiry  time.Time
+    client       *spotify.Client
     songRepo     repository.SongRepository
+    artistRepo   repository.ArtistRepository
+    albumRepo    repository.AlbumRepository
+    
+    seedProfiles   map[string]config.SeedProfile
+    defaultProfile string
+}
+
+// NewSpotifyClient membuat client Spotify dari config global; satu instance dipakai bersama
+// (token app di-cache per client).
+func NewSpotifyClient() *spotify.Client {
+    cfg := config.GlobalConfig
+    return spotify.NewClient(spotify.Config{
+        ClientID:        cfg.SpotifyClientID,
+        ClientSecret:    cfg.SpotifyClientSecret,
+        APIBaseURL:      cfg.SpotifyAPIBaseURL,
+        AccountsBaseURL: cfg.SpotifyAccountsBaseURL,
+    })
 }
 
-func NewSpotifyService(songRepo repository.SongRepository) SpotifyService {
+func NewSpotifyService(client *spotify.Client, songRepo repository.SongRepository, artistRepo repository.ArtistRepository, albumRepo repository.AlbumRepository) SpotifyService {
     cfg := config.GlobalConfig
     return &spotifyService{
-        clientID:     cfg.SpotifyClientID,
-        clientSecret: cfg.SpotifyClientSecret,
+        client:       client,
         songRepo:     songRepo,
+        artistRepo:   artistRepo,
+        albumRepo:    albumRepo,
+        
+        seedProfiles:   cfg.SeedProfiles,
+        defaultProfile: cfg.DefaultSeedProfile,
     }
 }
 
-type spotifyTokenResponse struct {
-    AccessToken string `json:"access_token"`
-    TokenType   string `json:"token_type"`
-    ExpiresIn   int    `json:"expires_in"`
-}
-
 func (s *spotifyService) GetAccessToken() (string, error) {
-    if time.Now().Before(s.tokenExpiry) && s.accessToken != "" {
-        return s.accessToken, nil
-    }
-    
-    auth := base64.StdEncoding.EncodeToString([]byte(s.clientID + ":" + s.clientSecret))
-    
-    data := url.Values{}
-    data.Set("grant_type", "client_credentials")
-    
-    req, err := http.NewRequest("POST", "https://accounts.spotify.com/api/token", 
-        strings.NewReader(data.Encode()))
-    if err != nil {
-        return "", err
-    }
-    
-    req.Header.Set("Authorization", "Basic "+auth)
-    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-    
-    client := &http.Client{Timeout: 10 * time.Second}
-    resp, err := client.Do(req)
-    if err != nil {
-        return "", err
-    }
-    defer resp.Body.Close()
-    
-    body, err := io.ReadAll(resp.Body)
-    if err != nil {
-        return "", err
-    }
-    
-    if resp.StatusCode != http.StatusOK {
-        return "", fmt.Errorf("failed to get token: %s", string(body))
-    }
-    
-    var tokenResp spotifyTokenResponse
-    if err := json.Unmarshal(body, &tokenResp); err != nil {
-        return "", err
-    }
-    
-    s.accessToken = tokenResp.AccessToken
-    s.tokenExpiry = time.Now().Add(time.Duration(tokenResp.ExpiresIn-60) * time.Second)
-    
-    return s.accessToken, nil
+    return s.client.Token(context.Background())
 }
 
-
+// TokenStats dipakai admin untuk memantau kegagalan refresh token Spotify.
+func (s *spotifyService) TokenStats() spotify.TokenStats {
+    return s.client.TokenStats()
+}
 
 //  TAMBAH fungsi helper untuk generate dummy features
 func (s *spotifyService) generateDummyFeatures(trackID string) models.AudioFeatures {
@@ -181,178 +160,242 @@ func (s *spotifyService) GetMultipleAudioFeatures(trackIDs []string) (map[string
 }
 
 
+// SearchTracks mencari di market utama profile seeding default.
 func (s *spotifyService) SearchTracks(query string, limit int) ([]models.Song, error) {
-    token, err := s.GetAccessToken()
-    if err != nil {
-        return nil, err
+    market, genre := "", ""
+    if profile, err := s.GetSeedProfile(""); err == nil {
+        market, genre = profile.PrimaryMarket(), profile.Genre
     }
-    
-    // PERBAIKAN: Jangan tambahkan "genre:indonesian market:ID" di sini
-    // Hanya encode query asli
-    encodedQuery := url.QueryEscape(query)
-    
-    // PERBAIKAN: Gunakan parameter market=ID yang benar
-    url := fmt.Sprintf("https://api.spotify.com/v1/search?q=%s&type=track&limit=%d&market=ID", 
-        encodedQuery, limit)
-    
-    log.Printf("🔍 Spotify API Request: %s", url)
-    
-    req, err := http.NewRequest("GET", url, nil)
+    songs, err := s.searchTracks(context.Background(), query, limit, market)
     if err != nil {
         return nil, err
     }
-    
-    req.Header.Set("Authorization", "Bearer "+token)
-    
-    client := &http.Client{Timeout: 10 * time.Second}
-    resp, err := client.Do(req)
+    for i := range songs {
+        songs[i].Genre = genre
+    }
+    return songs, nil
+}
+
+func (s *spotifyService) searchTracks(ctx context.Context, query string, limit int, market string) ([]models.Song, error) {
+    tracks, err := s.client.SearchTracks(ctx, query, market, limit)
     if err != nil {
+        log.Printf("❌ Spotify search failed for '%s': %v", query, err)
         return nil, err
     }
-    defer resp.Body.Close()
     
-    body, err := io.ReadAll(resp.Body)
-    if err != nil {
-        return nil, err
+    if len(tracks) == 0 {
+        log.Printf("⚠️ Tidak ditemukan track untuk query: '%s'", query)
+        return []models.Song{}, nil
     }
     
-    if resp.StatusCode != http.StatusOK {
-        log.Printf("❌ Spotify search failed (%d): %s", resp.StatusCode, string(body))
-        return nil, fmt.Errorf("search failed: %s", string(body))
+    songs := make([]models.Song, 0, len(tracks))
+    for _, track := range tracks {
+        // Item null / tanpa ID kadang muncul di hasil search
+        if track.ID == "" || track.Name == "" {
+            continue
+        }
+        song := s.songFromTrack(track)
+        songs = append(songs, song)
+        log.Printf("✅ Found: %s - %s (Popularity: %d)", 
+            song.Artist, song.Title, song.Popularity)
     }
     
-    var result map[string]interface{}
-    if err := json.Unmarshal(body, &result); err != nil {
-        return nil, err
+    log.Printf("🎯 Total found for query '%s': %d tracks", query, len(songs))
+    return songs, nil
+}
+
+// songFromTrack memetakan track Spotify ke Song (fitur audio masih dummy).
+func (s *spotifyService) songFromTrack(track spotify.Track) models.Song {
+    artistCredits := make([]models.ArtistCredit, 0, len(track.Artists))
+    for _, artist := range track.Artists {
+        artistCredits = append(artistCredits, models.ArtistCredit{
+            SpotifyID: artist.ID,
+            Name:      artist.Name,
+        })
     }
     
-    tracks := result["tracks"].(map[string]interface{})["items"].([]interface{})
+    album := track.Album
+    imageURL := ""
+    if len(album.Images) > 0 {
+        imageURL = album.Images[0].URL
+    }
     
-    if len(tracks) == 0 {
-        log.Printf("⚠️ Tidak ditemukan track untuk query: '%s'", query)
-        return []models.Song{}, nil
+    // Album entity (cover art semua ukuran)
+    var spotifyAlbum *models.Album
+    if album.ID != "" {
+        albumImages := make([]models.AlbumImage, 0, len(album.Images))
+        for _, image := range album.Images {
+            albumImages = append(albumImages, models.AlbumImage{URL: image.URL, Width: image.Width, Height: image.Height})
+        }
+        spotifyAlbum = &models.Album{
+            SpotifyID:            album.ID,
+            Title:                album.Name,
+            AlbumType:            album.AlbumType,
+            ReleaseDate:          album.ReleaseDate,
+            ReleaseDatePrecision: album.ReleaseDatePrecision,
+            TotalTracks:          album.TotalTracks,
+            Images:               albumImages,
+        }
     }
     
-    songs := make([]models.Song, 0, len(tracks))
+    // Generate dummy features
+    features := s.generateDummyFeatures(track.ID)
+    
+    return models.Song{
+        SpotifyID:        track.ID,
+        Title:            track.Name,
+        Artist:           strings.Join(track.ArtistNames(), ", "),
+        Album:            album.Name,
+        Popularity:       track.Popularity,
+        DurationMs:       track.DurationMs,
+        // ISRC untuk deteksi rekaman duplikat (remaster, single vs album)
+        ISRC:             strings.ToUpper(track.ExternalIDs.ISRC),
+        PreviewURL:       track.PreviewURL,
+        ImageURL:         imageURL,
+        ArtistCredits:    artistCredits,
+        SpotifyAlbum:     spotifyAlbum,
+        DiscNumber:       track.DiscNumber,
+        TrackNumber:      track.TrackNumber,
+        
+        // Audio features dari dummy
+        Danceability:     features.Danceability,
+        Energy:           features.Energy,
+        Key:              features.Key,
+        Loudness:         features.Loudness,
+        Mode:             features.Mode,
+        Speechiness:      features.Speechiness,
+        Acousticness:     features.Acousticness,
+        Instrumentalness: features.Instrumentalness,
+        Liveness:         features.Liveness,
+        Valence:          features.Valence,
+        Tempo:            features.Tempo,
+        TimeSignature:    features.TimeSignature,
+    }
+}
+
+func (s *spotifyService) ImportTracks(tracks []spotify.Track) (map[string]string, int, error) {
+    songIDs := make(map[string]string, len(tracks))
+    created := 0
     
     for _, track := range tracks {
-        trackMap := track.(map[string]interface{})
-        
-        // Get artists
-        artists := trackMap["artists"].([]interface{})
-        artistNames := make([]string, 0, len(artists))
-        for _, artist := range artists {
-            artistMap := artist.(map[string]interface{})
-            artistNames = append(artistNames, artistMap["name"].(string))
+        if track.ID == "" || track.Name == "" || songIDs[track.ID] != "" {
+            continue
         }
         
-        // Get album
-        album := trackMap["album"].(map[string]interface{})
-        albumName := album["name"].(string)
-        
-        // Get images
-        images := album["images"].([]interface{})
-        imageURL := ""
-        if len(images) > 0 {
-            imageURL = images[0].(map[string]interface{})["url"].(string)
+        existing, err := s.songRepo.GetSongBySpotifyID(track.ID)
+        if err == nil {
+            songIDs[track.ID] = existing.ID
+            continue
         }
-        
-        // Get preview URL
-        previewURL, _ := trackMap["preview_url"].(string)
-        
-        // Generate dummy features
-        trackID := trackMap["id"].(string)
-        features := s.generateDummyFeatures(trackID)
-        
-        song := models.Song{
-            SpotifyID:        trackID,
-            Title:           trackMap["name"].(string),
-            Artist:          strings.Join(artistNames, ", "),
-            Album:           albumName,
-            Genre:           "indonesian", // Default untuk lagu Indonesia
-            Popularity:      int(trackMap["popularity"].(float64)),
-            DurationMs:      int(trackMap["duration_ms"].(float64)),
-            PreviewURL:      previewURL,
-            ImageURL:        imageURL,
-            
-            // Audio features dari dummy
-            Danceability:    features.Danceability,
-            Energy:          features.Energy,
-            Key:             features.Key,
-            Loudness:        features.Loudness,
-            Mode:            features.Mode,
-            Speechiness:     features.Speechiness,
-            Acousticness:    features.Acousticness,
-            Instrumentalness: features.Instrumentalness,
-            Liveness:        features.Liveness,
-            Valence:         features.Valence,
-            Tempo:           features.Tempo,
-            TimeSignature:   features.TimeSignature,
+        if !errors.Is(err, repository.ErrSongNotFound) {
+            log.Printf("❌ Gagal cek lagu '%s': %v", track.Name, err)
+            continue
         }
         
-        songs = append(songs, song)
-        log.Printf("✅ Found: %s - %s (Popularity: %d)", 
-            song.Artist, song.Title, song.Popularity)
+        song := s.songFromTrack(track)
+        if err := s.ensureAlbum(&song); err != nil {
+            log.Printf("⚠️ Gagal menyimpan album '%s': %v", song.Album, err)
+        }
+        if err := s.songRepo.CreateSong(&song); err != nil {
+            log.Printf("❌ Gagal menyimpan '%s': %v", song.Title, err)
+            continue
+        }
+        if err := s.artistRepo.LinkSongArtists(song.ID, song.ArtistCredits); err != nil {
+            log.Printf("⚠️ Gagal link artist untuk '%s': %v", song.Title, err)
+        }
+        songIDs[track.ID] = song.ID
+        created++
     }
     
-    log.Printf("🎯 Total found for query '%s': %d tracks", query, len(songs))
+    return songIDs, created, nil
+}
+
+// SeedResult adalah ringkasan satu kali seeding, disimpan sebagai hasil job.
+type SeedResult struct {
+    Profile   string `json:"profile"`
+    Requested int    `json:"requested"`
+    Found     int    `json:"found"`
+    Saved     int    `json:"saved"`
+    Skipped   int    `json:"skipped"`
+    Failed    int    `json:"failed"`
+}
+
+// GetSeedProfile mencari profile seeding; nama kosong = profile default dari config.
+func (s *spotifyService) GetSeedProfile(name string) (*config.SeedProfile, error) {
+    name = strings.ToLower(strings.TrimSpace(name))
+    if name == "" {
+        name = s.defaultProfile
+    }
+    profile, ok := s.seedProfiles[name]
+    if !ok {
+        return nil, fmt.Errorf("%w: %s", ErrUnknownSeedProfile, name)
+    }
+    return &profile, nil
+}
+
+func (s *spotifyService) ListSeedProfiles() []config.SeedProfile {
+    profiles := make([]config.SeedProfile, 0, len(s.seedProfiles))
+    for _, profile := range s.seedProfiles {
+        profiles = append(profiles, profile)
+    }
+    sort.Slice(profiles, func(i, j int) bool { return profiles[i].Name < profiles[j].Name })
+    return profiles
+}
+
+// searchProfileQuery menjalankan satu query di semua market profile.
+func (s *spotifyService) searchProfileQuery(ctx context.Context, profile *config.SeedProfile, query config.SeedQuery) ([]models.Song, error) {
+    markets := profile.Markets
+    if len(markets) == 0 {
+        markets = []string{""}
+    }
+    
+    var songs []models.Song
+    var lastErr error
+    for _, market := range markets {
+        found, err := s.searchTracks(ctx, query.Query, profile.QueryLimitFor(query), market)
+        if err != nil {
+            lastErr = err
+            continue
+        }
+        songs = append(songs, found...)
+    }
+    if len(songs) == 0 && lastErr != nil {
+        return nil, lastErr
+    }
     return songs, nil
 }
 
-func (s *spotifyService) SeedSongsFromSpotify(limit int) error {
-    log.Printf("🚀 Memulai seed %d lagu Indonesia dari Spotify...", limit)
-    
-    // PERBAIKAN: Gunakan query yang lebih efektif
-    queries := []string{
-        // Query langsung dengan nama artis populer Indonesia
-        "tulus",
-        "raisa",
-        "nadin amizah",
-        "sheila on 7",
-        "noah band",
-        "ungu band",
-        "rossa",
-        "judika",
-        "armada band",
-        "virgoun",
-        "tiara andini",
-        "lyodra",
-        "ziva magnolya",
-        "yovie & nuno",
-        "kerispatih",
-        "d'masiv",
-        "vierratale",
-        "geisha band",
-        
-        // Query lagu populer Indonesia
-        "lagu indonesia terbaru",
-        "indonesian pop",
-        "pop indonesia 2024",
-        "lagu indonesia viral",
-        "chart indonesia",
-        
-        // Query dengan tanda kutip untuk hasil lebih spesifik
-        "\"tulus\"",
-        "\"raisa\"",
-        "\"nadin\"",
-        "\"sheila on 7\"",
+// SeedSongsFromSpotify dijalankan sebagai job: progress = query yang dicari + lagu yang disimpan.
+// Berhenti di antara query/lagu ketika ctx dibatalkan.
+func (s *spotifyService) SeedSongsFromSpotify(ctx context.Context, profileName string, limit int, progress JobProgress) (*SeedResult, error) {
+    profile, err := s.GetSeedProfile(profileName)
+    if err != nil {
+        return nil, err
     }
+    log.Printf("🚀 Memulai seed %d lagu dari Spotify (profile: %s)...", limit, profile.Name)
     
-    songsPerQuery := 10 // Ambil 10 lagu per query
     allSongs := make([]models.Song, 0, limit)
     trackMap := make(map[string]bool)
+    result := &SeedResult{Profile: profile.Name, Requested: limit}
+    searched := 0
+    progress.SetTotal(len(profile.Queries))
     
-    for i, query := range queries {
-        log.Printf("🔍 [%d/%d] Mencari: '%s'", i+1, len(queries), query)
+    for i, query := range profile.Queries {
+        if err := ctx.Err(); err != nil {
+            return result, err
+        }
+        log.Printf("🔍 [%d/%d] Mencari: '%s'", i+1, len(profile.Queries), query.Query)
         
-        songs, err := s.SearchTracks(query, songsPerQuery)
+        songs, err := s.searchProfileQuery(ctx, profile, query)
+        searched++
+        progress.Advance(1)
         if err != nil {
-            log.Printf("⚠️ Warning: Gagal untuk query '%s': %v", query, err)
+            log.Printf("⚠️ Warning: Gagal untuk query '%s': %v", query.Query, err)
+            progress.LogError("search %q: %v", query.Query, err)
             continue
         }
         
-        // Filter lagu Indonesia berdasarkan artis/judul
-        filteredSongs := s.filterIndonesianSongs(songs)
+        filteredSongs := filterSongsForProfile(profile, songs)
         
         // Tambahkan ke list tanpa duplikat
         for _, song := range filteredSongs {
@@ -363,15 +406,19 @@ func (s *spotifyService) SeedSongsFromSpotify(limit int) error {
             }
         }
         
-        log.Printf("📊 Query '%s': %d found, %d Indonesian", 
-            query, len(songs), len(filteredSongs))
-        
-        // Rate limiting
-        time.Sleep(500 * time.Millisecond)
+        log.Printf("📊 Query '%s': %d found, %d matched profile", 
+            query.Query, len(songs), len(filteredSongs))
         
         if len(allSongs) >= limit*2 { // Kumpulkan lebih banyak untuk dipilih yang terbaik
             break
         }
+        
+        // Rate limiting
+        select {
+        case <-ctx.Done():
+            return result, ctx.Err()
+        case <-time.After(500 * time.Millisecond):
+        }
     }
     
     // Sort by popularity (descending)
@@ -385,121 +432,118 @@ func (s *spotifyService) SeedSongsFromSpotify(limit int) error {
     }
     
     // Save to database
-    log.Printf("💾 Menyimpan %d lagu Indonesia ke database...", len(allSongs))
+    log.Printf("💾 Menyimpan %d lagu ke database...", len(allSongs))
+    result.Found = len(allSongs)
+    // Total = query yang benar-benar dicari + lagu yang akan disimpan
+    progress.SetTotal(searched + len(allSongs))
     
     savedCount := 0
     for _, song := range allSongs {
+        if err := ctx.Err(); err != nil {
+            return result, err
+        }
+        
         // Skip jika sudah ada
-        existing, err := s.songRepo.GetSongBySpotifyID(song.SpotifyID)
-        if err == nil && existing != nil {
+        _, err := s.songRepo.GetSongBySpotifyID(song.SpotifyID)
+        if err == nil {
             log.Printf("⏭️ Skipping existing: %s - %s", song.Artist, song.Title)
+            result.Skipped++
+            progress.Advance(1)
             continue
         }
+        if !errors.Is(err, repository.ErrSongNotFound) {
+            progress.LogError("lookup %s (%s - %s): %v", song.SpotifyID, song.Artist, song.Title, err)
+            result.Failed++
+            progress.Advance(1)
+            continue
+        }
+        
+        if profile.Genre != "" {
+            song.Genre = profile.Genre
+        }
         
-        // Set genre ke "indonesian"
-        song.Genre = "indonesian"
+        if err := s.ensureAlbum(&song); err != nil {
+            log.Printf("⚠️ Gagal menyimpan album '%s': %v", song.Album, err)
+        }
         
         if err := s.songRepo.CreateSong(&song); err != nil {
             log.Printf("❌ Gagal menyimpan '%s': %v", song.Title, err)
+            progress.LogError("save %s (%s - %s): %v", song.SpotifyID, song.Artist, song.Title, err)
+            result.Failed++
         } else {
             savedCount++
+            result.Saved++
             log.Printf("✅ Saved #%d: %s - %s (Popularity: %d)", 
                 savedCount, song.Artist, song.Title, song.Popularity)
+            
+            if err := s.artistRepo.LinkSongArtists(song.ID, song.ArtistCredits); err != nil {
+                log.Printf("⚠️ Gagal link artist untuk '%s': %v", song.Title, err)
+            }
         }
+        progress.Advance(1)
     }
     
     log.Printf("🎉 Seed selesai! Berhasil menyimpan %d/%d lagu.", savedCount, len(allSongs))
-    return nil
+    return result, nil
 }
 
-// PERBAIKAN: Optimasi filter untuk lagu Indonesia
-func (s *spotifyService) filterIndonesianSongs(songs []models.Song) []models.Song {
-    indonesianSongs := make([]models.Song, 0)
-    
-    // Daftar artis Indonesia (case insensitive)
-    indonesianArtists := []string{
-        "tulus", "raisa", "nadin", "sheila", "noah", "ungu", "rossa", "judika",
-        "armada", "virgoun", "tiara", "lyodra", "ziva", "yovie", "kerispatih",
-        "d'masiv", "vierra", "geisha", "last child", "fiersa", "ghea", "hari",
-        "gita", "iqbaal", "jidat", "kunto", "lale", "mansur", "naura", "ongen",
-    }
-    
-    // Kata kunci dalam judul lagu yang menandakan Indonesia
-    indonesianKeywords := []string{
-        "indonesia", "jakarta", "bandung", "surabaya", "jogja", "yogyakarta",
-        "bali", "papua", "kalimantan", "sumatra", "jawa", "nusantara",
-        "merah", "putih", "sang", "saka", "garuda", "pancasila",
+// filterSongsForProfile menerapkan allow/deny list dan keyword judul dari profile.
+func filterSongsForProfile(profile *config.SeedProfile, songs []models.Song) []models.Song {
+    filtered := make([]models.Song, 0, len(songs))
+    open := len(profile.AllowArtists) == 0 && len(profile.TitleKeywords) == 0
+    
+    containsAny := func(value string, needles []string) bool {
+        for _, needle := range needles {
+            if strings.Contains(value, needle) {
+                return true
+            }
+        }
+        return false
     }
     
     for _, song := range songs {
         lowerArtist := strings.ToLower(song.Artist)
         lowerTitle := strings.ToLower(song.Title)
         
-        isIndonesian := false
-        
-        // Cek artis
-        for _, artist := range indonesianArtists {
-            if strings.Contains(lowerArtist, artist) {
-                isIndonesian = true
-                break
-            }
-        }
-        
-        // Jika belum ketemu, cek judul
-        if !isIndonesian {
-            for _, keyword := range indonesianKeywords {
-                if strings.Contains(lowerTitle, keyword) {
-                    isIndonesian = true
-                    break
-                }
-            }
-        }
-        
-        // Cek tambahan: popularity tinggi (>50) biasanya lagu populer Indonesia
-        if !isIndonesian && song.Popularity > 50 {
-            // Cek apakah ada kata Indonesia dalam artis/judul
-            if strings.Contains(lowerArtist, "indonesia") || 
-               strings.Contains(lowerTitle, "indonesia") {
-                isIndonesian = true
-            }
+        if song.Popularity < profile.MinPopularity || containsAny(lowerArtist, profile.DenyArtists) {
+            continue
         }
-        
-        if isIndonesian {
-            indonesianSongs = append(indonesianSongs, song)
+        if open || containsAny(lowerArtist, profile.AllowArtists) || containsAny(lowerTitle, profile.TitleKeywords) {
+            filtered = append(filtered, song)
         }
     }
     
-    return indonesianSongs
+    return filtered
 }
 
-// Fungsi GetPopularIndonesianSongs yang lebih baik
-func (s *spotifyService) GetPopularIndonesianSongs(limit int) ([]models.Song, error) {
-    log.Printf("🎵 Mencari %d lagu Indonesia terpopuler...", limit)
+// GetPopularSongs mencari lagu trending untuk profile (TrendingQueries, fallback ke Queries).
+func (s *spotifyService) GetPopularSongs(profileName string, limit int) ([]models.Song, error) {
+    profile, err := s.GetSeedProfile(profileName)
+    if err != nil {
+        return nil, err
+    }
+    log.Printf("🎵 Mencari %d lagu terpopuler (profile: %s)...", limit, profile.Name)
     
-    // Query untuk chart/trending Indonesia
-    queries := []string{
-        "lagu indonesia terbaru 2024",
-        "indonesian viral hits",
-        "spotify chart indonesia",
-        "top hits indonesia",
-        "trending indonesia",
+    queries := profile.TrendingQueries
+    if len(queries) == 0 {
+        queries = profile.Queries
     }
     
     allSongs := make([]models.Song, 0, limit)
     
     for i, query := range queries {
-        log.Printf("🔍 [%d/%d] Mencari trending: '%s'", i+1, len(queries), query)
+        log.Printf("🔍 [%d/%d] Mencari trending: '%s'", i+1, len(queries), query.Query)
         
-        songs, err := s.SearchTracks(query, 20)
+        songs, err := s.searchProfileQuery(context.Background(), profile, query)
         if err != nil {
-            log.Printf("⚠️ Gagal untuk '%s': %v", query, err)
+            log.Printf("⚠️ Gagal untuk '%s': %v", query.Query, err)
             continue
         }
         
-        // Filter hanya lagu Indonesia
-        indonesianSongs := s.filterIndonesianSongs(songs)
-        
-        allSongs = append(allSongs, indonesianSongs...)
+        for _, song := range filterSongsForProfile(profile, songs) {
+            song.Genre = profile.Genre
+            allSongs = append(allSongs, song)
+        }
         
         if len(allSongs) >= limit*2 {
             break
@@ -529,7 +573,7 @@ func (s *spotifyService) GetPopularIndonesianSongs(limit int) ([]models.Song, er
         uniqueSongs = uniqueSongs[:limit]
     }
     
-    log.Printf("✅ Found %d popular Indonesian songs", len(uniqueSongs))
+    log.Printf("✅ Found %d popular songs for profile %s", len(uniqueSongs), profile.Name)
     return uniqueSongs, nil
 }
 
@@ -537,4 +581,42 @@ func (s *spotifyService) GetRecommendations(seedTracks []string, limit int) ([]m
     // This would use Spotify's recommendation endpoint
     // Implementation simplified for brevity
     return s.SearchTracks(strings.Join(seedTracks, " "), limit)
-}
\ No newline at end of file
+}
+
+// ensureAlbum menyimpan album dari hasil search (label diambil dari endpoint album
+// karena tidak ada di simplified album) lalu mengisi song.AlbumID.
+func (s *spotifyService) ensureAlbum(song *models.Song) error {
+    if song.SpotifyAlbum == nil {
+        return nil
+    }
+    
+    album := *song.SpotifyAlbum
+    existing, err := s.albumRepo.GetAlbumBySpotifyID(album.SpotifyID)
+    if err == nil {
+        song.AlbumID = &existing.ID
+        return nil
+    }
+    if !errors.Is(err, repository.ErrAlbumNotFound) {
+        return err
+    }
+    
+    if label, err := s.fetchAlbumLabel(album.SpotifyID); err == nil {
+        album.Label = label
+    } else {
+        log.Printf("⚠️ Gagal mengambil label album %s: %v", album.SpotifyID, err)
+    }
+    
+    if err := s.albumRepo.UpsertAlbum(&album); err != nil {
+        return err
+    }
+    song.AlbumID = &album.ID
+    return nil
+}
+
+func (s *spotifyService) fetchAlbumLabel(albumID string) (string, error) {
+    album, err := s.client.GetAlbum(context.Background(), albumID)
+    if err != nil {
+        return "", err
+    }
+    return album.Label, nil
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"time"
+
+	"back_music/internal/config"
+)
+
+var (
+	ErrStreamURLExpired       = errors.New("stream url has expired")
+	ErrStreamSignatureInvalid = errors.New("stream url signature is invalid")
+)
+
+// streamSigner menandatangani URL /api/songs/:id/stream dengan HMAC supaya tag <audio>
+// bisa memutar file tanpa membawa JWT. Masa berlaku dibulatkan ke atas per window,
+// jadi URL untuk sumber yang sama stabil beberapa menit dan tetap bisa di-cache browser.
+type streamSigner struct {
+	key    []byte
+	ttl    time.Duration
+	window time.Duration
+	now    func() time.Time
+}
+
+func newStreamSigner() *streamSigner {
+	cfg := config.GlobalConfig
+
+	var key []byte
+	switch {
+	case cfg.StreamSigningKey != "":
+		key = []byte(cfg.StreamSigningKey)
+	case cfg.HasJWTSecret():
+		log.Println("⚠️ STREAM_SIGNING_KEY not set, deriving stream URL signing key from JWT_SECRET")
+		key = []byte("audio-stream:" + cfg.JWTSecret)
+	default:
+		// JWT_SECRET default bersifat publik: kunci acak per proses, URL lama tidak berlaku
+		// setelah restart dan tidak bisa dipakai lintas instance
+		log.Println("⚠️ STREAM_SIGNING_KEY and JWT_SECRET not set, using a random stream URL signing key")
+		key = make([]byte, 32)
+		rand.Read(key)
+	}
+	return &streamSigner{
+		key:    key,
+		ttl:    cfg.StreamURLTTL,
+		window: cfg.StreamURLTTL / 4,
+		now:    time.Now,
+	}
+}
+
+func (s *streamSigner) signature(songID string, sourceID uint, expires int64) string {
+	mac := hmac.New(sha256.New, s.key)
+	fmt.Fprintf(mac, "%s\n%d\n%d", songID, sourceID, expires)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// URL mengembalikan URL stream bertanda tangan untuk satu sumber audio.
+func (s *streamSigner) URL(songID string, sourceID uint) string {
+	expires := s.now().Add(s.ttl).Truncate(s.window).Add(s.window).Unix()
+	query := url.Values{}
+	query.Set("source", strconv.FormatUint(uint64(sourceID), 10))
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("sig", s.signature(songID, sourceID, expires))
+	return fmt.Sprintf("/api/songs/%s/stream?%s", songID, query.Encode())
+}
+
+// Verify memeriksa tanda tangan dan mengembalikan waktu kedaluwarsa URL.
+func (s *streamSigner) Verify(songID string, sourceID uint, rawExpires, sig string) (time.Time, error) {
+	expires, err := strconv.ParseInt(rawExpires, 10, 64)
+	if err != nil {
+		return time.Time{}, ErrStreamSignatureInvalid
+	}
+	expected := s.signature(songID, sourceID, expires)
+	if !hmac.Equal([]byte(sig), []byte(expected)) {
+		return time.Time{}, ErrStreamSignatureInvalid
+	}
+	expiresAt := time.Unix(expires, 0)
+	if !s.now().Before(expiresAt) {
+		return time.Time{}, ErrStreamURLExpired
+	}
+	return expiresAt, nil
+}
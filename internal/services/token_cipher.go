@@ -0,0 +1,42 @@
+package services
+
+import (
+	"log"
+	"sync"
+
+	"back_music/internal/config"
+	"back_music/internal/encryption"
+)
+
+var (
+	tokenCipherOnce sync.Once
+	tokenCipher     *encryption.Cipher
+)
+
+// newTokenCipher mengembalikan cipher untuk token pihak ketiga milik user (refresh token
+// Spotify, token ListenBrainz). Kunci dari TOKEN_ENCRYPTION_KEY; jika kosong diturunkan dari
+// JWT_SECRET, jadi kunci tetap sama selama JWT_SECRET tidak diganti. Jika JWT_SECRET juga
+// tidak di-set (default publik) enkripsi dimatikan dan fitur akun tertaut tidak tersedia.
+func newTokenCipher() *encryption.Cipher {
+	tokenCipherOnce.Do(func() {
+		cfg := config.GlobalConfig
+
+		key := cfg.TokenEncryptionKey
+		if key == "" {
+			if !cfg.HasJWTSecret() {
+				log.Println("⚠️ TOKEN_ENCRYPTION_KEY and JWT_SECRET not set, linked-account tokens disabled")
+				return
+			}
+			log.Println("⚠️ TOKEN_ENCRYPTION_KEY not set, deriving token encryption key from JWT_SECRET")
+			key = "spotify-token:" + cfg.JWTSecret
+		}
+		cipher, err := encryption.NewCipher(encryption.KeyFromString(key))
+		if err != nil {
+			// Tidak mungkin terjadi: KeyFromString selalu menghasilkan 32 byte
+			log.Println("⚠️ Token encryption disabled:", err)
+			return
+		}
+		tokenCipher = cipher
+	})
+	return tokenCipher
+}
@@ -1,119 +1,187 @@
 package services
 
-// import (
-// 	"back_music/internal/models"
-// 	"back_music/internal/repository"
-// 	"context"
-// 	"fmt"
-// 	"io"
-// 	"log"
-// 	"os"
-// 	"strings"
-// 	"time"
-
-// 	"github.com/cloudinary/cloudinary-go/v2"
-// 	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
-// )
-
-// type UploadService interface {
-// 	UploadMP3ToCloudinary(file io.Reader, filename string, songID string) (string, error)
-// 	UpdateSongWithCustomMP3(songID string, mp3URL string) error
-// 	GetCustomSongs() ([]models.Song, error)
-// }
-
-// func (u UploadService) SearchYouTube(searchQuery string) (any, error) {
-// 	panic("unimplemented")
-// }
-
-// type uploadService struct {
-// 	songRepo        repository.SongRepository
-// 	cloudinaryCloud *cloudinary.Cloudinary
-// }
-
-// func NewUploadService(songRepo repository.SongRepository) (UploadService, error) {
-// 	cloudinaryURL := fmt.Sprintf("cloudinary://%s:%s@%s",
-// 		os.Getenv("CLOUDINARY_API_KEY"),
-// 		os.Getenv("CLOUDINARY_API_SECRET"),
-// 		os.Getenv("CLOUDINARY_CLOUD_NAME"),
-// 	)
-
-// 	cld, err := cloudinary.NewFromURL(cloudinaryURL)
-// 	if err != nil {
-// 		return nil, fmt.Errorf("failed to initialize Cloudinary: %v", err)
-// 	}
-
-// 	return &uploadService{
-// 		songRepo:        songRepo,
-// 		cloudinaryCloud: cld,
-// 	}, nil
-// }
-
-// func (us *uploadService) UploadMP3ToCloudinary(file io.Reader, filename string, songID string) (string, error) {
-// 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
-// 	defer cancel()
-
-// 	log.Printf("[Cloudinary] Memulai upload: %s", filename)
-
-// 	uploadResult, err := us.cloudinaryCloud.Upload.Upload(ctx, file, uploader.UploadParams{
-// 		// Paksa sebagai video agar muncul di tab Video/Audio Cloudinary
-// 		ResourceType: "video",
-// 		Folder:       "music_app/songs",
-// 		// Hapus karakter aneh dari nama file untuk PublicID
-// 		PublicID: fmt.Sprintf("song_%s", songID),
-// 	})
-
-// 	if err != nil {
-// 		return "", err
-// 	}
-
-// 	// Ambil URL yang tersedia (SecureURL lebih baik, tapi URL biasa juga OK)
-// 	finalURL := uploadResult.SecureURL
-// 	if finalURL == "" {
-// 		finalURL = uploadResult.URL
-// 	}
-
-// 	// Jika keduanya kosong, kita buat manual menggunakan PublicID sebagai failover
-// 	if finalURL == "" && uploadResult.PublicID != "" {
-// 		log.Printf("[Cloudinary] URL kosong, mencoba generate manual dari PublicID...")
-// 		finalURL = fmt.Sprintf("https://res.cloudinary.com/%s/video/upload/%s",
-// 			us.cloudinaryCloud.Config.Cloud.CloudName,
-// 			uploadResult.PublicID)
-// 	}
-
-// 	if finalURL == "" {
-// 		return "", fmt.Errorf("semua field URL dari cloudinary kosong")
-// 	}
-
-// 	log.Printf("[Cloudinary] BERHASIL! URL: %s", finalURL)
-// 	return finalURL, nil
-// }
-// func (us *uploadService) UpdateSongWithCustomMP3(songID string, mp3URL string) error {
-// 	song, err := us.songRepo.GetSongByID(songID)
-// 	if err != nil {
-// 		return err
-// 	}
-
-// 	now := time.Now()
-// 	song.CustomMP3URL = mp3URL
-// 	song.HasCustomMP3 = true
-// 	song.CustomUploadDate = &now
-
-// 	return us.songRepo.UpdateSong(song)
-// }
-
-// func (us *uploadService) GetCustomSongs() ([]models.Song, error) {
-// 	return us.songRepo.GetSongsWithCustomMP3()
-// }
-
-// func sanitizeFilename(filename string) string {
-// 	filename = strings.TrimSuffix(filename, ".mp3")
-// 	var result []rune
-// 	for _, r := range filename {
-// 		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
-// 			result = append(result, r)
-// 		} else {
-// 			result = append(result, '_')
-// 		}
-// 	}
-// 	return string(result)
-// }
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"back_music/internal/config"
+	"back_music/internal/models"
+	"back_music/internal/repository"
+	"back_music/internal/storage"
+)
+
+// MaxAudioUploadSize membatasi ukuran file audio yang diupload admin.
+const MaxAudioUploadSize = 100 << 20
+
+var (
+	ErrAudioTooLarge          = errors.New("audio file is too large")
+	ErrUnsupportedAudioFormat = errors.New("unsupported audio format (mp3, flac, ogg only)")
+)
+
+// AudioUploadResult adalah sumber audio hasil upload. Deduplicated true jika file yang
+// sama persis sudah pernah diupload untuk lagu ini.
+type AudioUploadResult struct {
+	Source       *models.SongSource `json:"source"`
+	Deduplicated bool               `json:"deduplicated"`
+}
+
+type UploadService interface {
+	// UploadSongAudio menyimpan file audio ke BlobStore dan menautkannya ke lagu
+	// sebagai sumber "local" yang bisa diputar.
+	UploadSongAudio(ctx context.Context, songID, filename string, file io.Reader, durationMs, bitrateKbps int) (*AudioUploadResult, error)
+}
+
+// NewBlobStore membuat storage file audio sesuai STORAGE_BACKEND (local | s3).
+func NewBlobStore() (storage.BlobStore, error) {
+	cfg := config.GlobalConfig
+	switch cfg.StorageBackend {
+	case "", "local":
+		return storage.NewLocalStore(cfg.AudioUploadDir), nil
+	case "s3":
+		return storage.NewS3Store(storage.S3Config{
+			Endpoint:        cfg.S3Endpoint,
+			Region:          cfg.S3Region,
+			Bucket:          cfg.S3Bucket,
+			AccessKeyID:     cfg.S3AccessKeyID,
+			SecretAccessKey: cfg.S3SecretAccessKey,
+			PathStyle:       cfg.S3PathStyle,
+		})
+	}
+	return nil, fmt.Errorf("unknown STORAGE_BACKEND %q", cfg.StorageBackend)
+}
+
+type uploadService struct {
+	songRepo   repository.SongRepository
+	sourceRepo repository.SongSourceRepository
+	store      storage.BlobStore
+}
+
+func NewUploadService(
+	songRepo repository.SongRepository,
+	sourceRepo repository.SongSourceRepository,
+	store storage.BlobStore,
+) UploadService {
+	return &uploadService{
+		songRepo:   songRepo,
+		sourceRepo: sourceRepo,
+		store:      store,
+	}
+}
+
+func (s *uploadService) UploadSongAudio(ctx context.Context, songID, filename string, file io.Reader, durationMs, bitrateKbps int) (*AudioUploadResult, error) {
+	if _, err := s.songRepo.GetSongByID(songID); err != nil {
+		return nil, err
+	}
+
+	// File ditampung dulu di temp supaya checksum diketahui sebelum disimpan
+	tmp, err := os.CreateTemp("", "audio-upload-*")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+
+	hasher := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hasher), io.LimitReader(file, MaxAudioUploadSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if size > MaxAudioUploadSize {
+		return nil, ErrAudioTooLarge
+	}
+
+	header := make([]byte, 512)
+	n, err := tmp.ReadAt(header, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	format, ok := sniffAudioFormat(header[:n])
+	if !ok {
+		return nil, ErrUnsupportedAudioFormat
+	}
+	checksum := hex.EncodeToString(hasher.Sum(nil))
+
+	existing, err := s.sourceRepo.FindByChecksum(checksum)
+	if err != nil {
+		return nil, err
+	}
+	for i := range existing {
+		if existing[i].SongID == songID {
+			return &AudioUploadResult{Source: &existing[i], Deduplicated: true}, nil
+		}
+	}
+
+	// Key berbasis isi: file yang sama untuk lagu lain (mis. duplikat katalog) disimpan sekali
+	key := fmt.Sprintf("audio/%s/%s%s", checksum[:2], checksum, format.ext)
+	// Dikunci sampai sumber tertaut supaya RemoveSource/DeleteSong untuk object yang sama
+	// tidak menghapusnya di antara Stat dan Upsert
+	unlock := blobRefLocks.Lock(key)
+	defer unlock()
+	if _, err := s.store.Stat(ctx, key); err != nil {
+		if !errors.Is(err, storage.ErrNotFound) {
+			return nil, err
+		}
+		if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		if err := s.store.Put(ctx, key, tmp, size, format.mimeType); err != nil {
+			return nil, fmt.Errorf("failed to store audio: %w", err)
+		}
+		log.Printf("🎵 Stored audio upload %q for song %s as %s (%d bytes)", filename, songID, key, size)
+	}
+
+	source := &models.SongSource{
+		SongID:      songID,
+		Provider:    models.SourceProviderLocal,
+		Ref:         key,
+		Quality:     models.SourceQualityFull,
+		DurationMs:  durationMs,
+		BitrateKbps: bitrateKbps,
+		MimeType:    format.mimeType,
+		Checksum:    checksum,
+		SizeBytes:   size,
+	}
+	if err := s.sourceRepo.Upsert(source); err != nil {
+		return nil, err
+	}
+	// Object baru saja disimpan: langsung terverifikasi
+	now := time.Now()
+	source.Status = models.SourceStatusVerified
+	source.VerifiedAt = &now
+	source.LastError = ""
+	if err := s.sourceRepo.Update(source); err != nil {
+		return nil, err
+	}
+	return &AudioUploadResult{Source: source}, nil
+}
+
+type audioFormat struct {
+	mimeType string
+	ext      string
+}
+
+// sniffAudioFormat mengenali format dari magic bytes, bukan dari ekstensi nama file.
+func sniffAudioFormat(header []byte) (audioFormat, bool) {
+	switch {
+	case bytes.HasPrefix(header, []byte("fLaC")):
+		return audioFormat{mimeType: "audio/flac", ext: ".flac"}, true
+	case bytes.HasPrefix(header, []byte("OggS")):
+		return audioFormat{mimeType: "audio/ogg", ext: ".ogg"}, true
+	case bytes.HasPrefix(header, []byte("ID3")):
+		return audioFormat{mimeType: "audio/mpeg", ext: ".mp3"}, true
+	case len(header) >= 2 && header[0] == 0xFF && header[1]&0xE0 == 0xE0 && header[1]&0x06 != 0:
+		// Frame sync MPEG audio tanpa tag ID3 (layer bits 00 adalah AAC ADTS, ditolak)
+		return audioFormat{mimeType: "audio/mpeg", ext: ".mp3"}, true
+	}
+	return audioFormat{}, false
+}
@@ -0,0 +1,35 @@
+package services
+
+import "testing"
+
+func TestSniffAudioFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   []byte
+		wantMime string
+		wantExt  string
+		wantOK   bool
+	}{
+		{name: "mp3 with ID3 tag", header: []byte("ID3\x04\x00\x00\x00\x00\x00\x00"), wantMime: "audio/mpeg", wantExt: ".mp3", wantOK: true},
+		{name: "mp3 frame sync layer III", header: []byte{0xFF, 0xFB, 0x90, 0x64}, wantMime: "audio/mpeg", wantExt: ".mp3", wantOK: true},
+		{name: "mpeg-2 frame sync layer II", header: []byte{0xFF, 0xF4, 0x90, 0x64}, wantMime: "audio/mpeg", wantExt: ".mp3", wantOK: true},
+		{name: "aac adts is rejected", header: []byte{0xFF, 0xF1, 0x50, 0x80}, wantOK: false},
+		{name: "flac", header: []byte("fLaC\x00\x00\x00\x22"), wantMime: "audio/flac", wantExt: ".flac", wantOK: true},
+		{name: "ogg", header: []byte("OggS\x00\x02"), wantMime: "audio/ogg", wantExt: ".ogg", wantOK: true},
+		{name: "wav is rejected", header: []byte("RIFF\x24\x08\x00\x00WAVE"), wantOK: false},
+		{name: "text is rejected", header: []byte("not audio at all"), wantOK: false},
+		{name: "single sync byte", header: []byte{0xFF}, wantOK: false},
+		{name: "empty", header: nil, wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, ok := sniffAudioFormat(tt.header)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if format.mimeType != tt.wantMime || format.ext != tt.wantExt {
+				t.Errorf("format = %+v, want %s %s", format, tt.wantMime, tt.wantExt)
+			}
+		})
+	}
+}
@@ -1,146 +1,534 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"net/url"
 	"os"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
+
+	"back_music/internal/config"
+	"back_music/internal/models"
+	"back_music/internal/repository"
+)
+
+const (
+	youtubeSearchURL = "https://www.googleapis.com/youtube/v3/search"
+	youtubeVideosURL = "https://www.googleapis.com/youtube/v3/videos"
+
+	// MinYouTubeConfidence: kandidat dengan skor di bawah ini tidak dipakai.
+	MinYouTubeConfidence = 0.5
+	// youtubeGoodConfidence: strategi pencarian berikutnya tidak perlu dicoba.
+	youtubeGoodConfidence = 0.8
+
+	// Negative cache: lagu yang tidak ketemu dicoba lagi setelah 1, 2, 4, ... hari (maks 30),
+	// error API dicoba lagi setelah 1 jam.
+	youtubeMissBaseDelay = 24 * time.Hour
+	youtubeMissMaxDelay  = 30 * 24 * time.Hour
+	youtubeErrorRetry    = time.Hour
+	youtubeResolveBatch  = 25
 )
 
+var (
+	ErrYouTubeNotConfigured = errors.New("youtube api key is not configured")
+	ErrYouTubeNoMatch       = errors.New("no confident youtube match")
+	// ErrYouTubeNegativeCached: lagu baru saja gagal dicari dan belum waktunya dicoba lagi.
+	ErrYouTubeNegativeCached = errors.New("youtube lookup skipped: recent miss is cached")
+)
+
+// YouTubeMatch adalah video terbaik untuk sebuah lagu beserta skor kecocokannya (0-1).
+type YouTubeMatch struct {
+	VideoID    string  `json:"video_id"`
+	Title      string  `json:"title"`
+	Channel    string  `json:"channel"`
+	DurationMs int     `json:"duration_ms"`
+	Confidence float64 `json:"confidence"`
+}
+
 type YouTubeService interface {
-	SearchAudio(query string) (string, error)
+	// SearchAudio mencari video yang paling cocok dengan lagu. Video yang ada di
+	// exclude (mis. sudah diketahui rusak) dilewati.
+	SearchAudio(song *models.Song, exclude map[string]bool) (*YouTubeMatch, error)
+	// StartBatchResolver mengisi YoutubeID lagu di background memakai sisa kuota harian.
+	StartBatchResolver(ctx context.Context)
+	QuotaStatus() (*YouTubeQuotaStatus, error)
 }
 
 type YoutubeService struct {
-	apiKey string
+	apiKey     string
+	httpClient *http.Client
+	repo       repository.YoutubeRepository
+	songRepo   repository.SongRepository
+	quota      *youtubeQuota
+	interval   time.Duration
 }
 
-func NewYouTubeService() YouTubeService {
+func NewYouTubeService(repo repository.YoutubeRepository, songRepo repository.SongRepository) YouTubeService {
+	cfg := config.GlobalConfig
 	return &YoutubeService{
-		apiKey: os.Getenv("YOUTUBE_API_KEY"),
+		apiKey:     os.Getenv("YOUTUBE_API_KEY"),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		repo:       repo,
+		songRepo:   songRepo,
+		quota:      newYouTubeQuota(repo, cfg.YouTubeDailyQuota, cfg.YouTubeInteractiveReserve),
+		interval:   cfg.YouTubeResolveInterval,
 	}
 }
 
-func (s *YoutubeService) SearchAudio(query string) (string, error) {
-	baseURL := "https://www.googleapis.com/youtube/v3/search"
-	
+func (s *YoutubeService) SearchAudio(song *models.Song, exclude map[string]bool) (*YouTubeMatch, error) {
+	return s.lookup(song, exclude, false)
+}
+
+// lookup menjalankan pencarian dengan negative cache: hasil "tidak ketemu" dan error API
+// disimpan per lagu supaya lagu populer yang tidak ada di YouTube tidak menghabiskan kuota.
+func (s *YoutubeService) lookup(song *models.Song, exclude map[string]bool, background bool) (*YouTubeMatch, error) {
+	if s.apiKey == "" {
+		return nil, ErrYouTubeNotConfigured
+	}
+
+	miss, err := s.repo.GetMiss(song.ID)
+	if err != nil && !errors.Is(err, repository.ErrYoutubeMissNotFound) {
+		return nil, err
+	}
+	if miss != nil && time.Now().Before(miss.RetryAt) {
+		return nil, ErrYouTubeNegativeCached
+	}
+
+	match, err := s.match(song, exclude, background)
+	switch {
+	case err == nil:
+		if miss != nil {
+			if err := s.repo.ClearMiss(song.ID); err != nil {
+				log.Printf("⚠️ [YouTube] failed to clear miss for song %s: %v", song.ID, err)
+			}
+		}
+		return match, nil
+	case errors.Is(err, ErrYouTubeQuotaExhausted):
+		// Kuota habis bukan salah lagunya: jangan masuk negative cache
+		return nil, err
+	}
+
+	reason, delay := models.YoutubeMissError, youtubeErrorRetry
+	if errors.Is(err, ErrYouTubeNoMatch) {
+		reason, delay = models.YoutubeMissNotFound, youtubeMissBaseDelay
+		if miss != nil {
+			for i := 0; i < miss.Misses && delay < youtubeMissMaxDelay; i++ {
+				delay *= 2
+			}
+		}
+		if delay > youtubeMissMaxDelay {
+			delay = youtubeMissMaxDelay
+		}
+	}
+	if recordErr := s.repo.RecordMiss(song.ID, reason, err.Error(), time.Now().Add(delay)); recordErr != nil {
+		log.Printf("⚠️ [YouTube] failed to record miss for song %s: %v", song.ID, recordErr)
+	}
+	return nil, err
+}
+
+type youtubeCandidate struct {
+	videoID     string
+	title       string
+	description string
+	channel     string
+	durationMs  int
+}
+
+func (s *YoutubeService) match(song *models.Song, exclude map[string]bool, background bool) (*YouTubeMatch, error) {
+	query := fmt.Sprintf("%s - %s", song.Artist, song.Title)
 	// Multiple search strategies for better results
 	searchQueries := []string{
 		query + " official audio",
-		query + " official music video",
+		query,
 		query + " lyrics",
-		query + " audio only",
-		query, // fallback to original query
 	}
 
+	var best *YouTubeMatch
 	var lastErr error
-	
+	seen := make(map[string]bool)
+
 	for _, searchQuery := range searchQueries {
-		// Setup Query Parameters
-		params := url.Values{}
-		params.Add("part", "id,snippet")
-		params.Add("q", searchQuery)
-		params.Add("type", "video")
-		params.Add("maxResults", "5") // Get more results to choose from
-		params.Add("key", s.apiKey)
-		params.Add("videoCategoryId", "10") // Music Category
-		params.Add("order", "relevance")
-		params.Add("safeSearch", "moderate")
-
-		fullURL := fmt.Sprintf("%s?%s", baseURL, params.Encode())
-
-		// HTTP Request
-		resp, err := http.Get(fullURL)
+		candidates, err := s.search(searchQuery, background)
+		if errors.Is(err, ErrYouTubeQuotaExhausted) {
+			// Strategi belum lengkap dicoba: hasil lemah tidak boleh jadi negative cache
+			if best != nil && best.Confidence >= MinYouTubeConfidence {
+				break
+			}
+			return nil, err
+		}
 		if err != nil {
 			lastErr = err
 			continue
 		}
-		defer resp.Body.Close()
 
-		if resp.StatusCode != http.StatusOK {
-			lastErr = fmt.Errorf("youtube api error: status %d", resp.StatusCode)
+		var fresh []youtubeCandidate
+		for _, candidate := range candidates {
+			if exclude[candidate.videoID] || seen[candidate.videoID] {
+				continue
+			}
+			seen[candidate.videoID] = true
+			fresh = append(fresh, candidate)
+		}
+		if len(fresh) == 0 {
 			continue
 		}
 
-		// Parsing Response with snippet for better filtering
-		var result struct {
-			Items []struct {
-				Id struct {
-					VideoId string `json:"videoId"`
-				} `json:"id"`
-				Snippet struct {
-					Title        string `json:"title"`
-					Description  string `json:"description"`
-					ChannelTitle string `json:"channelTitle"`
-				} `json:"snippet"`
-			} `json:"items"`
+		// Durasi tidak ada di hasil search, ambil lewat endpoint videos (1 unit kuota)
+		if err := s.fillDurations(fresh, background); err != nil {
+			log.Printf("⚠️ [YouTube] failed to fetch video durations: %v", err)
 		}
 
-		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-			lastErr = err
-			continue
+		for _, candidate := range fresh {
+			confidence := scoreYouTubeCandidate(song, candidate)
+			if best == nil || confidence > best.Confidence {
+				best = &YouTubeMatch{
+					VideoID:    candidate.videoID,
+					Title:      candidate.title,
+					Channel:    candidate.channel,
+					DurationMs: candidate.durationMs,
+					Confidence: confidence,
+				}
+			}
+		}
+		if best != nil && best.Confidence >= youtubeGoodConfidence {
+			break
 		}
+	}
 
-		// Filter and prioritize results
-		var fallbackID string
-		for _, item := range result.Items {
-			title := item.Snippet.Title
-			description := item.Snippet.Description
-			channel := item.Snippet.ChannelTitle
+	if best != nil && best.Confidence >= MinYouTubeConfidence {
+		return best, nil
+	}
+	if best == nil && lastErr != nil {
+		return nil, lastErr
+	}
+	return nil, fmt.Errorf("%w for %q", ErrYouTubeNoMatch, query)
+}
 
-			// Skip if title contains unwanted keywords
-			if containsUnwantedKeywords(title) {
-				continue
-			}
+func (s *YoutubeService) search(query string, background bool) ([]youtubeCandidate, error) {
+	params := url.Values{}
+	params.Add("part", "id,snippet")
+	params.Add("q", query)
+	params.Add("type", "video")
+	params.Add("maxResults", "8") // Get more results to choose from
+	params.Add("key", s.apiKey)
+	params.Add("videoCategoryId", "10") // Music Category
+	params.Add("order", "relevance")
+	params.Add("safeSearch", "moderate")
 
-			// Store first valid result as fallback
-			if fallbackID == "" {
-				fallbackID = item.Id.VideoId
-			}
+	var result struct {
+		Items []struct {
+			Id struct {
+				VideoId string `json:"videoId"`
+			} `json:"id"`
+			Snippet struct {
+				Title        string `json:"title"`
+				Description  string `json:"description"`
+				ChannelTitle string `json:"channelTitle"`
+			} `json:"snippet"`
+		} `json:"items"`
+	}
+	if err := s.get(youtubeSearchURL, params, youtubeSearchCost, background, &result); err != nil {
+		return nil, err
+	}
 
-			// Prioritize official channels and good titles
-			if isOfficialChannel(channel) || isGoodTitle(title, description) {
-				return item.Id.VideoId, nil
-			}
+	candidates := make([]youtubeCandidate, 0, len(result.Items))
+	for _, item := range result.Items {
+		if item.Id.VideoId == "" {
+			continue
 		}
+		candidates = append(candidates, youtubeCandidate{
+			videoID:     item.Id.VideoId,
+			title:       item.Snippet.Title,
+			description: item.Snippet.Description,
+			channel:     item.Snippet.ChannelTitle,
+		})
+	}
+	return candidates, nil
+}
 
-		// If no perfect match found, return first fallback (if exists)
-		if fallbackID != "" {
-			return fallbackID, nil
-		}
+func (s *YoutubeService) fillDurations(candidates []youtubeCandidate, background bool) error {
+	ids := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		ids[i] = candidate.videoID
+	}
+
+	params := url.Values{}
+	params.Add("part", "contentDetails")
+	params.Add("id", strings.Join(ids, ","))
+	params.Add("key", s.apiKey)
+
+	var result struct {
+		Items []struct {
+			Id             string `json:"id"`
+			ContentDetails struct {
+				Duration string `json:"duration"`
+			} `json:"contentDetails"`
+		} `json:"items"`
+	}
+	if err := s.get(youtubeVideosURL, params, youtubeVideosCost, background, &result); err != nil {
+		return err
 	}
 
-	if lastErr != nil {
-		return "", lastErr
+	durations := make(map[string]int, len(result.Items))
+	for _, item := range result.Items {
+		durations[item.Id] = parseISO8601Duration(item.ContentDetails.Duration)
 	}
-	return "", fmt.Errorf("no audio found on youtube after trying multiple search strategies")
+	for i := range candidates {
+		candidates[i].durationMs = durations[candidates[i].videoID]
+	}
+	return nil
 }
 
-// Helper functions for better filtering
-func containsUnwantedKeywords(title string) bool {
-	// Relaxed filter - only exclude most problematic content
-	unwanted := []string{
-		"karaoke", "reaction", "tutorial", "review",
-		"parody", "mashup",
+// get mengirim request setelah memesan kuota; 403 quotaExceeded menandai kuota hari ini habis.
+func (s *YoutubeService) get(endpoint string, params url.Values, cost int, background bool, out interface{}) error {
+	if err := s.quota.take(cost, background); err != nil {
+		return err
+	}
+
+	resp, err := s.httpClient.Get(endpoint + "?" + params.Encode())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		if resp.StatusCode == http.StatusForbidden && isYouTubeQuotaError(body) {
+			s.quota.markExhausted()
+			return ErrYouTubeQuotaExhausted
+		}
+		return fmt.Errorf("youtube api error: status %d", resp.StatusCode)
 	}
-	
-	titleLower := strings.ToLower(title)
-	for _, word := range unwanted {
-		if strings.Contains(titleLower, word) {
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// isYouTubeQuotaError membaca {"error": {"errors": [{"reason": "quotaExceeded"}]}}.
+func isYouTubeQuotaError(body []byte) bool {
+	var payload struct {
+		Error struct {
+			Errors []struct {
+				Reason string `json:"reason"`
+			} `json:"errors"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return false
+	}
+	for _, item := range payload.Error.Errors {
+		if item.Reason == "quotaExceeded" || item.Reason == "dailyLimitExceeded" {
 			return true
 		}
 	}
 	return false
 }
 
+var iso8601DurationPattern = regexp.MustCompile(`^PT(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?$`)
+
+// parseISO8601Duration mengubah durasi YouTube ("PT3M25S") ke milidetik; 0 jika tidak dikenal.
+func parseISO8601Duration(value string) int {
+	match := iso8601DurationPattern.FindStringSubmatch(value)
+	if match == nil {
+		return 0
+	}
+	total := 0
+	for i, unit := range []int{3600, 60, 1} {
+		if match[i+1] == "" {
+			continue
+		}
+		n, _ := strconv.Atoi(match[i+1])
+		total += n * unit
+	}
+	return total * 1000
+}
+
+// Bobot komponen skor kecocokan; totalnya 1.
+const (
+	youtubeWeightDuration = 0.35
+	youtubeWeightTitle    = 0.35
+	youtubeWeightArtist   = 0.2
+	youtubeWeightOfficial = 0.1
+)
+
+// scoreYouTubeCandidate menilai seberapa cocok video dengan lagu (0-1) dari selisih
+// durasi, kemiripan token judul dan artist, sinyal channel resmi, dan penalti
+// kata kunci versi lain (cover, live, karaoke, ...).
+func scoreYouTubeCandidate(song *models.Song, candidate youtubeCandidate) float64 {
+	score := youtubeWeightDuration*durationScore(song.DurationMs, candidate.durationMs) +
+		youtubeWeightTitle*titleTokenScore(song.Title, candidate.title) +
+		youtubeWeightArtist*artistTokenScore(song.Artist, candidate) +
+		youtubeWeightOfficial*officialScore(song.Artist, candidate)
+	score -= unwantedKeywordPenalty(song.Title, candidate.title)
+
+	if score < 0 {
+		return 0
+	}
+	if score > 1 {
+		return 1
+	}
+	return score
+}
+
+// durationScore: selisih <= 3 detik bernilai penuh, turun linear sampai 0 di 30 detik.
+// Durasi yang tidak diketahui bernilai netral.
+func durationScore(songMs, videoMs int) float64 {
+	if songMs <= 0 || videoMs <= 0 {
+		return 0.5
+	}
+	delta := songMs - videoMs
+	if delta < 0 {
+		delta = -delta
+	}
+	const full, zero = 3000, 30000
+	switch {
+	case delta <= full:
+		return 1
+	case delta >= zero:
+		return 0
+	default:
+		return 1 - float64(delta-full)/float64(zero-full)
+	}
+}
+
+// titleTokenScore: porsi token judul lagu yang muncul di judul video, dikurangi
+// sedikit untuk token tambahan yang tidak ada di judul lagu maupun kata umum video.
+func titleTokenScore(songTitle, videoTitle string) float64 {
+	songTokens := normalizeSuggestText(NormalizeRecordingTitle(songTitle))
+	if len(songTokens) == 0 {
+		return 0
+	}
+	videoTokens := tokenSet(normalizeSuggestText(videoTitle))
+
+	matched := 0
+	for _, token := range songTokens {
+		if videoTokens[token] {
+			matched++
+		}
+	}
+	recall := float64(matched) / float64(len(songTokens))
+
+	songSet := tokenSet(songTokens)
+	extra := 0
+	for token := range videoTokens {
+		if !songSet[token] && !youtubeNeutralTokens[token] {
+			extra++
+		}
+	}
+	// Token artist di judul video ("Artist - Title") juga dihitung extra, jadi penaltinya kecil
+	penalty := 0.05 * float64(extra)
+	if penalty > 0.3 {
+		penalty = 0.3
+	}
+	return recall * (1 - penalty)
+}
+
+// artistTokenScore: porsi token artist utama yang muncul di judul video atau nama channel.
+func artistTokenScore(artist string, candidate youtubeCandidate) float64 {
+	names := SplitArtistNames(artist)
+	if len(names) == 0 {
+		return 0
+	}
+	artistTokens := normalizeSuggestText(names[0])
+	if len(artistTokens) == 0 {
+		return 0
+	}
+	haystack := tokenSet(normalizeSuggestText(candidate.title + " " + candidate.channel))
+
+	matched := 0
+	for _, token := range artistTokens {
+		if haystack[token] {
+			matched++
+		}
+	}
+	return float64(matched) / float64(len(artistTokens))
+}
+
+// officialScore: channel "Artist - Topic" / deskripsi "Provided to YouTube by" (audio dari label),
+// VEVO, atau channel yang bernama artist dianggap resmi.
+func officialScore(artist string, candidate youtubeCandidate) float64 {
+	channel := strings.ToLower(candidate.channel)
+	if strings.HasSuffix(channel, " - topic") ||
+		strings.Contains(strings.ToLower(candidate.description), "provided to youtube by") {
+		return 1
+	}
+	if strings.Contains(channel, "vevo") {
+		return 1
+	}
+	if names := SplitArtistNames(artist); len(names) > 0 {
+		artistKey := strings.Join(normalizeSuggestText(names[0]), "")
+		channelKey := strings.Join(normalizeSuggestText(candidate.channel), "")
+		if artistKey != "" && strings.Contains(channelKey, artistKey) {
+			return 1
+		}
+	}
+	if isOfficialChannel(candidate.channel) {
+		return 0.5
+	}
+	return 0
+}
+
+// youtubeUnwantedKeywords adalah penanda versi lain dari lagu; tidak dipenalti jika
+// kata yang sama ada di judul lagu (mis. lagu memang versi "Live").
+var youtubeUnwantedKeywords = map[string]float64{
+	"karaoke":      0.5,
+	"reaction":     0.5,
+	"tutorial":     0.5,
+	"review":       0.5,
+	"parody":       0.5,
+	"mashup":       0.4,
+	"cover":        0.4,
+	"nightcore":    0.4,
+	"instrumental": 0.3,
+	"remix":        0.3,
+	"live":         0.25,
+	"sped":         0.3,
+	"slowed":       0.3,
+	"8d":           0.3,
+	"acoustic":     0.15,
+}
+
+func unwantedKeywordPenalty(songTitle, videoTitle string) float64 {
+	songTokens := tokenSet(normalizeSuggestText(songTitle))
+	penalty := 0.0
+	for _, token := range normalizeSuggestText(videoTitle) {
+		if weight, ok := youtubeUnwantedKeywords[token]; ok && !songTokens[token] {
+			penalty += weight
+		}
+	}
+	if penalty > 0.6 {
+		penalty = 0.6
+	}
+	return penalty
+}
+
+// youtubeNeutralTokens adalah kata yang umum di judul video resmi dan tidak mengurangi skor judul.
+var youtubeNeutralTokens = map[string]bool{
+	"official": true, "audio": true, "video": true, "music": true, "mv": true,
+	"lyric": true, "lyrics": true, "visualizer": true, "hd": true, "hq": true,
+	"4k": true, "ft": true, "feat": true, "prod": true, "topic": true,
+}
+
+func tokenSet(tokens []string) map[string]bool {
+	set := make(map[string]bool, len(tokens))
+	for _, token := range tokens {
+		set[token] = true
+	}
+	return set
+}
+
 func isOfficialChannel(channel string) bool {
 	official := []string{
 		"official", "vevo", "records", "music", "entertainment",
 		"tv", "radio", "fm",
 	}
-	
+
 	channelLower := strings.ToLower(channel)
 	for _, word := range official {
 		if strings.Contains(channelLower, word) {
@@ -149,19 +537,3 @@ func isOfficialChannel(channel string) bool {
 	}
 	return false
 }
-
-func isGoodTitle(title, description string) bool {
-	content := strings.ToLower(title + " " + description)
-	
-	good := []string{
-		"official", "audio", "music video", "lyric", "lyrics",
-		"original", "album", "single", "track",
-	}
-	
-	for _, word := range good {
-		if strings.Contains(content, word) {
-			return true
-		}
-	}
-	return false
-}
\ No newline at end of file
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"testing"
+
+	"back_music/internal/models"
+)
+
+func TestParseISO8601Duration(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int
+	}{
+		{value: "PT1H2M3S", want: 3723000},
+		{value: "PT3M25S", want: 205000},
+		{value: "PT45S", want: 45000},
+		{value: "PT4M", want: 240000},
+		{value: "PT1H", want: 3600000},
+		{value: "P1DT2H", want: 0},
+		{value: "3M25S", want: 0},
+		{value: "PT3M25", want: 0},
+		{value: "PT-5S", want: 0},
+		{value: "", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := parseISO8601Duration(tt.value); got != tt.want {
+				t.Errorf("parseISO8601Duration(%q) = %d, want %d", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnwantedKeywordPenalty(t *testing.T) {
+	tests := []struct {
+		name       string
+		songTitle  string
+		videoTitle string
+		want       float64
+	}{
+		{name: "official audio", songTitle: "Blinding Lights", videoTitle: "The Weeknd - Blinding Lights (Official Audio)", want: 0},
+		{name: "cover", songTitle: "Blinding Lights", videoTitle: "Blinding Lights (Piano Cover)", want: 0.4},
+		{name: "live", songTitle: "Blinding Lights", videoTitle: "Blinding Lights Live at the Grammys", want: 0.25},
+		{name: "live version of a live song", songTitle: "Hotel California - Live", videoTitle: "Hotel California (Live)", want: 0},
+		{name: "penalty is capped", songTitle: "Blinding Lights", videoTitle: "Blinding Lights karaoke cover remix", want: 0.6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unwantedKeywordPenalty(tt.songTitle, tt.videoTitle); got < tt.want-1e-9 || got > tt.want+1e-9 {
+				t.Errorf("unwantedKeywordPenalty(%q, %q) = %.2f, want %.2f", tt.songTitle, tt.videoTitle, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScoreYouTubeCandidate(t *testing.T) {
+	song := &models.Song{Title: "Blinding Lights", Artist: "The Weeknd", DurationMs: 200040}
+
+	tests := []struct {
+		name      string
+		candidate youtubeCandidate
+		// wantMin > 0: skor minimal; 0: skor harus di bawah MinYouTubeConfidence
+		wantMin float64
+	}{
+		{
+			name: "topic channel upload",
+			candidate: youtubeCandidate{
+				title:       "Blinding Lights",
+				channel:     "The Weeknd - Topic",
+				description: "Provided to YouTube by Republic Records",
+				durationMs:  201000,
+			},
+			wantMin: 0.8,
+		},
+		{
+			name: "official music video on the artist channel",
+			candidate: youtubeCandidate{
+				title:      "The Weeknd - Blinding Lights (Official Video)",
+				channel:    "TheWeekndVEVO",
+				durationMs: 262000,
+			},
+			wantMin: MinYouTubeConfidence,
+		},
+		{
+			name: "cover by another channel",
+			candidate: youtubeCandidate{
+				title:      "Blinding Lights - The Weeknd (Acoustic Cover)",
+				channel:    "Cover Nation",
+				durationMs: 199000,
+			},
+		},
+		{
+			name: "live performance",
+			candidate: youtubeCandidate{
+				title:      "The Weeknd - Blinding Lights (Live at the BRIT Awards)",
+				channel:    "BRIT Awards",
+				durationMs: 245000,
+			},
+		},
+		{
+			name: "karaoke with matching duration",
+			candidate: youtubeCandidate{
+				title:      "Blinding Lights Karaoke Version - The Weeknd",
+				channel:    "Sing King",
+				durationMs: 200000,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := scoreYouTubeCandidate(song, tt.candidate)
+			if tt.wantMin > 0 && got < tt.wantMin {
+				t.Errorf("score = %.3f, want >= %.2f", got, tt.wantMin)
+			}
+			if tt.wantMin == 0 && got >= MinYouTubeConfidence {
+				t.Errorf("score = %.3f, want below %.2f", got, MinYouTubeConfidence)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+// StartBatchResolver mencari YoutubeID untuk lagu yang belum punya sumber audio, di luar
+// jalur request, sampai kuota background hari itu habis.
+func (s *YoutubeService) StartBatchResolver(ctx context.Context) {
+	if s.apiKey == "" || s.interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			s.resolveMissing(ctx)
+
+			select {
+			case <-ctx.Done():
+				log.Println("🛑 [YouTube] Batch resolver stopped")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (s *YoutubeService) resolveMissing(ctx context.Context) {
+	resolved, missed := 0, 0
+	attempted := make(map[string]bool)
+	defer func() {
+		if resolved+missed > 0 {
+			log.Printf("🎬 [YouTube] Batch resolver: %d resolved, %d without match", resolved, missed)
+		}
+	}()
+
+	for ctx.Err() == nil {
+		songs, err := s.repo.SongsNeedingLookup(time.Now(), youtubeResolveBatch)
+		if err != nil {
+			log.Printf("⚠️ [YouTube] failed to list songs for lookup: %v", err)
+			return
+		}
+		progressed := false
+		for i := range songs {
+			if ctx.Err() != nil {
+				return
+			}
+			song := &songs[i]
+			// Lagu yang gagal dicatat ke negative cache bisa terambil lagi: cukup sekali per putaran
+			if attempted[song.ID] {
+				continue
+			}
+			attempted[song.ID] = true
+			progressed = true
+
+			match, err := s.lookup(song, nil, true)
+			if err != nil {
+				if errors.Is(err, ErrYouTubeQuotaExhausted) {
+					return
+				}
+				missed++
+				continue
+			}
+
+			song.YoutubeID = match.VideoID
+			song.YoutubeConfidence = match.Confidence
+			if err := s.songRepo.UpdateSong(song); err != nil {
+				log.Printf("⚠️ [YouTube] failed to save YoutubeID for song %s: %v", song.ID, err)
+				return
+			}
+			resolved++
+		}
+		if !progressed {
+			return
+		}
+	}
+}
+
+func (s *YoutubeService) QuotaStatus() (*YouTubeQuotaStatus, error) {
+	status, err := s.quota.status()
+	if err != nil {
+		return nil, err
+	}
+	if status.ActiveMisses, err = s.repo.CountActiveMisses(time.Now()); err != nil {
+		return nil, err
+	}
+	if status.SongsWithoutYoutubeID, err = s.repo.CountSongsWithoutYoutubeID(); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
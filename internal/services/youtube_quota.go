@@ -0,0 +1,179 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"back_music/internal/models"
+	"back_music/internal/repository"
+)
+
+// Biaya unit kuota YouTube Data API per request.
+const (
+	youtubeSearchCost = 100
+	youtubeVideosCost = 1
+)
+
+var ErrYouTubeQuotaExhausted = errors.New("youtube api daily quota exhausted")
+
+// YouTubeQuotaStatus adalah ringkasan pemakaian kuota untuk admin.
+type YouTubeQuotaStatus struct {
+	Day                   string                     `json:"day"`
+	Budget                int                        `json:"budget"`
+	Used                  int                        `json:"used"`
+	Remaining             int                        `json:"remaining"`
+	InteractiveReserve    int                        `json:"interactive_reserve"`
+	Exhausted             bool                       `json:"exhausted"`
+	ResetsAt              time.Time                  `json:"resets_at"`
+	SearchCalls           int                        `json:"search_calls"`
+	VideoCalls            int                        `json:"video_calls"`
+	ActiveMisses          int64                      `json:"active_misses"`
+	SongsWithoutYoutubeID int64                      `json:"songs_without_youtube_id"`
+	History               []models.YoutubeQuotaUsage `json:"history"`
+}
+
+var (
+	youtubeQuotaZoneOnce sync.Once
+	youtubeQuotaZone     *time.Location
+)
+
+// youtubeQuotaLocation: kuota YouTube direset tengah malam waktu Pacific.
+func youtubeQuotaLocation() *time.Location {
+	youtubeQuotaZoneOnce.Do(func() {
+		loc, err := time.LoadLocation("America/Los_Angeles")
+		if err != nil {
+			loc = time.FixedZone("PST", -8*60*60)
+		}
+		youtubeQuotaZone = loc
+	})
+	return youtubeQuotaZone
+}
+
+func youtubeQuotaDay(t time.Time) string {
+	return t.In(youtubeQuotaLocation()).Format("2006-01-02")
+}
+
+func youtubeQuotaReset(t time.Time) time.Time {
+	local := t.In(youtubeQuotaLocation())
+	return time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, local.Location())
+}
+
+// youtubeQuota mencatat pemakaian kuota harian. Unit dihitung sebelum request dikirim
+// (YouTube tetap menghitung request yang gagal) dan disimpan ke DB supaya restart
+// tidak mereset hitungan.
+type youtubeQuota struct {
+	repo    repository.YoutubeRepository
+	budget  int
+	reserve int
+
+	mu        sync.Mutex
+	day       string
+	used      int
+	exhausted bool
+}
+
+func newYouTubeQuota(repo repository.YoutubeRepository, budget, reserve int) *youtubeQuota {
+	if reserve > budget {
+		reserve = budget
+	}
+	return &youtubeQuota{repo: repo, budget: budget, reserve: reserve}
+}
+
+// rollover memuat hitungan hari kuota yang sedang berjalan; dipanggil dengan mu terkunci.
+func (q *youtubeQuota) rollover(now time.Time) {
+	day := youtubeQuotaDay(now)
+	if q.day == day {
+		return
+	}
+	q.day, q.used, q.exhausted = day, 0, false
+	usage, err := q.repo.GetQuotaUsage(day)
+	if err != nil {
+		log.Printf("⚠️ [YouTube] failed to load quota usage: %v", err)
+		return
+	}
+	q.used, q.exhausted = usage.Units, usage.Exhausted
+}
+
+// take memesan unit kuota. Request background hanya boleh memakai kuota di atas
+// cadangan untuk request user.
+func (q *youtubeQuota) take(cost int, background bool) error {
+	q.mu.Lock()
+	q.rollover(time.Now())
+	limit := q.budget
+	if background {
+		limit -= q.reserve
+	}
+	if q.exhausted || q.used+cost > limit {
+		q.mu.Unlock()
+		return ErrYouTubeQuotaExhausted
+	}
+	q.used += cost
+	day := q.day
+	q.mu.Unlock()
+
+	searchCalls, videoCalls := 0, 0
+	if cost == youtubeSearchCost {
+		searchCalls = 1
+	} else {
+		videoCalls = 1
+	}
+	if err := q.repo.AddQuotaUsage(day, cost, searchCalls, videoCalls); err != nil {
+		log.Printf("⚠️ [YouTube] failed to record quota usage: %v", err)
+	}
+	return nil
+}
+
+// markExhausted dipanggil saat API menolak dengan quotaExceeded walau hitungan lokal
+// masih ada sisa (mis. key dipakai aplikasi lain).
+func (q *youtubeQuota) markExhausted() {
+	q.mu.Lock()
+	q.rollover(time.Now())
+	already := q.exhausted
+	q.exhausted = true
+	day := q.day
+	q.mu.Unlock()
+
+	if already {
+		return
+	}
+	log.Printf("⚠️ [YouTube] daily quota exhausted, lookups paused until %s", youtubeQuotaReset(time.Now()).Format(time.RFC3339))
+	if err := q.repo.MarkQuotaExhausted(day); err != nil {
+		log.Printf("⚠️ [YouTube] failed to record quota exhaustion: %v", err)
+	}
+}
+
+func (q *youtubeQuota) status() (*YouTubeQuotaStatus, error) {
+	now := time.Now()
+	q.mu.Lock()
+	q.rollover(now)
+	day := q.day
+	q.mu.Unlock()
+
+	usage, err := q.repo.GetQuotaUsage(day)
+	if err != nil {
+		return nil, err
+	}
+	history, err := q.repo.ListQuotaUsage(14)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := q.budget - usage.Units
+	if remaining < 0 || usage.Exhausted {
+		remaining = 0
+	}
+	return &YouTubeQuotaStatus{
+		Day:                day,
+		Budget:             q.budget,
+		Used:               usage.Units,
+		Remaining:          remaining,
+		InteractiveReserve: q.reserve,
+		Exhausted:          usage.Exhausted,
+		ResetsAt:           youtubeQuotaReset(now),
+		SearchCalls:        usage.SearchCalls,
+		VideoCalls:         usage.VideoCalls,
+		History:            history,
+	}, nil
+}
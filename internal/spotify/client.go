@@ -0,0 +1,283 @@
+// Package spotify adalah client Spotify Web API dengan response bertipe,
+// token client-credentials, retry dengan exponential backoff, dan dukungan 429 Retry-After.
+package spotify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultAPIBaseURL      = "https://api.spotify.com/v1"
+	DefaultAccountsBaseURL = "https://accounts.spotify.com"
+
+	defaultTimeout     = 10 * time.Second
+	defaultMaxRetries  = 3
+	defaultBaseBackoff = 500 * time.Millisecond
+	defaultMaxBackoff  = 10 * time.Second
+	// maxRetryAfter: Retry-After lebih lama dari ini dikembalikan sebagai error, bukan ditunggu.
+	maxRetryAfter = 60 * time.Second
+)
+
+var ErrMissingCredentials = errors.New("spotify client credentials are not configured")
+
+// APIError adalah response non-2xx dari Spotify.
+type APIError struct {
+	StatusCode int
+	Message    string
+	RetryAfter time.Duration // hanya terisi untuk 429
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("spotify api error %d: %s", e.StatusCode, e.Message)
+}
+
+// IsRateLimited true jika err adalah 429 dari Spotify.
+func IsRateLimited(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests
+}
+
+// Config mengatur Client; field kosong memakai default.
+type Config struct {
+	ClientID        string
+	ClientSecret    string
+	APIBaseURL      string
+	AccountsBaseURL string
+	HTTPClient      *http.Client
+	MaxRetries      int
+	BaseBackoff     time.Duration
+	MaxBackoff      time.Duration
+}
+
+// Client aman dipakai dari banyak goroutine; http.Client dan token dipakai bersama.
+type Client struct {
+	clientID        string
+	clientSecret    string
+	apiBaseURL      string
+	accountsBaseURL string
+	httpClient      *http.Client
+	maxRetries      int
+	baseBackoff     time.Duration
+	maxBackoff      time.Duration
+
+	tokens TokenSource
+}
+
+func NewClient(cfg Config) *Client {
+	client := &Client{
+		clientID:        cfg.ClientID,
+		clientSecret:    cfg.ClientSecret,
+		apiBaseURL:      strings.TrimRight(cfg.APIBaseURL, "/"),
+		accountsBaseURL: strings.TrimRight(cfg.AccountsBaseURL, "/"),
+		httpClient:      cfg.HTTPClient,
+		maxRetries:      cfg.MaxRetries,
+		baseBackoff:     cfg.BaseBackoff,
+		maxBackoff:      cfg.MaxBackoff,
+	}
+	if client.apiBaseURL == "" {
+		client.apiBaseURL = DefaultAPIBaseURL
+	}
+	if client.accountsBaseURL == "" {
+		client.accountsBaseURL = DefaultAccountsBaseURL
+	}
+	if client.httpClient == nil {
+		client.httpClient = &http.Client{Timeout: defaultTimeout}
+	}
+	if client.maxRetries <= 0 {
+		client.maxRetries = defaultMaxRetries
+	}
+	if client.baseBackoff <= 0 {
+		client.baseBackoff = defaultBaseBackoff
+	}
+	if client.maxBackoff <= 0 {
+		client.maxBackoff = defaultMaxBackoff
+	}
+	client.tokens = newClientCredentialsSource(client)
+	return client
+}
+
+// Token mengembalikan access token client-credentials dari token source bersama.
+func (c *Client) Token(ctx context.Context) (string, error) {
+	return c.tokens.Token(ctx)
+}
+
+// TokenStats mengembalikan metrik refresh token (jumlah refresh, kegagalan, expiry).
+func (c *Client) TokenStats() TokenStats {
+	return c.tokens.Stats()
+}
+
+// get memanggil endpoint API dengan bearer token; 401 sekali memicu token baru.
+func (c *Client) get(ctx context.Context, path string, query url.Values, out interface{}) error {
+	endpoint := c.apiBaseURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	for attempt := 0; ; attempt++ {
+		token, err := c.Token(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = c.getWithToken(ctx, token, endpoint, out)
+
+		var apiErr *APIError
+		if attempt == 0 && errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized {
+			c.tokens.Invalidate(token)
+			continue
+		}
+		return err
+	}
+}
+
+// getWithToken memanggil URL lengkap dengan bearer token tertentu (token app atau token user).
+func (c *Client) getWithToken(ctx context.Context, token, endpoint string, out interface{}) error {
+	return c.doWithRetry(ctx, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		return req, nil
+	}, out)
+}
+
+// doWithRetry mengirim request dan mengulang untuk 429, 5xx, dan error jaringan.
+func (c *Client) doWithRetry(ctx context.Context, newRequest func() (*http.Request, error), out interface{}) error {
+	var lastErr error
+	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+		if attempt > 0 {
+			wait := c.backoff(attempt)
+			var apiErr *APIError
+			if errors.As(lastErr, &apiErr) && apiErr.RetryAfter > 0 {
+				wait = apiErr.RetryAfter
+			}
+			log.Printf("⏳ Spotify request retry %d/%d in %s: %v", attempt, c.maxRetries, wait, lastErr)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+
+		req, err := newRequest()
+		if err != nil {
+			return err
+		}
+
+		retry, err := c.do(req, out)
+		if err == nil {
+			return nil
+		}
+		if !retry || ctx.Err() != nil {
+			return err
+		}
+		lastErr = err
+	}
+	return lastErr
+}
+
+// do mengirim satu request. retry=true berarti error-nya sementara.
+func (c *Client) do(req *http.Request, out interface{}) (retry bool, err error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return true, err
+	}
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		if out == nil || len(body) == 0 {
+			return false, nil
+		}
+		if err := json.Unmarshal(body, out); err != nil {
+			return false, fmt.Errorf("invalid spotify response: %w", err)
+		}
+		return false, nil
+	}
+
+	apiErr := &APIError{StatusCode: resp.StatusCode, Message: errorMessage(body)}
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		apiErr.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+		return apiErr.RetryAfter <= maxRetryAfter, apiErr
+	case resp.StatusCode >= 500:
+		return true, apiErr
+	default:
+		return false, apiErr
+	}
+}
+
+// backoff: exponential dengan jitter, dibatasi maxBackoff.
+func (c *Client) backoff(attempt int) time.Duration {
+	wait := c.baseBackoff << (attempt - 1)
+	if wait > c.maxBackoff || wait <= 0 {
+		wait = c.maxBackoff
+	}
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+}
+
+// parseRetryAfter membaca Retry-After dalam detik (Spotify) atau HTTP-date.
+func parseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Second
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			seconds = 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(at); wait > 0 {
+			return wait
+		}
+	}
+	return time.Second
+}
+
+// errorMessage mengambil pesan dari format error API ({"error": {"message"}})
+// maupun format OAuth accounts ({"error": "...", "error_description": "..."}).
+func errorMessage(body []byte) string {
+	var payload struct {
+		Error            json.RawMessage `json:"error"`
+		ErrorDescription string          `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &payload); err == nil && len(payload.Error) > 0 {
+		var apiError struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(payload.Error, &apiError) == nil && apiError.Message != "" {
+			return apiError.Message
+		}
+		var code string
+		if json.Unmarshal(payload.Error, &code) == nil {
+			if payload.ErrorDescription != "" {
+				return code + ": " + payload.ErrorDescription
+			}
+			return code
+		}
+	}
+
+	message := strings.TrimSpace(string(body))
+	if len(message) > 200 {
+		message = message[:200]
+	}
+	return message
+}
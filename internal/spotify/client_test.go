@@ -0,0 +1,296 @@
+package spotify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stubSpotify melayani /api/token (client credentials, token bernomor urut) dan
+// /v1/albums/:id. apiResponses diputar berurutan; setelah habis selalu 200.
+type stubSpotify struct {
+	mu            sync.Mutex
+	tokenRequests int
+	apiTokens     []string
+	apiResponses  []stubResponse
+}
+
+type stubResponse struct {
+	status     int
+	retryAfter string
+	// rejectToken: 401 hanya jika bearer token sama dengan ini
+	rejectToken string
+}
+
+func newStubClient(t *testing.T, stub *stubSpotify) *Client {
+	t.Helper()
+	server := httptest.NewServer(stub)
+	t.Cleanup(server.Close)
+	return NewClient(Config{
+		ClientID:        "client-id",
+		ClientSecret:    "client-secret",
+		APIBaseURL:      server.URL + "/v1",
+		AccountsBaseURL: server.URL,
+		MaxRetries:      3,
+		BaseBackoff:     time.Millisecond,
+		MaxBackoff:      5 * time.Millisecond,
+	})
+}
+
+func (s *stubSpotify) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.URL.Path == "/api/token" {
+		s.tokenRequests++
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": fmt.Sprintf("token-%d", s.tokenRequests),
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+		})
+		return
+	}
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	s.apiTokens = append(s.apiTokens, token)
+	if len(s.apiResponses) > 0 {
+		next := s.apiResponses[0]
+		if next.rejectToken == "" || next.rejectToken == token {
+			s.apiResponses = s.apiResponses[1:]
+			if next.retryAfter != "" {
+				w.Header().Set("Retry-After", next.retryAfter)
+			}
+			w.WriteHeader(next.status)
+			fmt.Fprintf(w, `{"error":{"status":%d,"message":"stub error"}}`, next.status)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprint(w, `{"id":"album-1","name":"Stub Album","label":"Stub Records"}`)
+}
+
+func (s *stubSpotify) apiRequestCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.apiTokens)
+}
+
+func TestClientRetriesTransientErrors(t *testing.T) {
+	stub := &stubSpotify{apiResponses: []stubResponse{
+		{status: http.StatusInternalServerError},
+		{status: http.StatusBadGateway},
+	}}
+	client := newStubClient(t, stub)
+
+	album, err := client.GetAlbum(context.Background(), "album-1")
+	if err != nil {
+		t.Fatalf("GetAlbum: %v", err)
+	}
+	if album.Name != "Stub Album" {
+		t.Errorf("album name = %q", album.Name)
+	}
+	if got := stub.apiRequestCount(); got != 3 {
+		t.Errorf("api requests = %d, want 3", got)
+	}
+}
+
+func TestClientGivesUpAfterMaxRetries(t *testing.T) {
+	stub := &stubSpotify{}
+	for i := 0; i < 10; i++ {
+		stub.apiResponses = append(stub.apiResponses, stubResponse{status: http.StatusServiceUnavailable})
+	}
+	client := newStubClient(t, stub)
+
+	_, err := client.GetAlbum(context.Background(), "album-1")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("GetAlbum error = %v, want 503 APIError", err)
+	}
+	if got := stub.apiRequestCount(); got != 4 {
+		t.Errorf("api requests = %d, want 1 + 3 retries", got)
+	}
+}
+
+func TestClientDoesNotRetryClientErrors(t *testing.T) {
+	stub := &stubSpotify{apiResponses: []stubResponse{{status: http.StatusNotFound}}}
+	client := newStubClient(t, stub)
+
+	_, err := client.GetAlbum(context.Background(), "missing")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("GetAlbum error = %v, want 404 APIError", err)
+	}
+	if apiErr.Message != "stub error" {
+		t.Errorf("message = %q, want message from error body", apiErr.Message)
+	}
+	if got := stub.apiRequestCount(); got != 1 {
+		t.Errorf("api requests = %d, want 1", got)
+	}
+}
+
+func TestClientWaitsForRetryAfter(t *testing.T) {
+	stub := &stubSpotify{apiResponses: []stubResponse{
+		{status: http.StatusTooManyRequests, retryAfter: "1"},
+	}}
+	client := newStubClient(t, stub)
+
+	start := time.Now()
+	if _, err := client.GetAlbum(context.Background(), "album-1"); err != nil {
+		t.Fatalf("GetAlbum: %v", err)
+	}
+	// Backoff stub hanya beberapa ms, jadi jeda ini berasal dari Retry-After
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Errorf("retried after %s, want Retry-After of 1s", elapsed)
+	}
+	if got := stub.apiRequestCount(); got != 2 {
+		t.Errorf("api requests = %d, want 2", got)
+	}
+}
+
+func TestClientReturnsLongRetryAfter(t *testing.T) {
+	stub := &stubSpotify{apiResponses: []stubResponse{
+		{status: http.StatusTooManyRequests, retryAfter: "3600"},
+	}}
+	client := newStubClient(t, stub)
+
+	_, err := client.GetAlbum(context.Background(), "album-1")
+	if !IsRateLimited(err) {
+		t.Fatalf("GetAlbum error = %v, want rate limited", err)
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter != time.Hour {
+		t.Errorf("RetryAfter = %s, want 1h", apiErr.RetryAfter)
+	}
+	if got := stub.apiRequestCount(); got != 1 {
+		t.Errorf("api requests = %d, want 1 (not waited)", got)
+	}
+}
+
+func TestClientStopsRetryingWhenContextIsDone(t *testing.T) {
+	stub := &stubSpotify{apiResponses: []stubResponse{
+		{status: http.StatusTooManyRequests, retryAfter: "30"},
+	}}
+	client := newStubClient(t, stub)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := client.GetAlbum(ctx, "album-1")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("GetAlbum error = %v, want deadline exceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("returned after %s, want prompt cancellation", elapsed)
+	}
+}
+
+func TestClientRefreshesTokenOn401(t *testing.T) {
+	stub := &stubSpotify{apiResponses: []stubResponse{
+		{status: http.StatusUnauthorized, rejectToken: "token-1"},
+	}}
+	client := newStubClient(t, stub)
+
+	if _, err := client.GetAlbum(context.Background(), "album-1"); err != nil {
+		t.Fatalf("GetAlbum: %v", err)
+	}
+
+	stub.mu.Lock()
+	defer stub.mu.Unlock()
+	if want := []string{"token-1", "token-2"}; strings.Join(stub.apiTokens, ",") != strings.Join(want, ",") {
+		t.Errorf("api tokens = %v, want %v", stub.apiTokens, want)
+	}
+	if stub.tokenRequests != 2 {
+		t.Errorf("token requests = %d, want 2", stub.tokenRequests)
+	}
+	if stats := client.TokenStats(); stats.Refreshes != 2 {
+		t.Errorf("token refreshes = %d, want 2", stats.Refreshes)
+	}
+}
+
+func TestClientRefreshesTokenOnlyOnce(t *testing.T) {
+	stub := &stubSpotify{apiResponses: []stubResponse{
+		{status: http.StatusUnauthorized},
+		{status: http.StatusUnauthorized},
+	}}
+	client := newStubClient(t, stub)
+
+	_, err := client.GetAlbum(context.Background(), "album-1")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("GetAlbum error = %v, want 401 APIError", err)
+	}
+	if got := stub.apiRequestCount(); got != 2 {
+		t.Errorf("api requests = %d, want 2", got)
+	}
+}
+
+func TestExchangeCodeIsNotRetried(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, `{"error":"server_error","error_description":"try later"}`)
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		ClientID:        "client-id",
+		AccountsBaseURL: server.URL,
+		BaseBackoff:     time.Millisecond,
+	})
+	_, err := client.ExchangeCode(context.Background(), "code", "verifier", "http://localhost/callback")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Message != "server_error: try later" {
+		t.Fatalf("ExchangeCode error = %v, want server_error APIError", err)
+	}
+	if requests != 1 {
+		t.Errorf("token requests = %d, want 1 (authorization codes are single-use)", requests)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		min   time.Duration
+		max   time.Duration
+	}{
+		{name: "missing", value: "", min: time.Second, max: time.Second},
+		{name: "seconds", value: "7", min: 7 * time.Second, max: 7 * time.Second},
+		{name: "padded seconds", value: " 2 ", min: 2 * time.Second, max: 2 * time.Second},
+		{name: "negative", value: "-3", min: 0, max: 0},
+		{name: "http date", value: time.Now().Add(20 * time.Second).UTC().Format(http.TimeFormat), min: 18 * time.Second, max: 20 * time.Second},
+		{name: "past http date", value: time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), min: time.Second, max: time.Second},
+		{name: "garbage", value: "soon", min: time.Second, max: time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseRetryAfter(tt.value)
+			if got < tt.min || got > tt.max {
+				t.Errorf("parseRetryAfter(%q) = %s, want between %s and %s", tt.value, got, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestBackoffIsBounded(t *testing.T) {
+	client := NewClient(Config{BaseBackoff: 100 * time.Millisecond, MaxBackoff: time.Second})
+	for attempt := 1; attempt <= 40; attempt++ {
+		wait := client.backoff(attempt)
+		limit := min(100*time.Millisecond<<(attempt-1), time.Second)
+		if attempt > 10 {
+			limit = time.Second
+		}
+		if wait < limit/2 || wait > limit {
+			t.Errorf("backoff(%d) = %s, want between %s and %s", attempt, wait, limit/2, limit)
+		}
+	}
+}
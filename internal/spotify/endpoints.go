@@ -0,0 +1,58 @@
+package spotify
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// SearchTracks mencari track. market kosong = tanpa filter market.
+func (c *Client) SearchTracks(ctx context.Context, query, market string, limit int) ([]Track, error) {
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("type", "track")
+	params.Set("limit", strconv.Itoa(limit))
+	if market != "" {
+		params.Set("market", market)
+	}
+
+	var result SearchResponse
+	if err := c.get(ctx, "/search", params, &result); err != nil {
+		return nil, err
+	}
+	return result.Tracks.Items, nil
+}
+
+// GetAlbum mengambil full album object (termasuk label).
+func (c *Client) GetAlbum(ctx context.Context, albumID string) (*Album, error) {
+	var album Album
+	if err := c.get(ctx, "/albums/"+url.PathEscape(albumID), nil, &album); err != nil {
+		return nil, err
+	}
+	return &album, nil
+}
+
+// GetTracks mengambil beberapa track sekaligus (maksimal 50 ID per request).
+// ID yang tidak dikenal Spotify dilewati.
+func (c *Client) GetTracks(ctx context.Context, ids []string, market string) ([]Track, error) {
+	params := url.Values{}
+	params.Set("ids", strings.Join(ids, ","))
+	if market != "" {
+		params.Set("market", market)
+	}
+
+	var result struct {
+		Tracks []*Track `json:"tracks"`
+	}
+	if err := c.get(ctx, "/tracks", params, &result); err != nil {
+		return nil, err
+	}
+	tracks := make([]Track, 0, len(result.Tracks))
+	for _, track := range result.Tracks {
+		if track != nil && track.ID != "" {
+			tracks = append(tracks, *track)
+		}
+	}
+	return tracks, nil
+}
@@ -0,0 +1,122 @@
+package spotify
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// UserScopes adalah scope yang diminta saat user menautkan akun Spotify.
+var UserScopes = []string{
+	"user-library-read",
+	"user-top-read",
+	"playlist-read-private",
+	"playlist-read-collaborative",
+}
+
+// UserToken adalah token authorization-code milik satu user Spotify.
+type UserToken struct {
+	AccessToken  string
+	RefreshToken string // bisa kosong saat refresh (Spotify tidak selalu merotasi)
+	Scope        string
+	ExpiresAt    time.Time
+}
+
+type userTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	Scope        string `json:"scope"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// NewPKCEVerifier membuat code_verifier acak dan code_challenge S256-nya (RFC 7636).
+func NewPKCEVerifier() (verifier, challenge string, err error) {
+	buf := make([]byte, 64)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	verifier = base64.RawURLEncoding.EncodeToString(buf)
+	sum := sha256.Sum256([]byte(verifier))
+	return verifier, base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// AuthorizeURL membangun URL consent Spotify untuk flow authorization code + PKCE.
+func (c *Client) AuthorizeURL(redirectURI, state, codeChallenge string, scopes []string) string {
+	params := url.Values{}
+	params.Set("client_id", c.clientID)
+	params.Set("response_type", "code")
+	params.Set("redirect_uri", redirectURI)
+	params.Set("state", state)
+	params.Set("code_challenge_method", "S256")
+	params.Set("code_challenge", codeChallenge)
+	params.Set("scope", strings.Join(scopes, " "))
+	return c.accountsBaseURL + "/authorize?" + params.Encode()
+}
+
+// ExchangeCode menukar authorization code (callback) dengan token user. Code hanya
+// berlaku sekali, jadi request dikirim tanpa retry.
+func (c *Client) ExchangeCode(ctx context.Context, code, codeVerifier, redirectURI string) (*UserToken, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", redirectURI)
+	form.Set("code_verifier", codeVerifier)
+	return c.requestUserToken(ctx, form, false)
+}
+
+// RefreshUserToken memperbarui access token user dari refresh token.
+func (c *Client) RefreshUserToken(ctx context.Context, refreshToken string) (*UserToken, error) {
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("refresh_token", refreshToken)
+	return c.requestUserToken(ctx, form, true)
+}
+
+func (c *Client) requestUserToken(ctx context.Context, form url.Values, retry bool) (*UserToken, error) {
+	if c.clientID == "" {
+		return nil, ErrMissingCredentials
+	}
+	// PKCE: client_id di body, tanpa client secret
+	form.Set("client_id", c.clientID)
+
+	newRequest := func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.accountsBaseURL+"/api/token",
+			strings.NewReader(form.Encode()))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return req, nil
+	}
+
+	var resp userTokenResponse
+	var err error
+	if retry {
+		err = c.doWithRetry(ctx, newRequest, &resp)
+	} else {
+		var req *http.Request
+		if req, err = newRequest(); err == nil {
+			_, err = c.do(req, &resp)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resp.AccessToken == "" {
+		return nil, errors.New("empty access token in response")
+	}
+
+	return &UserToken{
+		AccessToken:  resp.AccessToken,
+		RefreshToken: resp.RefreshToken,
+		Scope:        resp.Scope,
+		ExpiresAt:    time.Now().Add(time.Duration(resp.ExpiresIn)*time.Second - tokenExpiryMargin),
+	}, nil
+}
@@ -0,0 +1,180 @@
+package spotify
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// tokenRefreshWindow: token yang akan kedaluwarsa dalam window ini diperbarui di background.
+	tokenRefreshWindow = 5 * time.Minute
+	// tokenExpiryMargin: token dianggap kedaluwarsa sedikit lebih awal (clock skew, request lambat).
+	tokenExpiryMargin = 30 * time.Second
+	// tokenFetchTimeout: refresh tidak ikut context caller karena hasilnya dipakai bersama.
+	tokenFetchTimeout = 30 * time.Second
+)
+
+// TokenSource memberi access token yang aman dipakai dari banyak goroutine.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+	// Invalidate membuang token yang ditolak API (401) supaya request berikutnya refresh.
+	Invalidate(token string)
+	Stats() TokenStats
+}
+
+// TokenStats adalah metrik refresh token untuk monitoring.
+type TokenStats struct {
+	Refreshes           int64      `json:"refreshes"`
+	Failures            int64      `json:"failures"`
+	ConsecutiveFailures int64      `json:"consecutive_failures"`
+	LastRefreshAt       *time.Time `json:"last_refresh_at,omitempty"`
+	LastFailureAt       *time.Time `json:"last_failure_at,omitempty"`
+	LastError           string     `json:"last_error,omitempty"`
+	ExpiresAt           *time.Time `json:"expires_at,omitempty"`
+}
+
+// clientCredentialsSource: refresh lewat singleflight sehingga caller bersamaan
+// hanya memicu satu request token.
+type clientCredentialsSource struct {
+	client *Client
+
+	mu          sync.RWMutex
+	accessToken string
+	expiresAt   time.Time
+	stats       TokenStats
+
+	group      singleflight.Group
+	refreshing atomic.Bool
+}
+
+func newClientCredentialsSource(client *Client) *clientCredentialsSource {
+	return &clientCredentialsSource{client: client}
+}
+
+func (s *clientCredentialsSource) Token(ctx context.Context) (string, error) {
+	s.mu.RLock()
+	token, expiresAt := s.accessToken, s.expiresAt
+	s.mu.RUnlock()
+
+	now := time.Now()
+	if token != "" && now.Before(expiresAt) {
+		// Masih valid tapi hampir habis: refresh proaktif tanpa menahan caller
+		if expiresAt.Sub(now) < tokenRefreshWindow && s.refreshing.CompareAndSwap(false, true) {
+			go s.refresh()
+		}
+		return token, nil
+	}
+
+	result := s.group.DoChan("token", func() (interface{}, error) {
+		return s.fetch()
+	})
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case res := <-result:
+		if res.Err != nil {
+			return "", res.Err
+		}
+		return res.Val.(string), nil
+	}
+}
+
+func (s *clientCredentialsSource) refresh() {
+	defer s.refreshing.Store(false)
+	_, _, _ = s.group.Do("token", func() (interface{}, error) {
+		return s.fetch()
+	})
+}
+
+func (s *clientCredentialsSource) Invalidate(token string) {
+	s.mu.Lock()
+	if s.accessToken == token {
+		s.accessToken = ""
+		s.expiresAt = time.Time{}
+	}
+	s.mu.Unlock()
+}
+
+func (s *clientCredentialsSource) Stats() TokenStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.stats
+}
+
+// fetch meminta token baru; hanya dipanggil di dalam singleflight.
+func (s *clientCredentialsSource) fetch() (string, error) {
+	// Bisa jadi goroutine lain baru saja refresh
+	s.mu.RLock()
+	if s.accessToken != "" && time.Until(s.expiresAt) > tokenRefreshWindow {
+		token := s.accessToken
+		s.mu.RUnlock()
+		return token, nil
+	}
+	s.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), tokenFetchTimeout)
+	defer cancel()
+
+	token, expiresIn, err := s.client.requestToken(ctx)
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.stats.Failures++
+		s.stats.ConsecutiveFailures++
+		s.stats.LastFailureAt = &now
+		s.stats.LastError = err.Error()
+		log.Printf("⚠️ Spotify token refresh failed (%d consecutive): %v", s.stats.ConsecutiveFailures, err)
+		return "", fmt.Errorf("failed to get spotify token: %w", err)
+	}
+
+	expiresAt := now.Add(expiresIn - tokenExpiryMargin)
+	s.accessToken = token
+	s.expiresAt = expiresAt
+	s.stats.Refreshes++
+	s.stats.ConsecutiveFailures = 0
+	s.stats.LastRefreshAt = &now
+	s.stats.ExpiresAt = &expiresAt
+	return token, nil
+}
+
+// requestToken menjalankan client-credentials grant ke accounts service.
+func (c *Client) requestToken(ctx context.Context) (string, time.Duration, error) {
+	if c.clientID == "" || c.clientSecret == "" {
+		return "", 0, ErrMissingCredentials
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	auth := base64.StdEncoding.EncodeToString([]byte(c.clientID + ":" + c.clientSecret))
+
+	var token tokenResponse
+	err := c.doWithRetry(ctx, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.accountsBaseURL+"/api/token",
+			strings.NewReader(form.Encode()))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Basic "+auth)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return req, nil
+	}, &token)
+	if err != nil {
+		return "", 0, err
+	}
+	if token.AccessToken == "" {
+		return "", 0, fmt.Errorf("empty access token in response")
+	}
+	return token.AccessToken, time.Duration(token.ExpiresIn) * time.Second, nil
+}
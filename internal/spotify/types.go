@@ -0,0 +1,116 @@
+package spotify
+
+// Image adalah cover art / foto dari Spotify (width/height bisa kosong).
+type Image struct {
+	URL    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+type SimplifiedArtist struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type SimplifiedAlbum struct {
+	ID                   string             `json:"id"`
+	Name                 string             `json:"name"`
+	AlbumType            string             `json:"album_type"`
+	ReleaseDate          string             `json:"release_date"`
+	ReleaseDatePrecision string             `json:"release_date_precision"`
+	TotalTracks          int                `json:"total_tracks"`
+	Images               []Image            `json:"images"`
+	Artists              []SimplifiedArtist `json:"artists"`
+}
+
+// Album adalah full album object (punya label, tidak ada di SimplifiedAlbum).
+type Album struct {
+	SimplifiedAlbum
+	Label      string `json:"label"`
+	Popularity int    `json:"popularity"`
+}
+
+type ExternalIDs struct {
+	ISRC string `json:"isrc"`
+}
+
+type Track struct {
+	ID          string             `json:"id"`
+	Name        string             `json:"name"`
+	Popularity  int                `json:"popularity"`
+	DurationMs  int                `json:"duration_ms"`
+	Explicit    bool               `json:"explicit"`
+	PreviewURL  string             `json:"preview_url"`
+	DiscNumber  int                `json:"disc_number"`
+	TrackNumber int                `json:"track_number"`
+	Artists     []SimplifiedArtist `json:"artists"`
+	Album       SimplifiedAlbum    `json:"album"`
+	ExternalIDs ExternalIDs        `json:"external_ids"`
+}
+
+// ArtistNames mengembalikan nama semua artist track sesuai urutan kredit.
+func (t *Track) ArtistNames() []string {
+	names := make([]string, 0, len(t.Artists))
+	for _, artist := range t.Artists {
+		names = append(names, artist.Name)
+	}
+	return names
+}
+
+// Paging adalah wrapper list dengan pagination dari Spotify.
+type Paging[T any] struct {
+	Href   string `json:"href"`
+	Items  []T    `json:"items"`
+	Limit  int    `json:"limit"`
+	Offset int    `json:"offset"`
+	Total  int    `json:"total"`
+	Next   string `json:"next"`
+}
+
+type SearchResponse struct {
+	Tracks Paging[Track] `json:"tracks"`
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// PrivateUser adalah profil user dari /me.
+type PrivateUser struct {
+	ID          string  `json:"id"`
+	DisplayName string  `json:"display_name"`
+	Country     string  `json:"country"`
+	Product     string  `json:"product"`
+	Images      []Image `json:"images"`
+}
+
+type SavedTrack struct {
+	AddedAt string `json:"added_at"`
+	Track   Track  `json:"track"`
+}
+
+type SimplifiedPlaylist struct {
+	ID            string  `json:"id"`
+	Name          string  `json:"name"`
+	Description   string  `json:"description"`
+	Collaborative bool    `json:"collaborative"`
+	Public        bool    `json:"public"`
+	SnapshotID    string  `json:"snapshot_id"`
+	Images        []Image `json:"images"`
+	Owner         struct {
+		ID          string `json:"id"`
+		DisplayName string `json:"display_name"`
+	} `json:"owner"`
+	Tracks struct {
+		Total int `json:"total"`
+	} `json:"tracks"`
+}
+
+// PlaylistTrack: Track bisa null (lagu dihapus) dan IsLocal untuk file lokal user.
+type PlaylistTrack struct {
+	AddedAt string `json:"added_at"`
+	IsLocal bool   `json:"is_local"`
+	Track   *Track `json:"track"`
+}
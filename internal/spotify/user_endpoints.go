@@ -0,0 +1,71 @@
+package spotify
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+// Endpoint di file ini memakai token user (hasil ExchangeCode/RefreshUserToken), bukan token app.
+
+func (c *Client) userGet(ctx context.Context, token, path string, query url.Values, out interface{}) error {
+	endpoint := c.apiBaseURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	return c.getWithToken(ctx, token, endpoint, out)
+}
+
+func pageQuery(offset, limit int) url.Values {
+	query := url.Values{}
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set("offset", strconv.Itoa(offset))
+	return query
+}
+
+func (c *Client) CurrentUser(ctx context.Context, token string) (*PrivateUser, error) {
+	var user PrivateUser
+	if err := c.userGet(ctx, token, "/me", nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// SavedTracks mengembalikan "Liked Songs" user (limit maks 50).
+func (c *Client) SavedTracks(ctx context.Context, token string, offset, limit int) (*Paging[SavedTrack], error) {
+	var page Paging[SavedTrack]
+	if err := c.userGet(ctx, token, "/me/tracks", pageQuery(offset, limit), &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// TopTracks: timeRange = short_term | medium_term | long_term.
+func (c *Client) TopTracks(ctx context.Context, token, timeRange string, limit int) ([]Track, error) {
+	query := pageQuery(0, limit)
+	query.Set("time_range", timeRange)
+
+	var page Paging[Track]
+	if err := c.userGet(ctx, token, "/me/top/tracks", query, &page); err != nil {
+		return nil, err
+	}
+	return page.Items, nil
+}
+
+func (c *Client) UserPlaylists(ctx context.Context, token string, offset, limit int) (*Paging[SimplifiedPlaylist], error) {
+	var page Paging[SimplifiedPlaylist]
+	if err := c.userGet(ctx, token, "/me/playlists", pageQuery(offset, limit), &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// PlaylistTracks mengembalikan item playlist (limit maks 100).
+func (c *Client) PlaylistTracks(ctx context.Context, token, playlistID string, offset, limit int) (*Paging[PlaylistTrack], error) {
+	var page Paging[PlaylistTrack]
+	path := "/playlists/" + url.PathEscape(playlistID) + "/tracks"
+	if err := c.userGet(ctx, token, path, pageQuery(offset, limit), &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
@@ -0,0 +1,50 @@
+// Package storage menyimpan object biner (file audio upload) di filesystem lokal atau
+// object storage S3-compatible (AWS S3, MinIO, R2, ...).
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound   = errors.New("object not found")
+	ErrInvalidKey = errors.New("invalid object key")
+)
+
+// ObjectInfo adalah metadata object yang tersimpan.
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	ETag         string
+	LastModified time.Time
+}
+
+// BlobStore adalah backend penyimpanan object. Key memakai "/" sebagai pemisah
+// (mis. "audio/ab/abcdef....mp3").
+type BlobStore interface {
+	// Put menyimpan body sebagai key. size boleh -1 jika tidak diketahui.
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Get membaca object mulai offset; length < 0 berarti sampai akhir object.
+	Get(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error)
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// cleanKey menolak key kosong, absolut, atau yang keluar dari root ("..").
+func cleanKey(key string) (string, error) {
+	key = strings.TrimSpace(key)
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return "", ErrInvalidKey
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return "", ErrInvalidKey
+		}
+	}
+	return key, nil
+}
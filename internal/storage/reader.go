@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ObjectReader membuat object di BlobStore bisa di-seek (mis. untuk http.ServeContent).
+// Object baru dibuka saat Read pertama setelah Seek, dengan Get mulai dari offset tersebut.
+// Tanpa LimitToRange setiap Get membaca sampai akhir object; dengan LimitToRange Get hanya
+// meminta bagian yang diminta client, dan reader membuka Get baru jika pembacaan melewatinya.
+type ObjectReader struct {
+	ctx    context.Context
+	store  BlobStore
+	key    string
+	size   int64
+	offset int64
+	body   io.ReadCloser
+
+	// [windowStart, windowEnd) adalah byte yang diminta Range request; windowEnd 0 = tanpa batas.
+	windowStart int64
+	windowEnd   int64
+}
+
+// NewObjectReader membuat reader untuk object yang metadata-nya sudah di-Stat.
+func NewObjectReader(ctx context.Context, store BlobStore, info *ObjectInfo) *ObjectReader {
+	return &ObjectReader{ctx: ctx, store: store, key: info.Key, size: info.Size}
+}
+
+// LimitToRange membatasi Get ke rentang header Range ("bytes=0-1023", "bytes=500-",
+// "bytes=-200"; beberapa range digabung jadi satu rentang). Header yang tidak valid
+// diabaikan, jadi pembacaan tetap benar apa pun keputusan ServeContent.
+func (r *ObjectReader) LimitToRange(header string) {
+	start, end, ok := rangeSpan(header, r.size)
+	if !ok {
+		return
+	}
+	r.windowStart, r.windowEnd = start, end
+}
+
+func (r *ObjectReader) Read(p []byte) (int, error) {
+	for {
+		if r.offset >= r.size {
+			return 0, io.EOF
+		}
+		if r.body == nil {
+			length := int64(-1)
+			if r.windowEnd > 0 && r.offset >= r.windowStart && r.offset < r.windowEnd {
+				length = r.windowEnd - r.offset
+			}
+			body, err := r.store.Get(r.ctx, r.key, r.offset, length)
+			if err != nil {
+				return 0, err
+			}
+			r.body = body
+		}
+		n, err := r.body.Read(p)
+		r.offset += int64(n)
+		if errors.Is(err, io.EOF) && r.offset < r.size {
+			// Akhir rentang yang diminta, bukan akhir object: Get berikutnya melanjutkan
+			r.body.Close()
+			r.body = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (r *ObjectReader) Seek(offset int64, whence int) (int64, error) {
+	var next int64
+	switch whence {
+	case io.SeekStart:
+		next = offset
+	case io.SeekCurrent:
+		next = r.offset + offset
+	case io.SeekEnd:
+		next = r.size + offset
+	default:
+		return 0, errors.New("storage: invalid whence")
+	}
+	if next < 0 {
+		return 0, errors.New("storage: negative position")
+	}
+	if next != r.offset && r.body != nil {
+		r.body.Close()
+		r.body = nil
+	}
+	r.offset = next
+	return next, nil
+}
+
+func (r *ObjectReader) Close() error {
+	if r.body == nil {
+		return nil
+	}
+	err := r.body.Close()
+	r.body = nil
+	return err
+}
+
+// rangeSpan mengembalikan rentang [start, end) yang mencakup semua range di header.
+func rangeSpan(header string, size int64) (int64, int64, bool) {
+	spec, ok := strings.CutPrefix(strings.TrimSpace(header), "bytes=")
+	if !ok || size <= 0 {
+		return 0, 0, false
+	}
+	spanStart, spanEnd := size, int64(0)
+	for _, part := range strings.Split(spec, ",") {
+		first, last, ok := strings.Cut(strings.TrimSpace(part), "-")
+		if !ok {
+			return 0, 0, false
+		}
+		var start, end int64
+		if first == "" {
+			// Suffix range: n byte terakhir
+			n, err := strconv.ParseInt(last, 10, 64)
+			if err != nil || n <= 0 {
+				return 0, 0, false
+			}
+			start, end = max(size-n, 0), size
+		} else {
+			var err error
+			if start, err = strconv.ParseInt(first, 10, 64); err != nil || start < 0 {
+				return 0, 0, false
+			}
+			end = size
+			if last != "" {
+				stop, err := strconv.ParseInt(last, 10, 64)
+				if err != nil || stop < start {
+					return 0, 0, false
+				}
+				end = min(stop+1, size)
+			}
+		}
+		if start >= size {
+			continue
+		}
+		spanStart, spanEnd = min(spanStart, start), max(spanEnd, end)
+	}
+	if spanEnd <= spanStart {
+		return 0, 0, false
+	}
+	return spanStart, spanEnd, true
+}
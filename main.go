@@ -60,13 +60,35 @@ func main() {
 	// =========================
 	userRepo := repository.NewUserRepository()
 	songRepo := repository.NewSongRepository()
+	featureStatRepo := repository.NewFeatureStatRepository()
+	playlistRepo := repository.NewPlaylistRepository()
+	playEventRepo := repository.NewPlayEventRepository()
+	chartRepo := repository.NewChartRepository()
+	artistRepo := repository.NewArtistRepository()
+	albumRepo := repository.NewAlbumRepository()
+	catalogRepo := repository.NewCatalogRepository()
+	auditLogRepo := repository.NewAuditLogRepository()
+	jobRepo := repository.NewJobRepository()
+	duplicateRepo := repository.NewDuplicateRepository()
+	spotifyAccountRepo := repository.NewSpotifyAccountRepository()
+	scrobbleRepo := repository.NewScrobbleRepository()
+	songSourceRepo := repository.NewSongSourceRepository()
+	youtubeRepo := repository.NewYoutubeRepository()
 
 	// =========================
 	// INIT SERVICES
 	// =========================
-	spotifyService := services.NewSpotifyService(songRepo)
+	spotifyClient := services.NewSpotifyClient()
 
-	contentService := services.NewContentBasedService(songRepo)
+	blobStore, err := services.NewBlobStore()
+	if err != nil {
+		log.Println("❌ Audio storage init failed:", err)
+		os.Exit(1)
+	}
+	spotifyService := services.NewSpotifyService(spotifyClient, songRepo, artistRepo, albumRepo)
+
+	featureStatsService := services.NewFeatureStatsService(featureStatRepo, songRepo)
+	contentService := services.NewContentBasedService(songRepo, featureStatsService)
 	collaborativeService := services.NewCollaborativeService(userRepo, songRepo)
 	hybridService := services.NewHybridService(contentService, collaborativeService)
 
@@ -76,7 +98,56 @@ func main() {
 		hybridService,
 	)
 
-	youtubeSvc := services.NewYouTubeService()
+	youtubeSvc := services.NewYouTubeService(youtubeRepo, songRepo)
+
+	discoverService := services.NewDiscoverService(
+		contentService,
+		collaborativeService,
+		songRepo,
+		userRepo,
+		playlistRepo,
+	)
+
+	chartService := services.NewChartService(playEventRepo, chartRepo)
+	artistService := services.NewArtistService(artistRepo, songRepo, featureStatsService)
+	searchSuggestService := services.NewSearchSuggestService(songRepo, artistRepo, albumRepo, userRepo, catalogRepo)
+	catalogService := services.NewCatalogService(catalogRepo, songRepo, artistRepo, auditLogRepo, songSourceRepo, blobStore)
+	jobRunner := services.NewJobRunner(jobRepo)
+	catalogImportService := services.NewCatalogImportService(songRepo, artistRepo, jobRunner, auditLogRepo)
+	duplicateService := services.NewDuplicateService(duplicateRepo, songRepo, catalogService)
+	spotifyLinkService := services.NewSpotifyLinkService(spotifyClient, spotifyAccountRepo, songRepo, playlistRepo, spotifyService, jobRunner)
+	historyService := services.NewListeningHistoryService(spotifyClient, songRepo, playEventRepo, spotifyService, jobRunner)
+	scrobbleService := services.NewScrobbleService(scrobbleRepo, songRepo, playEventRepo)
+	audioSourceService := services.NewAudioSourceService(songSourceRepo, songRepo, youtubeSvc, blobStore)
+	uploadService := services.NewUploadService(songRepo, songSourceRepo, blobStore)
+
+	// =========================
+	// BACKGROUND JOBS
+	// =========================
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	discoverService.StartScheduler(jobsCtx)
+	chartService.StartScheduler(jobsCtx)
+	searchSuggestService.StartRefresher(jobsCtx)
+	scrobbleService.StartWorker(jobsCtx)
+	youtubeSvc.StartBatchResolver(jobsCtx)
+	audioSourceService.StartVerifier(jobsCtx)
+	jobRunner.RecoverInterrupted()
+
+	// Lagu lama belum punya relasi artist, isi dari string Song.Artist
+	go func() {
+		if _, err := artistService.BackfillSongArtists(); err != nil {
+			log.Println("⚠️ Artist backfill failed:", err)
+		}
+	}()
+
+	// Isi antrian review duplikat saat startup
+	go func() {
+		if _, err := duplicateService.Scan(); err != nil {
+			log.Println("⚠️ Duplicate scan failed:", err)
+		}
+	}()
 
 	// =========================
 	// INIT HANDLERS
@@ -86,8 +157,9 @@ func main() {
 	songHandler := handlers.NewSongHandler(
 		songRepo,
 		userRepo,
+		playEventRepo,
 		spotifyService,
-		youtubeSvc,
+		audioSourceService,
 	)
 
 	recommendationHandler := handlers.NewRecommendationHandler(
@@ -99,6 +171,16 @@ func main() {
 		songRepo,
 	)
 
+	playlistHandler := handlers.NewPlaylistHandler(playlistRepo, songRepo, discoverService)
+	chartHandler := handlers.NewChartHandler(chartService, songRepo)
+	artistHandler := handlers.NewArtistHandler(artistService)
+	albumHandler := handlers.NewAlbumHandler(albumRepo, songRepo)
+	searchHandler := handlers.NewSearchHandler(searchSuggestService)
+	adminHandler := handlers.NewAdminHandler(catalogService, catalogImportService, duplicateService, spotifyService, jobRunner, audioSourceService, youtubeSvc, uploadService)
+	spotifyHandler := handlers.NewSpotifyHandler(spotifyLinkService)
+	historyHandler := handlers.NewHistoryHandler(historyService)
+	scrobbleHandler := handlers.NewScrobbleHandler(scrobbleService, songRepo)
+
 	// =========================
 	// ROUTES
 	// =========================
@@ -106,6 +188,15 @@ func main() {
 		authHandler,
 		songHandler,
 		recommendationHandler,
+		playlistHandler,
+		chartHandler,
+		artistHandler,
+		albumHandler,
+		searchHandler,
+		adminHandler,
+		spotifyHandler,
+		historyHandler,
+		scrobbleHandler,
 		userRepo,
 	)
 
@@ -146,6 +237,8 @@ func main() {
 		<-quit
 
 		log.Println("🛑 Shutting down server...")
+		stopJobs()
+		jobRunner.Shutdown()
 
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()